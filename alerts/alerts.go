@@ -0,0 +1,161 @@
+// Package alerts is a source independent model of traffic alerts, so
+// consumers can use SL deviations and GTFS-RT service alerts alike.
+package alerts
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/nobina/go-trafiklab/sl/deviations"
+)
+
+type Source string
+
+const (
+	SourceDeviations   Source = "deviations"
+	SourceGTFSRT       Source = "gtfs-rt"
+	SourcePlannedWorks Source = "planned-works"
+)
+
+type Severity int
+
+const (
+	SeverityUnknown Severity = iota
+	SeverityInfo
+	SeverityWarning
+	SeveritySevere
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeveritySevere:
+		return "severe"
+	}
+	return "unknown"
+}
+
+// Text is the message of an alert in one language.
+type Text struct {
+	Header      string
+	Description string
+	URL         string
+}
+
+// Period is when an alert applies. A zero Start or End is open.
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains reports whether t is within p.
+func (p Period) Contains(t time.Time) bool {
+	return (p.Start.IsZero() || !t.Before(p.Start)) && (p.End.IsZero() || t.Before(p.End))
+}
+
+type Alert struct {
+	ID     string
+	Source Source
+	// Texts is keyed by language, e.g. "sv" or "en".
+	Texts    map[string]Text
+	Periods  []Period
+	Lines    []string
+	Stops    []string
+	Cause    string
+	Effect   string
+	Severity Severity
+}
+
+// Text returns the text in lang, falling back to Swedish and then any
+// language.
+func (a *Alert) Text(lang string) Text {
+	if t, ok := a.Texts[lang]; ok {
+		return t
+	}
+	if t, ok := a.Texts["sv"]; ok {
+		return t
+	}
+	for _, t := range a.Texts {
+		return t
+	}
+	return Text{}
+}
+
+// ActiveAt reports whether any period contains t. Alerts without periods
+// are always active.
+func (a *Alert) ActiveAt(t time.Time) bool {
+	if len(a.Periods) == 0 {
+		return true
+	}
+	for _, p := range a.Periods {
+		if p.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Importance levels of deviations from which they are severe or warnings.
+// They are shared by the packages grading deviations, e.g. networkstatus
+// and notifier.
+const (
+	SevereImportanceLevel  = 7
+	WarningImportanceLevel = 4
+)
+
+// FromDeviation converts an SL deviation. Lines are line designations and
+// stops are stop area ids.
+func FromDeviation(d *deviations.DeviationsResponse) Alert {
+	a := Alert{
+		ID:      strconv.Itoa(d.DeviationCaseID),
+		Source:  SourceDeviations,
+		Texts:   map[string]Text{},
+		Periods: []Period{{Start: d.Publish.From, End: d.Publish.Upto}},
+	}
+	for _, v := range d.MessageVariants {
+		a.Texts[v.Language] = Text{
+			Header:      v.Header,
+			Description: v.Details,
+			URL:         v.Weblink,
+		}
+	}
+	for _, l := range d.Scope.Lines {
+		a.Lines = append(a.Lines, l.Designation)
+	}
+	for _, s := range d.Scope.StopAreas {
+		a.Stops = append(a.Stops, strconv.Itoa(s.ID))
+	}
+	switch {
+	case d.Priority.ImportanceLevel >= SevereImportanceLevel:
+		a.Severity = SeveritySevere
+	case d.Priority.ImportanceLevel >= WarningImportanceLevel:
+		a.Severity = SeverityWarning
+	case d.Priority.ImportanceLevel > 0:
+		a.Severity = SeverityInfo
+	}
+	return a
+}
+
+// FromDeviations converts a list of deviations.
+func FromDeviations(devs []*deviations.DeviationsResponse) []Alert {
+	alerts := make([]Alert, 0, len(devs))
+	for _, d := range devs {
+		alerts = append(alerts, FromDeviation(d))
+	}
+	return alerts
+}
+
+// FromPlannedWorks converts deviations returned by
+// deviations.Client.PlannedWorks. Their period is the publish window.
+func FromPlannedWorks(devs []*deviations.DeviationsResponse) []Alert {
+	alerts := make([]Alert, 0, len(devs))
+	for _, d := range devs {
+		a := FromDeviation(d)
+		a.Source = SourcePlannedWorks
+		alerts = append(alerts, a)
+	}
+	return alerts
+}
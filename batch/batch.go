@@ -0,0 +1,124 @@
+// Package batch runs independent SDK calls concurrently, e.g. the trips,
+// departures and stop lookups needed to render one screen.
+package batch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Call is one call of a batch. Timeout overrides the default timeout of
+// the executor, 0 meaning the default.
+type Call struct {
+	Fn      func(ctx context.Context) (any, error)
+	Timeout time.Duration
+}
+
+// Func wraps a typed call, e.g. a bound client method.
+func Func[T any](fn func(ctx context.Context) (T, error)) Call {
+	return Call{Fn: func(ctx context.Context) (any, error) {
+		return fn(ctx)
+	}}
+}
+
+// WithTimeout returns c with its own timeout.
+func (c Call) WithTimeout(d time.Duration) Call {
+	c.Timeout = d
+	return c
+}
+
+type Result struct {
+	Value    any
+	Err      error
+	Duration time.Duration
+}
+
+// Value returns the value of r as T, as wrapped by Func.
+func Value[T any](r Result) (T, error) {
+	var zero T
+	if r.Err != nil {
+		return zero, r.Err
+	}
+	v, ok := r.Value.(T)
+	if !ok && r.Value != nil {
+		return zero, fmt.Errorf("result is %T, not %T", r.Value, zero)
+	}
+	return v, nil
+}
+
+// Executor limits the number of calls in flight across all batches it
+// runs.
+type Executor struct {
+	sem     chan struct{}
+	timeout time.Duration
+}
+
+type Option func(*Executor)
+
+// WithTimeout sets the default timeout of calls. Calls have no timeout of
+// their own by default.
+func WithTimeout(d time.Duration) Option {
+	return func(e *Executor) {
+		e.timeout = d
+	}
+}
+
+// New allows limit calls in flight at once.
+func New(limit int, opts ...Option) *Executor {
+	e := &Executor{sem: make(chan struct{}, max(limit, 1))}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Run runs calls and returns their results in the same order. Calls not
+// yet started when ctx is done fail with the error of ctx. A panicking
+// call fails rather than crashing the batch.
+func (e *Executor) Run(ctx context.Context, calls ...Call) []Result {
+	results := make([]Result, len(calls))
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		select {
+		case e.sem <- struct{}{}:
+		case <-ctx.Done():
+			for j := i; j < len(calls); j++ {
+				results[j].Err = ctx.Err()
+			}
+			wg.Wait()
+			return results
+		}
+		wg.Add(1)
+		go func(i int, call Call) {
+			defer wg.Done()
+			defer func() { <-e.sem }()
+			results[i] = e.run(ctx, call)
+		}(i, call)
+	}
+	wg.Wait()
+	return results
+}
+
+func (e *Executor) run(ctx context.Context, call Call) (res Result) {
+	timeout := call.Timeout
+	if timeout == 0 {
+		timeout = e.timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	defer func() {
+		res.Duration = time.Since(start)
+		if r := recover(); r != nil {
+			res = Result{Err: fmt.Errorf("call panicked: %v", r), Duration: res.Duration}
+		}
+	}()
+	res.Value, res.Err = call.Fn(ctx)
+	return res
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/nobina/go-trafiklab/requests"
+)
+
+// Cache stores raw response bodies. Implementations must be safe for
+// concurrent use.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+}
+
+// Key returns the cache key for u, leaving out the api key so cached
+// responses are shared between keys.
+func Key(u *url.URL) string {
+	q := u.Query()
+	q.Del("key")
+	k := *u
+	k.RawQuery = q.Encode()
+	return k.String()
+}
+
+// Middleware caches successful GET responses matched by match for ttl. A
+// nil match caches all GET requests.
+func Middleware(c Cache, ttl time.Duration, match func(*http.Request) bool) requests.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return requests.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodGet || (match != nil && !match(req)) {
+				return next.RoundTrip(req)
+			}
+
+			key := Key(req.URL)
+			if b, ok := c.Get(req.Context(), key); ok {
+				return &http.Response{
+					Status:        "200 OK",
+					StatusCode:    http.StatusOK,
+					Proto:         "HTTP/1.1",
+					ProtoMajor:    1,
+					ProtoMinor:    1,
+					Header:        http.Header{"X-Cache": {"HIT"}},
+					Body:          io.NopCloser(bytes.NewReader(b)),
+					ContentLength: int64(len(b)),
+					Request:       req,
+				}, nil
+			}
+
+			res, err := next.RoundTrip(req)
+			if err != nil || res.StatusCode != http.StatusOK {
+				return res, err
+			}
+			b, err := io.ReadAll(res.Body)
+			res.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			c.Set(req.Context(), key, b, ttl)
+			res.Body = io.NopCloser(bytes.NewReader(b))
+			return res, nil
+		})
+	}
+}
+
+// PathMatcher matches requests whose path ends with suffix.
+func PathMatcher(suffix string) func(*http.Request) bool {
+	return func(req *http.Request) bool {
+		p := req.URL.Path
+		return len(p) >= len(suffix) && p[len(p)-len(suffix):] == suffix
+	}
+}
@@ -0,0 +1,247 @@
+// Package commute runs recurring trip searches, such as home to work every
+// weekday at 07:30, and reports when the best option is materially worse
+// than usual.
+package commute
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nobina/go-trafiklab/sl/travelplanner"
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+// Defaults for what counts as a material deviation from the baseline.
+const (
+	DefaultExtraDuration = 10 * time.Minute
+	DefaultExtraChanges  = 1
+)
+
+// Summary is what a trip is compared on.
+type Summary struct {
+	Departure time.Time
+	Arrival   time.Time
+	Duration  time.Duration
+	Changes   int
+}
+
+func Summarize(trip *travelplanner.Trip) (Summary, error) {
+	dep, arr, err := trip.Times()
+	if err != nil {
+		return Summary{}, err
+	}
+	return Summary{
+		Departure: dep,
+		Arrival:   arr,
+		Duration:  arr.Sub(dep),
+		Changes:   trip.Changes(),
+	}, nil
+}
+
+// Commute is a trip search repeated at a wall clock time in Stockholm.
+type Commute struct {
+	Name string
+	// Request is copied for every search, with Time set to the run.
+	Request travelplanner.TripsRequest
+	// Weekdays the commute runs on, every day if empty.
+	Weekdays []time.Weekday
+	// At is the wall clock time of the search, e.g. "07:30".
+	At string
+	// Baseline is the usual trip. If nil, the first search sets it, so a
+	// Commute must not be checked concurrently.
+	Baseline *Summary
+}
+
+// next returns the first run of c after now.
+func (c *Commute) next(now time.Time) (time.Time, error) {
+	at, err := time.Parse("15:04", c.At)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: %w", c.At, err)
+	}
+	loc := timeutils.EuropeStockholm()
+	now = now.In(loc)
+	for i := 0; i <= 7; i++ {
+		day := now.AddDate(0, 0, i)
+		run := time.Date(day.Year(), day.Month(), day.Day(), at.Hour(), at.Minute(), 0, 0, loc)
+		if !run.After(now) {
+			continue
+		}
+		if len(c.Weekdays) == 0 || slices.Contains(c.Weekdays, run.Weekday()) {
+			return run, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("commute %q never runs", c.Name)
+}
+
+// Report is sent when the best trip of a run deviates from the baseline.
+type Report struct {
+	Commute  *Commute
+	Run      time.Time
+	Trip     *travelplanner.Trip
+	Best     Summary
+	Baseline Summary
+	// Reasons describe the deviations, e.g. "12 min longer".
+	Reasons []string
+}
+
+// Scheduler runs commutes at their scheduled times.
+type Scheduler struct {
+	client        *travelplanner.TravelPlannerClient
+	commutes      []*Commute
+	extraDuration time.Duration
+	extraChanges  int
+	clock         timeutils.Clock
+	onError       func(*Commute, error)
+}
+
+type Option func(*Scheduler)
+
+// WithThresholds sets how much longer, or how many more changes, the best
+// trip may have before it is reported. A threshold of 0 or less disables
+// that check.
+func WithThresholds(extraDuration time.Duration, extraChanges int) Option {
+	return func(s *Scheduler) {
+		s.extraDuration = extraDuration
+		s.extraChanges = extraChanges
+	}
+}
+
+func WithClock(clock timeutils.Clock) Option {
+	return func(s *Scheduler) {
+		s.clock = clock
+	}
+}
+
+// WithErrorHandler is called when a search fails. The commute runs again
+// at its next scheduled time.
+func WithErrorHandler(fn func(*Commute, error)) Option {
+	return func(s *Scheduler) {
+		s.onError = fn
+	}
+}
+
+func NewScheduler(client *travelplanner.TravelPlannerClient, commutes []*Commute, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		client:        client,
+		commutes:      commutes,
+		extraDuration: DefaultExtraDuration,
+		extraChanges:  DefaultExtraChanges,
+		clock:         timeutils.SystemClock,
+		onError:       func(*Commute, error) {},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Run waits for the next scheduled commute, searches it and sends a
+// report if it deviates, until ctx is done.
+func (s *Scheduler) Run(ctx context.Context, reports chan<- Report) error {
+	for {
+		now := s.clock.Now()
+		var due []*Commute
+		var next time.Time
+		for _, c := range s.commutes {
+			run, err := c.next(now)
+			if err != nil {
+				return err
+			}
+			switch {
+			case next.IsZero() || run.Before(next):
+				next = run
+				due = []*Commute{c}
+			case run.Equal(next):
+				due = append(due, c)
+			}
+		}
+		if next.IsZero() {
+			return fmt.Errorf("no commutes")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.clock.After(next.Sub(now)):
+		}
+
+		for _, c := range due {
+			report, err := s.Check(ctx, c, next)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				s.onError(c, err)
+				continue
+			}
+			if report == nil {
+				continue
+			}
+			select {
+			case reports <- *report:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// Check searches c at run and compares the best trip, the one arriving
+// first, against the baseline. It returns nil if there is no deviation.
+// Check sets c.Baseline on the first search and is not safe for concurrent
+// use with the same c; Run checks commutes one at a time.
+func (s *Scheduler) Check(ctx context.Context, c *Commute, run time.Time) (*Report, error) {
+	req := c.Request
+	req.Time = run
+	resp, err := s.client.Trips(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", c.Name, err)
+	}
+
+	var best *travelplanner.Trip
+	var bestSummary Summary
+	for i := range resp.Trips {
+		summary, err := Summarize(&resp.Trips[i])
+		if err != nil {
+			continue
+		}
+		if best == nil || summary.Arrival.Before(bestSummary.Arrival) {
+			best, bestSummary = &resp.Trips[i], summary
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no trips found for %s", c.Name)
+	}
+
+	if c.Baseline == nil {
+		c.Baseline = &bestSummary
+		return nil, nil
+	}
+
+	var reasons []string
+	if extra := bestSummary.Duration - c.Baseline.Duration; s.extraDuration > 0 && extra >= s.extraDuration {
+		reasons = append(reasons, fmt.Sprintf("%d min longer", int(extra/time.Minute)))
+	}
+	if extra := bestSummary.Changes - c.Baseline.Changes; s.extraChanges > 0 && extra >= s.extraChanges {
+		if extra == 1 {
+			reasons = append(reasons, "1 more change")
+		} else {
+			reasons = append(reasons, fmt.Sprintf("%d more changes", extra))
+		}
+	}
+	if len(reasons) == 0 {
+		return nil, nil
+	}
+	return &Report{
+		Commute:  c,
+		Run:      run,
+		Trip:     best,
+		Best:     bestSummary,
+		Baseline: *c.Baseline,
+		Reasons:  reasons,
+	}, nil
+}
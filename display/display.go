@@ -0,0 +1,111 @@
+// Package display formats departure times and trips the way SL shows them,
+// in Swedish or English.
+package display
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nobina/go-trafiklab/sl/transport"
+	"github.com/nobina/go-trafiklab/sl/travelplanner"
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+type Language int
+
+const (
+	Swedish Language = iota
+	English
+)
+
+// DefaultThreshold is how far ahead departures are shown in minutes rather
+// than as clock time.
+const DefaultThreshold = 60 * time.Minute
+
+type Formatter struct {
+	Lang      Language
+	Threshold time.Duration
+}
+
+func New(lang Language) Formatter {
+	return Formatter{
+		Lang:      lang,
+		Threshold: DefaultThreshold,
+	}
+}
+
+func (f Formatter) text(sv, en string) string {
+	if f.Lang == English {
+		return en
+	}
+	return sv
+}
+
+// Departure formats t relative to now: "Nu" within the minute, "12 min"
+// below the threshold and "12:45" beyond it.
+func (f Formatter) Departure(now, t time.Time) string {
+	d := t.Sub(now)
+	switch {
+	case d < time.Minute:
+		return f.text("Nu", "Now")
+	case d < f.Threshold:
+		return fmt.Sprintf("%d min", int(d/time.Minute))
+	}
+	return Clock(t)
+}
+
+// TransportDeparture formats the expected time of d relative to now.
+func (f Formatter) TransportDeparture(now time.Time, d *transport.Departure) (string, error) {
+	t, err := d.ExpectedTime()
+	if err != nil {
+		return "", err
+	}
+	return f.Departure(now, t), nil
+}
+
+// Duration formats d rounded to minutes, e.g. "35 min" or "1 tim 5 min".
+func (f Formatter) Duration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%d min", minutes)
+	}
+	hours, minutes := minutes/60, minutes%60
+	h := fmt.Sprintf("%d %s", hours, f.text("tim", "h"))
+	if minutes == 0 {
+		return h
+	}
+	return fmt.Sprintf("%s %d min", h, minutes)
+}
+
+// Changes formats the number of changes, e.g. "1 byte" or "no changes".
+func (f Formatter) Changes(n int) string {
+	switch n {
+	case 0:
+		return f.text("inga byten", "no changes")
+	case 1:
+		return f.text("1 byte", "1 change")
+	}
+	return fmt.Sprintf("%d %s", n, f.text("byten", "changes"))
+}
+
+// Trip summarizes trip as departure and arrival time, duration and number
+// of changes, e.g. "12:05–12:40, 35 min, 1 byte". Realtime times are used
+// where available.
+func (f Formatter) Trip(trip *travelplanner.Trip) (string, error) {
+	dep, arr, err := trip.Times()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		Clock(dep) + "–" + Clock(arr),
+		f.Duration(arr.Sub(dep)),
+		f.Changes(trip.Changes()),
+	}, ", "), nil
+}
+
+// Clock formats t as Stockholm wall clock time, e.g. "12:45".
+func Clock(t time.Time) string {
+	return t.In(timeutils.EuropeStockholm()).Format("15:04")
+}
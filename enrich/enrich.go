@@ -0,0 +1,215 @@
+// Package enrich merges SL Transport departures with GTFS-RT trip updates
+// and vehicle positions into a single best-known view of each departure.
+//
+// Precedence, per field:
+//
+//   - Cancelled: set if either source reports the departure cancelled, or
+//     GTFS-RT skips the stop.
+//   - Expected time: the SL Transport prognosis when the departure has one,
+//     else the GTFS-RT stop level prediction, else the GTFS-RT trip level
+//     delay, else the scheduled time. WithPreferGTFSRT puts the GTFS-RT
+//     predictions first.
+//   - Occupancy: the GTFS-RT vehicle position, else the SL Transport
+//     passenger level.
+//
+// GTFS-RT data is only used with WithTripIDs. The journey ids of SL
+// Transport are not GTFS trip ids, so there is no usable default; match
+// departures on e.g. line, direction and scheduled time against the static
+// GTFS feed instead.
+package enrich
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/nobina/go-trafiklab/gtfsrt"
+	"github.com/nobina/go-trafiklab/sl/transport"
+	"github.com/nobina/go-trafiklab/slidentifiers"
+)
+
+type Source string
+
+const (
+	SourceNone      Source = ""
+	SourceSchedule  Source = "schedule"
+	SourceTransport Source = "transport"
+	SourceGTFSRT    Source = "gtfs-rt"
+)
+
+// Departure states of SL Transport without a prognosis.
+var noPrognosisStates = map[string]bool{
+	"":            true,
+	"NOTEXPECTED": true,
+	"CANCELLED":   true,
+}
+
+type Departure struct {
+	Departure *transport.Departure
+	// TripID is the GTFS trip id the departure was matched to, empty if
+	// it wasn't.
+	TripID          string
+	Scheduled       time.Time
+	Expected        time.Time
+	Delay           time.Duration
+	DelaySource     Source
+	Cancelled       bool
+	CancelledSource Source
+	Occupancy       string
+	OccupancySource Source
+}
+
+// TripIDFunc maps an SL Transport departure to a GTFS trip id.
+type TripIDFunc func(d *transport.Departure) (tripID string, ok bool)
+
+// Engine merges departures with realtime data.
+type Engine struct {
+	tripID       TripIDFunc
+	preferGTFSRT bool
+}
+
+type Option func(*Engine)
+
+// WithTripIDs sets how departures are matched to GTFS trips. Without it no
+// departure is matched and only SL Transport data is used.
+func WithTripIDs(fn TripIDFunc) Option {
+	return func(e *Engine) {
+		e.tripID = fn
+	}
+}
+
+// WithPreferGTFSRT uses GTFS-RT predictions before the SL Transport
+// prognosis.
+func WithPreferGTFSRT() Option {
+	return func(e *Engine) {
+		e.preferGTFSRT = true
+	}
+}
+
+func New(opts ...Option) *Engine {
+	e := &Engine{
+		tripID: noTripID,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+func noTripID(*transport.Departure) (string, bool) {
+	return "", false
+}
+
+// Enrich merges deps with updates and positions, either of which may be
+// nil. Departures whose scheduled time can't be parsed are skipped.
+func (e *Engine) Enrich(deps []*transport.Departure, updates *gtfsrt.TripUpdates, positions *gtfsrt.VehiclePositions) []Departure {
+	byTrip := map[string]*gtfsrt.TripUpdate{}
+	if updates != nil {
+		byTrip = updates.ByTripID()
+	}
+	occupancy := map[string]string{}
+	if positions != nil {
+		for _, v := range positions.Vehicles {
+			if v.Occupancy != "" && v.Trip.TripID != "" {
+				occupancy[v.Trip.TripID] = v.Occupancy
+			}
+		}
+	}
+
+	enriched := make([]Departure, 0, len(deps))
+	for _, d := range deps {
+		scheduled, err := d.ScheduledTime()
+		if err != nil {
+			continue
+		}
+		out := Departure{
+			Departure:   d,
+			Scheduled:   scheduled,
+			Expected:    scheduled,
+			DelaySource: SourceSchedule,
+		}
+
+		var update *gtfsrt.TripUpdate
+		if tripID, ok := e.tripID(d); ok {
+			if u, ok := byTrip[tripID]; ok {
+				out.TripID = tripID
+				update = u
+			}
+			if occ, ok := occupancy[tripID]; ok {
+				out.TripID = tripID
+				out.Occupancy, out.OccupancySource = occ, SourceGTFSRT
+			}
+		}
+		if out.Occupancy == "" && d.Journey.PassengerLevel != "" {
+			out.Occupancy, out.OccupancySource = d.Journey.PassengerLevel, SourceTransport
+		}
+
+		var stopUpdate *gtfsrt.StopTimeUpdate
+		if update != nil {
+			stopUpdate = stopTimeUpdate(update, d)
+		}
+
+		switch {
+		case d.State == "CANCELLED" || d.Journey.State == "CANCELLED":
+			out.Cancelled, out.CancelledSource = true, SourceTransport
+		case update != nil && (update.Cancelled() || (stopUpdate != nil && stopUpdate.Skipped())):
+			out.Cancelled, out.CancelledSource = true, SourceGTFSRT
+		}
+
+		transportExpected, hasTransport := time.Time{}, false
+		if !noPrognosisStates[d.State] && d.Expected != "" {
+			if t, err := d.ExpectedTime(); err == nil {
+				transportExpected, hasTransport = t, true
+			}
+		}
+		gtfsrtExpected, hasGTFSRT := predicted(scheduled, update, stopUpdate)
+
+		switch {
+		case hasGTFSRT && (e.preferGTFSRT || !hasTransport):
+			out.Expected, out.DelaySource = gtfsrtExpected, SourceGTFSRT
+		case hasTransport:
+			out.Expected, out.DelaySource = transportExpected, SourceTransport
+		}
+		out.Delay = out.Expected.Sub(scheduled)
+		enriched = append(enriched, out)
+	}
+	return enriched
+}
+
+// stopTimeUpdate finds the update for the stop point of d, matching the
+// GTFS stop id against the stop point's GID.
+func stopTimeUpdate(u *gtfsrt.TripUpdate, d *transport.Departure) *gtfsrt.StopTimeUpdate {
+	if d.StopPoint.ID == 0 {
+		return nil
+	}
+	gid, err := slidentifiers.DefaultRegistry.ToEFA(slidentifiers.AuthoritySL, slidentifiers.EntityStopPoint, strconv.Itoa(d.StopPoint.ID))
+	if err != nil {
+		return nil
+	}
+	stu, ok := u.Stop(gid)
+	if !ok {
+		return nil
+	}
+	return stu
+}
+
+// predicted returns the GTFS-RT expected departure, preferring the stop
+// level prediction over the trip level delay.
+func predicted(scheduled time.Time, u *gtfsrt.TripUpdate, stu *gtfsrt.StopTimeUpdate) (time.Time, bool) {
+	if stu != nil {
+		for _, ev := range []*gtfsrt.StopTimeEvent{stu.Departure, stu.Arrival} {
+			if ev == nil {
+				continue
+			}
+			if !ev.Time.IsZero() {
+				return ev.Time, true
+			}
+			if ev.Delay != nil {
+				return scheduled.Add(*ev.Delay), true
+			}
+		}
+	}
+	if u != nil && u.Delay != nil {
+		return scheduled.Add(*u.Delay), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,196 @@
+// Package events pushes changes seen by the watchers, such as departure
+// boards, deviation notifications and traffic status, to handlers and
+// HTTP webhooks, so small services don't need their own polling loops.
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/nobina/go-trafiklab/notifier"
+	"github.com/nobina/go-trafiklab/sl/trafficstatus"
+	"github.com/nobina/go-trafiklab/sl/transport"
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+type Type string
+
+const (
+	TypeDepartures    Type = "departures"
+	TypeDeviation     Type = "deviation"
+	TypeTrafficStatus Type = "traffic-status"
+)
+
+// Event is a change of one type. Data is a DepartureDiff, a
+// notifier.Notification or a trafficstatus.Change.
+type Event struct {
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data"`
+}
+
+type Handler interface {
+	Handle(ctx context.Context, ev Event) error
+}
+
+type HandlerFunc func(ctx context.Context, ev Event) error
+
+func (f HandlerFunc) Handle(ctx context.Context, ev Event) error {
+	return f(ctx, ev)
+}
+
+// Emitter sends events to the handlers registered for their type.
+type Emitter struct {
+	handlers map[Type][]Handler
+	clock    timeutils.Clock
+	onError  func(Event, error)
+}
+
+type Option func(*Emitter)
+
+func WithClock(clock timeutils.Clock) Option {
+	return func(e *Emitter) {
+		e.clock = clock
+	}
+}
+
+// WithErrorHandler is called when a handler fails. The event is still
+// sent to the remaining handlers.
+func WithErrorHandler(fn func(Event, error)) Option {
+	return func(e *Emitter) {
+		e.onError = fn
+	}
+}
+
+func NewEmitter(opts ...Option) *Emitter {
+	e := &Emitter{
+		handlers: map[Type][]Handler{},
+		clock:    timeutils.SystemClock,
+		onError:  func(Event, error) {},
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Register adds h for types, or for all types if none are given. Handlers
+// must be registered before the emitter is started.
+func (e *Emitter) Register(h Handler, types ...Type) {
+	if len(types) == 0 {
+		types = []Type{TypeDepartures, TypeDeviation, TypeTrafficStatus}
+	}
+	for _, t := range types {
+		e.handlers[t] = append(e.handlers[t], h)
+	}
+}
+
+// Emit sends an event of typ to its handlers in registration order. The
+// handlers run synchronously, in the loop of the watcher when emitted by
+// the Watch methods, so they should return quickly; a Webhook is bounded
+// by its timeout.
+func (e *Emitter) Emit(ctx context.Context, typ Type, data any) {
+	ev := Event{Type: typ, Time: e.clock.Now(), Data: data}
+	for _, h := range e.handlers[typ] {
+		if err := h.Handle(ctx, ev); err != nil {
+			e.onError(ev, err)
+		}
+	}
+}
+
+// DepartureDiff is the change of an aggregated board between two updates.
+// Departures are matched on site and journey.
+type DepartureDiff struct {
+	Added   []transport.BoardDeparture `json:"added,omitempty"`
+	Removed []transport.BoardDeparture `json:"removed,omitempty"`
+	// Changed holds the current version of departures whose expected time
+	// or state changed.
+	Changed []transport.BoardDeparture `json:"changed,omitempty"`
+}
+
+func (d DepartureDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+type boardKey struct {
+	site    string
+	journey int64
+}
+
+func boardKeyOf(d transport.BoardDeparture) boardKey {
+	return boardKey{site: d.SiteID, journey: d.Departure.Journey.ID}
+}
+
+// DiffBoards returns the changes from prev to curr.
+func DiffBoards(prev, curr []transport.BoardDeparture) DepartureDiff {
+	diff := DepartureDiff{}
+	prevByKey := make(map[boardKey]transport.BoardDeparture, len(prev))
+	for _, d := range prev {
+		prevByKey[boardKeyOf(d)] = d
+	}
+	seen := make(map[boardKey]bool, len(curr))
+	for _, d := range curr {
+		key := boardKeyOf(d)
+		seen[key] = true
+		p, ok := prevByKey[key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, d)
+		case !p.Expected.Equal(d.Expected) || p.Departure.State != d.Departure.State:
+			diff.Changed = append(diff.Changed, d)
+		}
+	}
+	for _, d := range prev {
+		if !seen[boardKeyOf(d)] {
+			diff.Removed = append(diff.Removed, d)
+		}
+	}
+	return diff
+}
+
+// WatchDepartures runs a until ctx is done, emitting a TypeDepartures
+// event with the diff of each update. The first board is emitted as added.
+func (e *Emitter) WatchDepartures(ctx context.Context, a *transport.Aggregator) error {
+	var prev []transport.BoardDeparture
+	return forward(ctx, a.Run, func(board []transport.BoardDeparture) {
+		diff := DiffBoards(prev, board)
+		prev = board
+		if !diff.Empty() {
+			e.Emit(ctx, TypeDepartures, diff)
+		}
+	})
+}
+
+// WatchDeviations runs n until ctx is done, emitting a TypeDeviation event
+// per notification.
+func (e *Emitter) WatchDeviations(ctx context.Context, n *notifier.Notifier) error {
+	return forward(ctx, n.Run, func(m notifier.Notification) {
+		e.Emit(ctx, TypeDeviation, m)
+	})
+}
+
+// WatchTrafficStatus runs w until ctx is done, emitting a
+// TypeTrafficStatus event per change.
+func (e *Emitter) WatchTrafficStatus(ctx context.Context, w *trafficstatus.Watcher) error {
+	return forward(ctx, w.Run, func(c trafficstatus.Change) {
+		e.Emit(ctx, TypeTrafficStatus, c)
+	})
+}
+
+// forward runs a watcher and calls fn with every value it sends, returning
+// the error of the watcher.
+func forward[T any](ctx context.Context, run func(context.Context, chan<- T) error, fn func(T)) error {
+	ch := make(chan T)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- run(ctx, ch)
+	}()
+	for {
+		select {
+		case v := <-ch:
+			fn(v)
+		case err := <-errc:
+			return err
+		}
+	}
+}
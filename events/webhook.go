@@ -0,0 +1,90 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nobina/go-trafiklab/requests"
+)
+
+// SignatureHeader holds the hex HMAC-SHA256 of the body, prefixed with
+// "sha256=", when the webhook has a secret.
+const SignatureHeader = "X-Trafiklab-Signature"
+
+// DefaultWebhookTimeout bounds a webhook request. Handlers run in the
+// loop of the watcher emitting the event, so a slow endpoint would
+// otherwise hold up every later event.
+const DefaultWebhookTimeout = 10 * time.Second
+
+// Webhook is a Handler posting events as JSON to a url.
+type Webhook struct {
+	url        string
+	httpClient *http.Client
+	secret     []byte
+	timeout    time.Duration
+}
+
+type WebhookOption func(*Webhook)
+
+// WithSecret signs the body of every request with secret.
+func WithSecret(secret string) WebhookOption {
+	return func(w *Webhook) {
+		w.secret = []byte(secret)
+	}
+}
+
+// WithTimeout replaces DefaultWebhookTimeout.
+func WithTimeout(d time.Duration) WebhookOption {
+	return func(w *Webhook) {
+		w.timeout = d
+	}
+}
+
+func NewWebhook(url string, client *http.Client, opts ...WebhookOption) *Webhook {
+	w := &Webhook{
+		url:        url,
+		httpClient: client,
+		timeout:    DefaultWebhookTimeout,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	w.httpClient = requests.WrapClient(w.httpClient, requests.UserAgent(""))
+	return w
+}
+
+// Handle posts ev, failing on non 2xx responses and after the timeout.
+func (w *Webhook) Handle(ctx context.Context, ev Event) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	req, err := requests.JSON(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if w.secret != nil {
+		mac := hmac.New(sha256.New, w.secret)
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	res, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return requests.NewAPIError(res)
+	}
+	return nil
+}
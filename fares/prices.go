@@ -0,0 +1,168 @@
+package fares
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+type TicketType string
+
+const (
+	TicketSingle TicketType = "single"
+	Ticket30Day  TicketType = "30-day"
+)
+
+// Price is a ticket price in öre.
+type Price struct {
+	Full    int
+	Reduced int
+}
+
+// PriceTable holds the prices of tickets from ValidFrom. Prices are
+// indexed by zone count minus one; journeys with more zones than listed
+// pay the last price, so a flat fare has a single entry.
+type PriceTable struct {
+	Version   string
+	ValidFrom time.Time
+	Currency  string
+	Prices    map[TicketType][]Price
+}
+
+// PriceFor returns the price in öre of ticket for zones zones.
+func (t *PriceTable) PriceFor(ticket TicketType, zones int, reduced bool) (int, error) {
+	if zones < 1 {
+		return 0, fmt.Errorf("invalid zone count %d", zones)
+	}
+	prices := t.Prices[ticket]
+	if len(prices) == 0 {
+		return 0, fmt.Errorf("no price for %s tickets in price table %s", ticket, t.Version)
+	}
+	p := prices[min(zones, len(prices))-1]
+	if reduced {
+		return p.Reduced, nil
+	}
+	return p.Full, nil
+}
+
+// SLPriceTables are the known SL price tables, oldest first. SL has a
+// flat fare, so zones only matter for supplements. Prices are approximate
+// and should be checked against sl.se.
+var SLPriceTables = []*PriceTable{
+	{
+		Version:   "2024-01",
+		ValidFrom: timeutils.Date(2024, time.January, 9),
+		Currency:  "SEK",
+		Prices: map[TicketType][]Price{
+			TicketSingle: {{Full: 4200, Reduced: 2600}},
+			Ticket30Day:  {{Full: 101000, Reduced: 65000}},
+		},
+	},
+}
+
+// Prices picks the price table in effect at a time. Tables can be added
+// or replaced at runtime, e.g. from configuration when SL changes its
+// prices. It is safe for concurrent use.
+type Prices struct {
+	mu     sync.RWMutex
+	tables []*PriceTable
+}
+
+func NewPrices(tables ...*PriceTable) *Prices {
+	p := &Prices{}
+	for _, t := range tables {
+		p.Set(t)
+	}
+	return p
+}
+
+// DefaultPrices holds SLPriceTables.
+var DefaultPrices = NewPrices(SLPriceTables...)
+
+// Set adds t, replacing any table with the same version.
+func (p *Prices) Set(t *PriceTable) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, old := range p.tables {
+		if old.Version == t.Version {
+			p.tables[i] = t
+			return
+		}
+	}
+	p.tables = append(p.tables, t)
+	sort.Slice(p.tables, func(i, j int) bool {
+		return p.tables[i].ValidFrom.Before(p.tables[j].ValidFrom)
+	})
+}
+
+// Override sets the price of ticket for zones in the table in effect at
+// at, without changing the shared table.
+func (p *Prices) Override(at time.Time, ticket TicketType, zones int, price Price) error {
+	if zones < 1 {
+		return fmt.Errorf("invalid zone count %d", zones)
+	}
+	t, err := p.TableAt(at)
+	if err != nil {
+		return err
+	}
+	updated := &PriceTable{
+		Version:   t.Version,
+		ValidFrom: t.ValidFrom,
+		Currency:  t.Currency,
+		Prices:    map[TicketType][]Price{},
+	}
+	for k, v := range t.Prices {
+		updated.Prices[k] = append([]Price(nil), v...)
+	}
+	prices := updated.Prices[ticket]
+	for len(prices) < zones {
+		if len(prices) > 0 {
+			prices = append(prices, prices[len(prices)-1])
+		} else {
+			prices = append(prices, price)
+		}
+	}
+	prices[zones-1] = price
+	updated.Prices[ticket] = prices
+	p.Set(updated)
+	return nil
+}
+
+// TableAt returns the table in effect at t.
+func (p *Prices) TableAt(t time.Time) (*PriceTable, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for i := len(p.tables) - 1; i >= 0; i-- {
+		if !t.Before(p.tables[i].ValidFrom) {
+			return p.tables[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no price table in effect at %s", t.Format(time.DateOnly))
+}
+
+// PriceFor returns the price in öre of ticket for zones zones in the
+// table in effect at at, e.g. the departure of the journey.
+func (p *Prices) PriceFor(at time.Time, ticket TicketType, zones int, reduced bool) (int, error) {
+	t, err := p.TableAt(at)
+	if err != nil {
+		return 0, err
+	}
+	return t.PriceFor(ticket, zones, reduced)
+}
+
+// PriceFor returns the price in öre at at using DefaultPrices.
+func PriceFor(at time.Time, ticket TicketType, zones int, reduced bool) (int, error) {
+	return DefaultPrices.PriceFor(at, ticket, zones, reduced)
+}
+
+// FormatPrice formats a price in öre as kronor, e.g. "42 kr" or
+// "42,50 kr".
+func FormatPrice(ore int) string {
+	if ore%100 == 0 {
+		return fmt.Sprintf("%d kr", ore/100)
+	}
+	return fmt.Sprintf("%d,%02d kr", ore/100, ore%100)
+}
@@ -0,0 +1,207 @@
+// Package fares estimates the zones and ticket needed for a journey, as
+// the SL APIs don't consistently return fare data.
+package fares
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/nobina/go-trafiklab/gtfs"
+	"github.com/nobina/go-trafiklab/netex"
+	"github.com/nobina/go-trafiklab/sl/travelplanner"
+	"github.com/nobina/go-trafiklab/slidentifiers"
+)
+
+// Zone is a fare zone, e.g. "A".
+type Zone string
+
+// ZoneMap holds the fare zone of every stop. Stops are keyed by EFA GID
+// when their id can be converted, so HAFAS and site ids find the zone of
+// their site. It is safe for concurrent use.
+type ZoneMap struct {
+	mu    sync.RWMutex
+	zones map[string]Zone
+	// supplements are zones that need a supplement on top of the ticket,
+	// e.g. the Arlanda airport stations.
+	supplements map[Zone]bool
+	hierarchy   *slidentifiers.Hierarchy
+}
+
+// NewZoneMap looks up the stop area of stop points without a zone in
+// hierarchy, which LoadGTFS adds the parent stations of its stops to. A
+// nil hierarchy starts empty.
+func NewZoneMap(hierarchy *slidentifiers.Hierarchy) *ZoneMap {
+	if hierarchy == nil {
+		hierarchy = slidentifiers.NewHierarchy()
+	}
+	return &ZoneMap{
+		zones:       map[string]Zone{},
+		supplements: map[Zone]bool{},
+		hierarchy:   hierarchy,
+	}
+}
+
+func key(stopID string) string {
+	if gid, err := slidentifiers.Normalize(stopID, slidentifiers.KindEFA); err == nil {
+		return gid
+	}
+	return stopID
+}
+
+func (m *ZoneMap) Set(stopID string, zone Zone) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.zones[key(stopID)] = zone
+}
+
+// SetSupplement marks zone as needing a supplement rather than counting
+// towards the zones of a journey.
+func (m *ZoneMap) SetSupplement(zone Zone) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.supplements[zone] = true
+}
+
+func (m *ZoneMap) supplement(zone Zone) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.supplements[zone]
+}
+
+// Zone returns the zone of a stop. Stop points without a zone of their
+// own fall back to their stop area.
+func (m *ZoneMap) Zone(stopID string) (Zone, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	k := key(stopID)
+	if z, ok := m.zones[k]; ok {
+		return z, true
+	}
+	parent, ok := m.hierarchy.Parent(k)
+	if !ok {
+		return "", false
+	}
+	z, ok := m.zones[parent]
+	return z, ok
+}
+
+// LoadGTFS reads the zone_id and parent_station of stops.txt. Parent
+// stations get the zone of their stops if they have none.
+func (m *ZoneMap) LoadGTFS(feed *gtfs.Feed) {
+	for _, s := range feed.Stops {
+		if s.ParentStation != "" {
+			m.hierarchy.Add(key(s.ID), key(s.ParentStation))
+		}
+		if s.ZoneID == "" {
+			continue
+		}
+		m.Set(s.ID, Zone(s.ZoneID))
+		if s.ParentStation != "" {
+			if _, ok := m.Zone(s.ParentStation); !ok {
+				m.Set(s.ParentStation, Zone(s.ZoneID))
+			}
+		}
+	}
+}
+
+// LoadNeTEx reads the first tariff zone of every stop place and applies
+// it to its quays.
+func (m *ZoneMap) LoadNeTEx(d *netex.Dataset) {
+	for _, sp := range d.StopPlaces {
+		if len(sp.TariffZones) == 0 {
+			continue
+		}
+		zone := Zone(netex.LocalID(sp.TariffZones[0].Ref))
+		m.Set(netex.LocalID(sp.ID), zone)
+		for _, q := range sp.Quays {
+			m.Set(netex.LocalID(q.ID), zone)
+		}
+	}
+}
+
+// Fare is the zones travelled through by a journey.
+type Fare struct {
+	Zones       []Zone
+	Supplements []Zone
+	// Unknown lists stops without a known zone. The fare is a lower bound
+	// when it isn't empty.
+	Unknown []string
+}
+
+// ZoneCount is the number of zones the ticket must cover.
+func (f Fare) ZoneCount() int {
+	return len(f.Zones)
+}
+
+// Calculator determines the fare zones of trips.
+type Calculator struct {
+	zones *ZoneMap
+}
+
+func NewCalculator(zones *ZoneMap) *Calculator {
+	return &Calculator{zones: zones}
+}
+
+// Stops returns the fare for travelling through stopIDs.
+func (c *Calculator) Stops(stopIDs []string) Fare {
+	var f Fare
+	zones := map[Zone]bool{}
+	supplements := map[Zone]bool{}
+	for _, id := range stopIDs {
+		z, ok := c.zones.Zone(id)
+		if !ok {
+			f.Unknown = append(f.Unknown, id)
+			continue
+		}
+		if c.zones.supplement(z) {
+			supplements[z] = true
+		} else {
+			zones[z] = true
+		}
+	}
+	f.Zones = sortedZones(zones)
+	f.Supplements = sortedZones(supplements)
+	return f
+}
+
+// Trip returns the fare of a travel planner trip. Intermediate stops are
+// only counted if the trip was requested with a passlist. Walks are
+// skipped.
+func (c *Calculator) Trip(trip *travelplanner.Trip) (Fare, error) {
+	var stopIDs []string
+	for _, leg := range trip.Legs {
+		if leg.Type == "WALK" {
+			continue
+		}
+		stopIDs = append(stopIDs, locationID(leg.Origin))
+		for _, s := range leg.Stops {
+			id := s.ExtId
+			if s.MainMastExtID != "" {
+				id = s.MainMastExtID
+			}
+			stopIDs = append(stopIDs, id)
+		}
+		stopIDs = append(stopIDs, locationID(leg.Destination))
+	}
+	if len(stopIDs) == 0 {
+		return Fare{}, fmt.Errorf("trip has no transport legs")
+	}
+	return c.Stops(stopIDs), nil
+}
+
+func locationID(loc travelplanner.Location) string {
+	if loc.MainMastExtID != "" {
+		return loc.MainMastExtID
+	}
+	return loc.ExtID
+}
+
+func sortedZones(set map[Zone]bool) []Zone {
+	zones := make([]Zone, 0, len(set))
+	for z := range set {
+		zones = append(zones, z)
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i] < zones[j] })
+	return zones
+}
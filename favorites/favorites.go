@@ -0,0 +1,118 @@
+// Package favorites stores users' saved stops and journeys, and upgrades
+// ids saved before SL switched from HAFAS ids to EFA GIDs.
+package favorites
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nobina/go-trafiklab/slidentifiers"
+)
+
+// ErrNotFound is returned when deleting a favorite that doesn't exist.
+var ErrNotFound = errors.New("favorite not found")
+
+type Stop struct {
+	ID      string
+	Ref     slidentifiers.StopRef
+	Name    string
+	Created time.Time
+}
+
+type Journey struct {
+	ID          string
+	Name        string
+	Origin      slidentifiers.StopRef
+	Destination slidentifiers.StopRef
+	Created     time.Time
+}
+
+// Store persists favorites per user. Save replaces a favorite with the
+// same ID.
+type Store interface {
+	Stops(ctx context.Context, user string) ([]Stop, error)
+	SaveStop(ctx context.Context, user string, s Stop) error
+	DeleteStop(ctx context.Context, user, id string) error
+	Journeys(ctx context.Context, user string) ([]Journey, error)
+	SaveJourney(ctx context.Context, user string, j Journey) error
+	DeleteJourney(ctx context.Context, user, id string) error
+}
+
+// MemoryStore is a Store kept in memory, for tests and small services.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	stops    map[string]map[string]Stop
+	journeys map[string]map[string]Journey
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		stops:    map[string]map[string]Stop{},
+		journeys: map[string]map[string]Journey{},
+	}
+}
+
+func (m *MemoryStore) Stops(ctx context.Context, user string) ([]Stop, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	stops := make([]Stop, 0, len(m.stops[user]))
+	for _, s := range m.stops[user] {
+		stops = append(stops, s)
+	}
+	sort.Slice(stops, func(i, j int) bool { return stops[i].ID < stops[j].ID })
+	return stops, nil
+}
+
+func (m *MemoryStore) SaveStop(ctx context.Context, user string, s Stop) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stops[user] == nil {
+		m.stops[user] = map[string]Stop{}
+	}
+	m.stops[user][s.ID] = s
+	return nil
+}
+
+func (m *MemoryStore) DeleteStop(ctx context.Context, user, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.stops[user][id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.stops[user], id)
+	return nil
+}
+
+func (m *MemoryStore) Journeys(ctx context.Context, user string) ([]Journey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	journeys := make([]Journey, 0, len(m.journeys[user]))
+	for _, j := range m.journeys[user] {
+		journeys = append(journeys, j)
+	}
+	sort.Slice(journeys, func(i, j int) bool { return journeys[i].ID < journeys[j].ID })
+	return journeys, nil
+}
+
+func (m *MemoryStore) SaveJourney(ctx context.Context, user string, j Journey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.journeys[user] == nil {
+		m.journeys[user] = map[string]Journey{}
+	}
+	m.journeys[user][j.ID] = j
+	return nil
+}
+
+func (m *MemoryStore) DeleteJourney(ctx context.Context, user, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.journeys[user][id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.journeys[user], id)
+	return nil
+}
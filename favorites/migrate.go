@@ -0,0 +1,214 @@
+package favorites
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/nobina/go-trafiklab/sl/stopindex"
+	"github.com/nobina/go-trafiklab/slidentifiers"
+)
+
+// Verifier confirms that a GID is a live stop, e.g. by looking it up in
+// a stop finder or the offline stop index.
+type Verifier interface {
+	Verify(ctx context.Context, gid string) (bool, error)
+}
+
+type VerifierFunc func(ctx context.Context, gid string) (bool, error)
+
+func (f VerifierFunc) Verify(ctx context.Context, gid string) (bool, error) {
+	return f(ctx, gid)
+}
+
+// IndexVerifier verifies GIDs against the sites of an offline stop index.
+func IndexVerifier(idx *stopindex.Index) Verifier {
+	return VerifierFunc(func(ctx context.Context, gid string) (bool, error) {
+		n, err := strconv.ParseInt(gid, 10, 64)
+		if err != nil {
+			return false, nil
+		}
+		_, ok := idx.Site(n)
+		return ok, nil
+	})
+}
+
+type Status int
+
+const (
+	// StatusCurrent means the id was already a GID or not an SL id.
+	StatusCurrent Status = iota
+	StatusMigrated
+	// StatusUnverified means the id was converted but the result could not
+	// be verified. The id is kept and the GID is left in Result.Candidate
+	// for review.
+	StatusUnverified
+	// StatusFailed means the id could not be converted and was kept.
+	StatusFailed
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusCurrent:
+		return "current"
+	case StatusMigrated:
+		return "migrated"
+	case StatusUnverified:
+		return "unverified"
+	case StatusFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+type Result struct {
+	From slidentifiers.StopRef
+	To   slidentifiers.StopRef
+	// Candidate is the unverified GID of StatusUnverified results.
+	Candidate slidentifiers.StopRef
+	Status    Status
+	Err       error
+}
+
+// Migrator upgrades site ids and HAFAS ids to EFA GIDs.
+type Migrator struct {
+	mapping  *slidentifiers.MappingTable
+	verifier Verifier
+}
+
+// NewMigrator converts through mapping, which applies known overrides,
+// and checks the results with verifier. Either may be nil.
+func NewMigrator(mapping *slidentifiers.MappingTable, verifier Verifier) *Migrator {
+	if mapping == nil {
+		mapping = slidentifiers.NewMappingTable()
+	}
+	return &Migrator{mapping: mapping, verifier: verifier}
+}
+
+// MigrateRef upgrades a single ref.
+func (m *Migrator) MigrateRef(ctx context.Context, ref slidentifiers.StopRef) Result {
+	r := Result{From: ref, To: ref}
+	var hafasID string
+	switch ref.Kind {
+	case slidentifiers.KindHAFAS:
+		hafasID = ref.ID
+	case slidentifiers.KindSiteID:
+		h, err := slidentifiers.ConvertSiteIDToHAFAS(ref.ID)
+		if err != nil {
+			r.Status, r.Err = StatusFailed, err
+			return r
+		}
+		hafasID = h
+	default:
+		return r
+	}
+
+	v, err := m.mapping.HAFASToEFA(hafasID)
+	if err != nil {
+		r.Status, r.Err = StatusFailed, err
+		return r
+	}
+	gid := slidentifiers.StopRef{Kind: slidentifiers.KindEFA, ID: v.GID}
+	if v.Verified {
+		r.To, r.Status = gid, StatusMigrated
+		return r
+	}
+	r.Candidate, r.Status = gid, StatusUnverified
+	if m.verifier == nil {
+		return r
+	}
+	ok, err := m.verifier.Verify(ctx, v.GID)
+	switch {
+	case err != nil:
+		r.Err = err
+	case !ok:
+		r.Err = fmt.Errorf("%s is not a known stop", v.GID)
+	default:
+		r.To, r.Candidate, r.Status = gid, slidentifiers.StopRef{}, StatusMigrated
+	}
+	return r
+}
+
+// Report lists the results of a migration by favorite id. Journeys have
+// one result for the origin and one for the destination.
+type Report struct {
+	Stops    map[string]Result
+	Journeys map[string][2]Result
+}
+
+// Failed reports whether any id could not be converted.
+func (r *Report) Failed() bool {
+	for _, res := range r.Stops {
+		if res.Status == StatusFailed {
+			return true
+		}
+	}
+	for _, res := range r.Journeys {
+		if res[0].Status == StatusFailed || res[1].Status == StatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// Unverified returns the ids of the stops and journeys that were kept
+// because a converted id could not be verified, sorted.
+func (r *Report) Unverified() []string {
+	var ids []string
+	for id, res := range r.Stops {
+		if res.Status == StatusUnverified {
+			ids = append(ids, id)
+		}
+	}
+	for id, res := range r.Journeys {
+		if res[0].Status == StatusUnverified || res[1].Status == StatusUnverified {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Migrate upgrades all favorites of user in store, saving those that
+// changed. Favorites that fail to convert or whose GIDs can't be verified
+// are kept as they are; Report.Unverified lists the latter.
+func (m *Migrator) Migrate(ctx context.Context, store Store, user string) (*Report, error) {
+	report := &Report{
+		Stops:    map[string]Result{},
+		Journeys: map[string][2]Result{},
+	}
+	var errs []error
+
+	stops, err := store.Stops(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stops: %w", err)
+	}
+	for _, s := range stops {
+		res := m.MigrateRef(ctx, s.Ref)
+		report.Stops[s.ID] = res
+		if res.To != s.Ref {
+			s.Ref = res.To
+			if err := store.SaveStop(ctx, user, s); err != nil {
+				errs = append(errs, fmt.Errorf("failed to save stop %s: %w", s.ID, err))
+			}
+		}
+	}
+
+	journeys, err := store.Journeys(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load journeys: %w", err)
+	}
+	for _, j := range journeys {
+		res := [2]Result{m.MigrateRef(ctx, j.Origin), m.MigrateRef(ctx, j.Destination)}
+		report.Journeys[j.ID] = res
+		if res[0].To != j.Origin || res[1].To != j.Destination {
+			j.Origin, j.Destination = res[0].To, res[1].To
+			if err := store.SaveJourney(ctx, user, j); err != nil {
+				errs = append(errs, fmt.Errorf("failed to save journey %s: %w", j.ID, err))
+			}
+		}
+	}
+	return report, errors.Join(errs...)
+}
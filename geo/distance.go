@@ -0,0 +1,18 @@
+package geo
+
+import "math"
+
+const earthRadius = 6371008.8
+
+// Distance returns the great circle distance in meters between two points
+// given in degrees.
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	phi1 := lat1 * degToRad
+	phi2 := lat2 * degToRad
+	dPhi := (lat2 - lat1) * degToRad
+	dLambda := (lng2 - lng1) * degToRad
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadius * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
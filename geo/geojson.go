@@ -0,0 +1,45 @@
+package geo
+
+// FeatureCollection is a GeoJSON feature collection, as read by Leaflet
+// and Mapbox.
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+func NewFeatureCollection() *FeatureCollection {
+	return &FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+}
+
+type Feature struct {
+	Type       string         `json:"type"`
+	Geometry   Geometry       `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// Geometry is a Point or LineString. Coordinates are [lng, lat] pairs, a
+// single pair for points.
+type Geometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+func Point(p LatLng, props map[string]any) Feature {
+	return Feature{
+		Type:       "Feature",
+		Geometry:   Geometry{Type: "Point", Coordinates: [2]float64{p.Lng, p.Lat}},
+		Properties: props,
+	}
+}
+
+func LineString(path []LatLng, props map[string]any) Feature {
+	coords := make([][2]float64, 0, len(path))
+	for _, p := range path {
+		coords = append(coords, [2]float64{p.Lng, p.Lat})
+	}
+	return Feature{
+		Type:       "Feature",
+		Geometry:   Geometry{Type: "LineString", Coordinates: coords},
+		Properties: props,
+	}
+}
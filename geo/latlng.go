@@ -0,0 +1,37 @@
+package geo
+
+import "math"
+
+// LatLng is a WGS84 coordinate in degrees.
+type LatLng struct {
+	Lat float64
+	Lng float64
+}
+
+// DistanceTo returns the great circle distance in meters to q.
+func (p LatLng) DistanceTo(q LatLng) float64 {
+	return Distance(p.Lat, p.Lng, q.Lat, q.Lng)
+}
+
+// BearingTo returns the initial bearing to q in degrees clockwise from
+// north, in [0, 360).
+func (p LatLng) BearingTo(q LatLng) float64 {
+	return Bearing(p.Lat, p.Lng, q.Lat, q.Lng)
+}
+
+// Within reports whether q is at most radius meters from p.
+func (p LatLng) Within(radius float64, q LatLng) bool {
+	return p.DistanceTo(q) <= radius
+}
+
+// Bearing returns the initial bearing from the first point to the second
+// in degrees clockwise from north, in [0, 360).
+func Bearing(lat1, lng1, lat2, lng2 float64) float64 {
+	phi1 := lat1 * degToRad
+	phi2 := lat2 * degToRad
+	dLambda := (lng2 - lng1) * degToRad
+
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+	return math.Mod(math.Atan2(y, x)/degToRad+360, 360)
+}
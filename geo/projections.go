@@ -0,0 +1,148 @@
+package geo
+
+import "math"
+
+// Projection is a transverse mercator grid on the GRS80 ellipsoid, using
+// the Gauss-Krüger formulas published by Lantmäteriet. Grid coordinates
+// follow the Swedish convention of northing (x) before easting (y).
+type Projection struct {
+	axis            float64
+	flattening      float64
+	centralMeridian float64
+	scale           float64
+	falseNorthing   float64
+	falseEasting    float64
+}
+
+var (
+	SWEREF99TM = Projection{
+		axis:            6378137.0,
+		flattening:      1.0 / 298.257222101,
+		centralMeridian: 15.0,
+		scale:           0.9996,
+		falseNorthing:   0.0,
+		falseEasting:    500000.0,
+	}
+
+	// RT90 is RT90 2.5 gon V, using the parameters that map it directly
+	// from GRS80 without a datum shift.
+	RT90 = Projection{
+		axis:            6378137.0,
+		flattening:      1.0 / 298.257222101,
+		centralMeridian: 15.0 + 48.0/60.0 + 22.624306/3600.0,
+		scale:           1.00000561024,
+		falseNorthing:   -667.711,
+		falseEasting:    1500064.274,
+	}
+)
+
+const degToRad = math.Pi / 180
+
+func (p Projection) constants() (e2, n, aRoof float64) {
+	e2 = p.flattening * (2.0 - p.flattening)
+	n = p.flattening / (2.0 - p.flattening)
+	aRoof = p.axis / (1.0 + n) * (1.0 + n*n/4.0 + n*n*n*n/64.0)
+	return e2, n, aRoof
+}
+
+// FromWGS84 projects lat/lng in degrees onto the grid.
+func (p Projection) FromWGS84(lat, lng float64) (northing, easting float64) {
+	e2, n, aRoof := p.constants()
+
+	a := e2
+	b := (5.0*e2*e2 - e2*e2*e2) / 6.0
+	c := (104.0*e2*e2*e2 - 45.0*e2*e2*e2*e2) / 120.0
+	d := (1237.0 * e2 * e2 * e2 * e2) / 1260.0
+	beta1 := n/2.0 - 2.0*n*n/3.0 + 5.0*n*n*n/16.0 + 41.0*n*n*n*n/180.0
+	beta2 := 13.0*n*n/48.0 - 3.0*n*n*n/5.0 + 557.0*n*n*n*n/1440.0
+	beta3 := 61.0*n*n*n/240.0 - 103.0*n*n*n*n/140.0
+	beta4 := 49561.0 * n * n * n * n / 161280.0
+
+	phi := lat * degToRad
+	lambda := lng * degToRad
+	lambdaZero := p.centralMeridian * degToRad
+
+	sinPhi := math.Sin(phi)
+	phiStar := phi - sinPhi*math.Cos(phi)*(a+
+		b*math.Pow(sinPhi, 2)+
+		c*math.Pow(sinPhi, 4)+
+		d*math.Pow(sinPhi, 6))
+	deltaLambda := lambda - lambdaZero
+	xiPrim := math.Atan(math.Tan(phiStar) / math.Cos(deltaLambda))
+	etaPrim := math.Atanh(math.Cos(phiStar) * math.Sin(deltaLambda))
+
+	northing = p.scale*aRoof*(xiPrim+
+		beta1*math.Sin(2.0*xiPrim)*math.Cosh(2.0*etaPrim)+
+		beta2*math.Sin(4.0*xiPrim)*math.Cosh(4.0*etaPrim)+
+		beta3*math.Sin(6.0*xiPrim)*math.Cosh(6.0*etaPrim)+
+		beta4*math.Sin(8.0*xiPrim)*math.Cosh(8.0*etaPrim)) + p.falseNorthing
+	easting = p.scale*aRoof*(etaPrim+
+		beta1*math.Cos(2.0*xiPrim)*math.Sinh(2.0*etaPrim)+
+		beta2*math.Cos(4.0*xiPrim)*math.Sinh(4.0*etaPrim)+
+		beta3*math.Cos(6.0*xiPrim)*math.Sinh(6.0*etaPrim)+
+		beta4*math.Cos(8.0*xiPrim)*math.Sinh(8.0*etaPrim)) + p.falseEasting
+	return northing, easting
+}
+
+// ToWGS84 converts grid coordinates to lat/lng in degrees.
+func (p Projection) ToWGS84(northing, easting float64) (lat, lng float64) {
+	e2, n, aRoof := p.constants()
+
+	delta1 := n/2.0 - 2.0*n*n/3.0 + 37.0*n*n*n/96.0 - n*n*n*n/360.0
+	delta2 := n*n/48.0 + n*n*n/15.0 - 437.0*n*n*n*n/1440.0
+	delta3 := 17.0*n*n*n/480.0 - 37*n*n*n*n/840.0
+	delta4 := 4397.0 * n * n * n * n / 161280.0
+
+	aStar := e2 + e2*e2 + e2*e2*e2 + e2*e2*e2*e2
+	bStar := -(7.0*e2*e2 + 17.0*e2*e2*e2 + 30.0*e2*e2*e2*e2) / 6.0
+	cStar := (224.0*e2*e2*e2 + 889.0*e2*e2*e2*e2) / 120.0
+	dStar := -(4279.0 * e2 * e2 * e2 * e2) / 1260.0
+
+	lambdaZero := p.centralMeridian * degToRad
+	xi := (northing - p.falseNorthing) / (p.scale * aRoof)
+	eta := (easting - p.falseEasting) / (p.scale * aRoof)
+	xiPrim := xi -
+		delta1*math.Sin(2.0*xi)*math.Cosh(2.0*eta) -
+		delta2*math.Sin(4.0*xi)*math.Cosh(4.0*eta) -
+		delta3*math.Sin(6.0*xi)*math.Cosh(6.0*eta) -
+		delta4*math.Sin(8.0*xi)*math.Cosh(8.0*eta)
+	etaPrim := eta -
+		delta1*math.Cos(2.0*xi)*math.Sinh(2.0*eta) -
+		delta2*math.Cos(4.0*xi)*math.Sinh(4.0*eta) -
+		delta3*math.Cos(6.0*xi)*math.Sinh(6.0*eta) -
+		delta4*math.Cos(8.0*xi)*math.Sinh(8.0*eta)
+	phiStar := math.Asin(math.Sin(xiPrim) / math.Cosh(etaPrim))
+	deltaLambda := math.Atan(math.Sinh(etaPrim) / math.Cos(xiPrim))
+
+	sinPhiStar := math.Sin(phiStar)
+	lngRadian := lambdaZero + deltaLambda
+	latRadian := phiStar + sinPhiStar*math.Cos(phiStar)*(aStar+
+		bStar*math.Pow(sinPhiStar, 2)+
+		cStar*math.Pow(sinPhiStar, 4)+
+		dStar*math.Pow(sinPhiStar, 6))
+	return latRadian / degToRad, lngRadian / degToRad
+}
+
+func WGS84ToSWEREF99TM(lat, lng float64) (northing, easting float64) {
+	return SWEREF99TM.FromWGS84(lat, lng)
+}
+
+func SWEREF99TMToWGS84(northing, easting float64) (lat, lng float64) {
+	return SWEREF99TM.ToWGS84(northing, easting)
+}
+
+func WGS84ToRT90(lat, lng float64) (x, y float64) {
+	return RT90.FromWGS84(lat, lng)
+}
+
+func RT90ToWGS84(x, y float64) (lat, lng float64) {
+	return RT90.ToWGS84(x, y)
+}
+
+func SWEREF99TMToRT90(northing, easting float64) (x, y float64) {
+	return RT90.FromWGS84(SWEREF99TM.ToWGS84(northing, easting))
+}
+
+func RT90ToSWEREF99TM(x, y float64) (northing, easting float64) {
+	return SWEREF99TM.FromWGS84(RT90.ToWGS84(x, y))
+}
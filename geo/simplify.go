@@ -0,0 +1,76 @@
+package geo
+
+import "math"
+
+// SimplifyRadial drops points closer than tolerance meters to the last
+// kept point. It is fast and removes clusters, e.g. of a vehicle standing
+// at a stop, but keeps every corner.
+func SimplifyRadial(path []LatLng, tolerance float64) []LatLng {
+	if len(path) <= 2 {
+		return path
+	}
+	simplified := []LatLng{path[0]}
+	last := path[0]
+	for _, p := range path[1 : len(path)-1] {
+		if last.DistanceTo(p) >= tolerance {
+			simplified = append(simplified, p)
+			last = p
+		}
+	}
+	return append(simplified, path[len(path)-1])
+}
+
+// Simplify reduces path with the Douglas-Peucker algorithm, keeping every
+// point further than tolerance meters from the simplified line. The first
+// and last points are always kept.
+func Simplify(path []LatLng, tolerance float64) []LatLng {
+	if len(path) <= 2 {
+		return path
+	}
+	keep := make([]bool, len(path))
+	keep[0], keep[len(path)-1] = true, true
+
+	// An explicit stack, as paths of long legs have thousands of points.
+	stack := [][2]int{{0, len(path) - 1}}
+	for len(stack) > 0 {
+		first, last := stack[len(stack)-1][0], stack[len(stack)-1][1]
+		stack = stack[:len(stack)-1]
+
+		maxDist, index := 0.0, 0
+		for i := first + 1; i < last; i++ {
+			if d := segmentDistance(path[i], path[first], path[last]); d > maxDist {
+				maxDist, index = d, i
+			}
+		}
+		if maxDist > tolerance {
+			keep[index] = true
+			stack = append(stack, [2]int{first, index}, [2]int{index, last})
+		}
+	}
+
+	simplified := []LatLng{}
+	for i, p := range path {
+		if keep[i] {
+			simplified = append(simplified, p)
+		}
+	}
+	return simplified
+}
+
+// segmentDistance returns the distance in meters from p to the segment
+// a-b, on a plane tangent at a. The error is negligible at the length of
+// leg segments.
+func segmentDistance(p, a, b LatLng) float64 {
+	cosLat := math.Cos(a.Lat * degToRad)
+	x := func(q LatLng) float64 { return (q.Lng - a.Lng) * degToRad * cosLat * earthRadius }
+	y := func(q LatLng) float64 { return (q.Lat - a.Lat) * degToRad * earthRadius }
+
+	px, py := x(p), y(p)
+	bx, by := x(b), y(b)
+	lenSq := bx*bx + by*by
+	if lenSq == 0 {
+		return math.Hypot(px, py)
+	}
+	t := math.Max(0, math.Min(1, (px*bx+py*by)/lenSq))
+	return math.Hypot(px-t*bx, py-t*by)
+}
@@ -0,0 +1,70 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Bounds is a bounding box in degrees.
+type Bounds struct {
+	Name   string
+	MinLat float64
+	MinLng float64
+	MaxLat float64
+	MaxLng float64
+}
+
+var (
+	BoundsSweden = Bounds{Name: "Sweden", MinLat: 55.0, MinLng: 10.5, MaxLat: 69.1, MaxLng: 24.2}
+	// BoundsStockholm covers Stockholm county, the area served by SL.
+	BoundsStockholm = Bounds{Name: "Stockholm county", MinLat: 58.7, MinLng: 17.2, MaxLat: 60.3, MaxLng: 19.4}
+)
+
+func (b Bounds) Contains(p LatLng) bool {
+	return p.Lat >= b.MinLat && p.Lat <= b.MaxLat && p.Lng >= b.MinLng && p.Lng <= b.MaxLng
+}
+
+var ErrInvalidCoordinate = errors.New("invalid coordinate")
+
+// CoordinateError describes why a coordinate was rejected. It matches
+// ErrInvalidCoordinate with errors.Is.
+type CoordinateError struct {
+	Lat    float64
+	Lng    float64
+	Reason string
+}
+
+func (e *CoordinateError) Error() string {
+	return fmt.Sprintf("invalid coordinate %v,%v: %s", e.Lat, e.Lng, e.Reason)
+}
+
+func (e *CoordinateError) Unwrap() error {
+	return ErrInvalidCoordinate
+}
+
+// ValidateCoordinate catches coordinates that are obviously wrong before
+// they are sent to an API: unset, projected instead of WGS84, swapped or
+// outside bounds.
+func ValidateCoordinate(lat, lng float64, bounds Bounds) error {
+	invalid := func(reason string) error {
+		return &CoordinateError{Lat: lat, Lng: lng, Reason: reason}
+	}
+	switch {
+	case math.IsNaN(lat) || math.IsNaN(lng) || math.IsInf(lat, 0) || math.IsInf(lng, 0):
+		return invalid("not a number")
+	case lat == 0 && lng == 0:
+		return invalid("zero, likely unset")
+	case math.Abs(lat) > 1000 || math.Abs(lng) > 1000:
+		return invalid("looks projected, e.g. SWEREF 99 TM, rather than WGS84 degrees")
+	case math.Abs(lat) > 90 || math.Abs(lng) > 180:
+		return invalid("out of range")
+	}
+	if bounds.Contains(LatLng{Lat: lat, Lng: lng}) {
+		return nil
+	}
+	if bounds.Contains(LatLng{Lat: lng, Lng: lat}) {
+		return invalid("latitude and longitude appear swapped")
+	}
+	return invalid("outside " + bounds.Name)
+}
@@ -0,0 +1,118 @@
+package gtfs
+
+// Feed is the parsed content of a static GTFS feed. Optional files missing
+// from the feed leave their fields empty.
+type Feed struct {
+	Agencies      []Agency
+	Stops         []Stop
+	Routes        []Route
+	Trips         []Trip
+	StopTimes     []StopTime
+	Calendars     []Calendar
+	CalendarDates []CalendarDate
+	Shapes        []ShapePoint
+	FeedInfo      *FeedInfo
+}
+
+type Agency struct {
+	ID       string
+	Name     string
+	URL      string
+	Timezone string
+}
+
+// Location types of Stop.
+const (
+	LocationStop     = 0
+	LocationStation  = 1
+	LocationEntrance = 2
+	LocationNode     = 3
+	LocationBoarding = 4
+)
+
+type Stop struct {
+	ID            string
+	Code          string
+	Name          string
+	Lat           float64
+	Lon           float64
+	LocationType  int
+	ParentStation string
+	PlatformCode  string
+	ZoneID        string
+}
+
+type Route struct {
+	ID        string
+	AgencyID  string
+	ShortName string
+	LongName  string
+	Type      int
+	Desc      string
+}
+
+type Trip struct {
+	ID          string
+	RouteID     string
+	ServiceID   string
+	Headsign    string
+	ShortName   string
+	DirectionID int
+	ShapeID     string
+}
+
+// StopTime keeps arrival and departure as in the feed, e.g. 25:10:00 for
+// trips after midnight. Use timeutils.ServiceTime to resolve them.
+type StopTime struct {
+	TripID        string
+	ArrivalTime   string
+	DepartureTime string
+	StopID        string
+	StopSequence  int
+	Headsign      string
+	PickupType    int
+	DropOffType   int
+	// ShapeDistTraveled is the distance along the trip's shape, 0 if
+	// not provided.
+	ShapeDistTraveled float64
+}
+
+// Calendar dates are formatted 20060102, see timeutils.ParseServiceDate.
+type Calendar struct {
+	ServiceID string
+	// Weekdays is indexed by time.Weekday.
+	Weekdays  [7]bool
+	StartDate string
+	EndDate   string
+}
+
+// Exception types of CalendarDate.
+const (
+	ServiceAdded   = 1
+	ServiceRemoved = 2
+)
+
+type CalendarDate struct {
+	ServiceID     string
+	Date          string
+	ExceptionType int
+}
+
+type ShapePoint struct {
+	ShapeID  string
+	Lat      float64
+	Lon      float64
+	Sequence int
+	// DistTraveled is the distance along the shape in the unit of the
+	// feed, 0 if not provided.
+	DistTraveled float64
+}
+
+type FeedInfo struct {
+	PublisherName string
+	PublisherURL  string
+	Lang          string
+	StartDate     string
+	EndDate       string
+	Version       string
+}
@@ -0,0 +1,153 @@
+// Package gtfs downloads and parses the static GTFS feeds published on
+// Trafiklab: GTFS Regional, one feed per operator, and GTFS Sweden 3, the
+// national feed.
+package gtfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/nobina/go-trafiklab/requests"
+)
+
+const DefaultBaseURL = "https://opendata.samtrafiken.se"
+
+// Config holds one key per dataset, as Trafiklab issues separate keys for
+// GTFS Regional and GTFS Sweden 3.
+type Config struct {
+	BaseURL        string
+	RegionalAPIKey string
+	SwedenAPIKey   string
+	AllowInsecure  requests.AllowInsecure
+}
+
+func (cfg *Config) Valid() error {
+	if cfg.BaseURL == "" {
+		return fmt.Errorf("missing base url")
+	}
+	if cfg.RegionalAPIKey == "" && cfg.SwedenAPIKey == "" {
+		return fmt.Errorf("missing api key")
+	}
+	return requests.ValidateBaseURL(cfg.BaseURL, cfg.AllowInsecure)
+}
+
+type Client struct {
+	httpClient     *http.Client
+	baseURL        string
+	regionalAPIKey string
+	swedenAPIKey   string
+	isDebug        bool
+}
+
+func NewClient(cfg *Config, client *http.Client, opts ...Option) *Client {
+	c := &Client{
+		httpClient:     client,
+		baseURL:        cfg.BaseURL,
+		regionalAPIKey: cfg.RegionalAPIKey,
+		swedenAPIKey:   cfg.SwedenAPIKey,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.httpClient = requests.WrapClient(c.httpClient, requests.UserAgent(""))
+
+	return c
+}
+
+type Option func(*Client)
+
+func WithDebug() Option {
+	return func(c *Client) {
+		c.isDebug = true
+	}
+}
+
+// DownloadRegional writes the GTFS Regional zip of operator, e.g. "sl" or
+// "ul", to w.
+func (c *Client) DownloadRegional(ctx context.Context, operator string, w io.Writer) error {
+	return c.download(ctx, "/gtfs/"+operator+"/"+operator+".zip", c.regionalAPIKey, w)
+}
+
+// DownloadSweden writes the GTFS Sweden 3 zip to w.
+func (c *Client) DownloadSweden(ctx context.Context, w io.Writer) error {
+	return c.download(ctx, "/gtfs-sweden/sweden.zip", c.swedenAPIKey, w)
+}
+
+// Regional downloads and parses the GTFS Regional feed of operator.
+func (c *Client) Regional(ctx context.Context, operator string, opts ...ParseOption) (*Feed, error) {
+	return c.fetch(ctx, func(w io.Writer) error {
+		return c.DownloadRegional(ctx, operator, w)
+	}, opts)
+}
+
+// Sweden downloads and parses GTFS Sweden 3. The national feed is large,
+// so pass WithAgencies to keep only the operators needed.
+func (c *Client) Sweden(ctx context.Context, opts ...ParseOption) (*Feed, error) {
+	return c.fetch(ctx, func(w io.Writer) error {
+		return c.DownloadSweden(ctx, w)
+	}, opts)
+}
+
+// SwedenOperators parses only the given agencies of GTFS Sweden 3.
+func (c *Client) SwedenOperators(ctx context.Context, agencyIDs ...string) (*Feed, error) {
+	return c.Sweden(ctx, WithAgencies(agencyIDs...))
+}
+
+func (c *Client) download(ctx context.Context, path, key string, w io.Writer) error {
+	if key == "" {
+		return fmt.Errorf("missing api key for %s", path)
+	}
+	endpoint := c.baseURL + path
+	if c.isDebug {
+		log.Printf("url: %s\n", endpoint)
+	}
+	return requests.Download(ctx, c.httpClient, endpoint, url.Values{"key": {key}}, w)
+}
+
+// Ping checks that the feed of one of the configured keys is available
+// and accepts the key. It sends a HEAD request, since the feeds are large.
+func (c *Client) Ping(ctx context.Context) error {
+	path, key := "/gtfs-sweden/sweden.zip", c.swedenAPIKey
+	if key == "" {
+		path, key = "/gtfs/sl/sl.zip", c.regionalAPIKey
+	}
+	ctx, cancel := context.WithTimeout(ctx, requests.DefaultPingTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.URL.RawQuery = url.Values{"key": {key}}.Encode()
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed request: %w", requests.RedactURLError(err, "key"))
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return requests.NewAPIError(res)
+	}
+	return nil
+}
+
+// Healthy reports whether Ping succeeds.
+func (c *Client) Healthy(ctx context.Context) bool {
+	return c.Ping(ctx) == nil
+}
+
+// fetch downloads to a temporary file, since zip archives need random
+// access and the national feed is too large to hold in memory.
+func (c *Client) fetch(ctx context.Context, download func(io.Writer) error, opts []ParseOption) (*Feed, error) {
+	var feed *Feed
+	err := requests.DownloadTemp("gtfs-*.zip", download, func(r io.ReaderAt, size int64) error {
+		var err error
+		feed, err = Parse(r, size, opts...)
+		return err
+	})
+	return feed, err
+}
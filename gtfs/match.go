@@ -0,0 +1,293 @@
+package gtfs
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/nobina/go-trafiklab/geo"
+	"github.com/nobina/go-trafiklab/gtfsrt"
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+// DefaultMaxOffset is the distance from its shape beyond which a vehicle
+// position is considered off route.
+const DefaultMaxOffset = 150.0
+
+// backtrackSlack is how far a vehicle may appear to move backwards along
+// its shape, to absorb gps noise, before a match is ignored in favour of
+// a later part of the shape.
+const backtrackSlack = 50.0
+
+// Progress is a vehicle position snapped to the shape of its trip.
+// Distances are in meters.
+type Progress struct {
+	TripID  string
+	Snapped geo.LatLng
+	// Offset is the distance from the reported position to the shape.
+	Offset float64
+	// Distance is the distance travelled along the shape.
+	Distance float64
+	// NextStop is the index in the trip's stop times of the next stop, or
+	// their length if the vehicle has passed the last one.
+	NextStop int
+}
+
+// matchedTrip is a trip's shape with cumulative distances, and the
+// distance of each of its stops along it.
+type matchedTrip struct {
+	points    []geo.LatLng
+	cumDist   []float64
+	stopTimes []StopTime
+	stopDist  []float64
+}
+
+// Matcher snaps vehicle positions to the shapes of their trips, to tell
+// how far a vehicle is from a stop. It remembers the last progress of
+// each vehicle, so that shapes passing the same street twice match the
+// right part. It is safe for concurrent use.
+type Matcher struct {
+	shapes    *Shapes
+	maxOffset float64
+
+	mu       sync.Mutex
+	trips    map[string]*matchedTrip
+	vehicles map[string]Progress
+}
+
+type MatcherOption func(*Matcher)
+
+// WithMaxOffset overrides DefaultMaxOffset.
+func WithMaxOffset(meters float64) MatcherOption {
+	return func(m *Matcher) {
+		m.maxOffset = meters
+	}
+}
+
+func NewMatcher(shapes *Shapes, opts ...MatcherOption) *Matcher {
+	m := &Matcher{
+		shapes:    shapes,
+		maxOffset: DefaultMaxOffset,
+		trips:     map[string]*matchedTrip{},
+		vehicles:  map[string]Progress{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// MatchVehicle matches a GTFS-RT vehicle position.
+func (m *Matcher) MatchVehicle(v *gtfsrt.VehiclePosition) (Progress, error) {
+	return m.Match(v.VehicleID, v.Trip.TripID, geo.LatLng{Lat: v.Lat, Lng: v.Lng})
+}
+
+// Match snaps pos to the shape of the trip. vehicleID may be empty, in
+// which case the nearest point of the whole shape is used.
+func (m *Matcher) Match(vehicleID, tripID string, pos geo.LatLng) (Progress, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mt, err := m.trip(tripID)
+	if err != nil {
+		return Progress{}, err
+	}
+	minDist := 0.0
+	if last, ok := m.vehicles[vehicleID]; ok && vehicleID != "" && last.TripID == tripID {
+		minDist = last.Distance - backtrackSlack
+	}
+
+	p, ok := mt.snap(pos, minDist, m.maxOffset)
+	if !ok && minDist > 0 {
+		// The vehicle may have started the trip over, e.g. after a
+		// turnaround on a loop line.
+		p, ok = mt.snap(pos, 0, m.maxOffset)
+	}
+	if !ok {
+		return Progress{}, fmt.Errorf("vehicle is more than %.0f m from the shape of trip %q", m.maxOffset, tripID)
+	}
+	p.TripID = tripID
+	if vehicleID != "" {
+		m.vehicles[vehicleID] = p
+	}
+	return p, nil
+}
+
+// Forget drops the last progress of a vehicle, e.g. when it leaves the
+// feed.
+func (m *Matcher) Forget(vehicleID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.vehicles, vehicleID)
+}
+
+// DistanceTo returns the distance along the shape from p to the stop, or
+// a parent station of it, at or after the next stop.
+func (m *Matcher) DistanceTo(p Progress, stopID string) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mt, idx, err := m.stopAhead(p, stopID)
+	if err != nil {
+		return 0, err
+	}
+	return max(mt.stopDist[idx]-p.Distance, 0), nil
+}
+
+// StopsAway returns the number of stops the vehicle calls at before
+// reaching the stop, 0 if it is the next one.
+func (m *Matcher) StopsAway(p Progress, stopID string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, idx, err := m.stopAhead(p, stopID)
+	if err != nil {
+		return 0, err
+	}
+	return idx - p.NextStop, nil
+}
+
+// ETA returns the scheduled travel time from p to the stop, interpolating
+// between the stops around the vehicle. Add it to the time of the position
+// for an arrival estimate that doesn't depend on the vehicle's delay.
+func (m *Matcher) ETA(p Progress, stopID string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mt, idx, err := m.stopAhead(p, stopID)
+	if err != nil {
+		return 0, err
+	}
+	arrival, err := stopOffset(mt.stopTimes[idx].ArrivalTime)
+	if err != nil {
+		return 0, err
+	}
+	if p.NextStop == 0 {
+		return 0, fmt.Errorf("vehicle has not started trip %q", p.TripID)
+	}
+
+	prev, next := p.NextStop-1, p.NextStop
+	prevDep, err := stopOffset(mt.stopTimes[prev].DepartureTime)
+	if err != nil {
+		return 0, err
+	}
+	nextArr, err := stopOffset(mt.stopTimes[next].ArrivalTime)
+	if err != nil {
+		return 0, err
+	}
+	share := 0.0
+	if span := mt.stopDist[next] - mt.stopDist[prev]; span > 0 {
+		share = math.Min(math.Max((p.Distance-mt.stopDist[prev])/span, 0), 1)
+	}
+	now := prevDep + time.Duration(share*float64(nextArr-prevDep))
+	return max(arrival-now, 0), nil
+}
+
+func (m *Matcher) stopAhead(p Progress, stopID string) (*matchedTrip, int, error) {
+	mt, err := m.trip(p.TripID)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := p.NextStop; i < len(mt.stopTimes); i++ {
+		if m.shapes.matches(mt.stopTimes[i].StopID, stopID) {
+			return mt, i, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("trip %q does not call at %q ahead of the vehicle", p.TripID, stopID)
+}
+
+// trip returns the matched trip, building it on first use.
+func (m *Matcher) trip(tripID string) (*matchedTrip, error) {
+	if mt, ok := m.trips[tripID]; ok {
+		return mt, nil
+	}
+	trip, ok := m.shapes.trips[tripID]
+	if !ok {
+		return nil, fmt.Errorf("unknown trip %q", tripID)
+	}
+	shape := m.shapes.shapes[trip.ShapeID]
+	if len(shape) < 2 {
+		return nil, fmt.Errorf("no shape for trip %q", tripID)
+	}
+
+	mt := &matchedTrip{
+		points:    make([]geo.LatLng, len(shape)),
+		cumDist:   make([]float64, len(shape)),
+		stopTimes: m.shapes.stopTimes[tripID],
+	}
+	for i, sp := range shape {
+		mt.points[i] = geo.LatLng{Lat: sp.Lat, Lng: sp.Lon}
+		if i > 0 {
+			mt.cumDist[i] = mt.cumDist[i-1] + mt.points[i-1].DistanceTo(mt.points[i])
+		}
+	}
+	// Stops are snapped in order, each no earlier than the one before.
+	mt.stopDist = make([]float64, len(mt.stopTimes))
+	last := 0.0
+	for i, st := range mt.stopTimes {
+		if stop, ok := m.shapes.stops[st.StopID]; ok {
+			if p, ok := mt.snap(geo.LatLng{Lat: stop.Lat, Lng: stop.Lon}, last, math.Inf(1)); ok {
+				last = p.Distance
+			}
+		}
+		mt.stopDist[i] = last
+	}
+	m.trips[tripID] = mt
+	return mt, nil
+}
+
+// snap returns the nearest point of the shape at least minDist along it,
+// failing if it is further than maxOffset from pos.
+func (mt *matchedTrip) snap(pos geo.LatLng, minDist, maxOffset float64) (Progress, bool) {
+	best, found := Progress{}, false
+	for i := 1; i < len(mt.points); i++ {
+		if mt.cumDist[i] < minDist {
+			continue
+		}
+		a, b := mt.points[i-1], mt.points[i]
+		t := projection(pos, a, b)
+		length := mt.cumDist[i] - mt.cumDist[i-1]
+		if dist := mt.cumDist[i-1] + t*length; dist < minDist && length > 0 {
+			t = (minDist - mt.cumDist[i-1]) / length
+		}
+		snapped := geo.LatLng{Lat: a.Lat + t*(b.Lat-a.Lat), Lng: a.Lng + t*(b.Lng-a.Lng)}
+		if offset := pos.DistanceTo(snapped); !found || offset < best.Offset {
+			best = Progress{Snapped: snapped, Offset: offset, Distance: mt.cumDist[i-1] + t*length}
+			found = true
+		}
+	}
+	if !found || best.Offset > maxOffset {
+		return Progress{}, false
+	}
+	best.NextStop = len(mt.stopDist)
+	for i, d := range mt.stopDist {
+		if d > best.Distance {
+			best.NextStop = i
+			break
+		}
+	}
+	return best, true
+}
+
+// projection returns the position of the projection of p on a-b as a
+// share of the segment, on a plane tangent at a.
+func projection(p, a, b geo.LatLng) float64 {
+	cosLat := math.Cos(a.Lat * math.Pi / 180)
+	px, py := (p.Lng-a.Lng)*cosLat, p.Lat-a.Lat
+	bx, by := (b.Lng-a.Lng)*cosLat, b.Lat-a.Lat
+	lenSq := bx*bx + by*by
+	if lenSq == 0 {
+		return 0
+	}
+	return math.Max(0, math.Min(1, (px*bx+py*by)/lenSq))
+}
+
+// offsetDay is an arbitrary day without a daylight saving switch, used to
+// turn timetable times into offsets from midnight.
+var offsetDay = timeutils.Date(2024, time.January, 15)
+
+func stopOffset(clock string) (time.Duration, error) {
+	t, err := timeutils.ServiceTime(offsetDay, clock)
+	if err != nil {
+		return 0, err
+	}
+	return t.Sub(offsetDay), nil
+}
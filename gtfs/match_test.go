@@ -0,0 +1,180 @@
+package gtfs_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/nobina/go-trafiklab/geo"
+	"github.com/nobina/go-trafiklab/gtfs"
+)
+
+// The test trips run along the parallel at lat, where 0.01 degrees of
+// longitude are about 573 m.
+const lat = 59.0
+
+func at(lng float64) geo.LatLng {
+	return geo.LatLng{Lat: lat, Lng: lng}
+}
+
+// testFeed has a straight trip east from 17.995, calling at 18.00 to 18.03
+// every two minutes, and a loop trip out to 18.02 and back along the same
+// street, calling at 18.01 on both ways.
+func testFeed() *gtfs.Feed {
+	feed := &gtfs.Feed{
+		Trips: []gtfs.Trip{
+			{ID: "straight", RouteID: "1", ShapeID: "straight"},
+			{ID: "loop", RouteID: "2", ShapeID: "loop"},
+		},
+		Stops: []gtfs.Stop{
+			{ID: "a", Lat: lat, Lon: 18.00},
+			{ID: "b", Lat: lat, Lon: 18.01},
+			{ID: "c", Lat: lat, Lon: 18.02},
+			{ID: "d", Lat: lat, Lon: 18.03},
+		},
+	}
+	for i, lng := range []float64{17.995, 18.00, 18.005, 18.01, 18.015, 18.02, 18.025, 18.03} {
+		feed.Shapes = append(feed.Shapes, gtfs.ShapePoint{ShapeID: "straight", Lat: lat, Lon: lng, Sequence: i})
+	}
+	for i, lng := range []float64{18.00, 18.01, 18.02, 18.01, 18.00} {
+		feed.Shapes = append(feed.Shapes, gtfs.ShapePoint{ShapeID: "loop", Lat: lat, Lon: lng, Sequence: i})
+	}
+	for i, st := range []struct{ stop, arr, dep string }{
+		{"a", "08:00:00", "08:00:00"},
+		{"b", "08:02:00", "08:02:00"},
+		{"c", "08:04:00", "08:04:00"},
+		{"d", "08:06:00", "08:06:00"},
+	} {
+		feed.StopTimes = append(feed.StopTimes, gtfs.StopTime{TripID: "straight", StopID: st.stop, ArrivalTime: st.arr, DepartureTime: st.dep, StopSequence: i})
+	}
+	for i, stop := range []string{"a", "b", "c", "b", "a"} {
+		feed.StopTimes = append(feed.StopTimes, gtfs.StopTime{TripID: "loop", StopID: stop, ArrivalTime: "08:00:00", DepartureTime: "08:00:00", StopSequence: i})
+	}
+	return feed
+}
+
+func near(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestMatchStraight(t *testing.T) {
+	m := gtfs.NewMatcher(gtfs.NewShapes(testFeed()))
+	stopA := at(17.995).DistanceTo(at(18.00))
+	for _, tc := range []struct {
+		name     string
+		pos      geo.LatLng
+		distance float64
+		nextStop int
+	}{
+		{"shape start", at(17.995), 0, 0},
+		{"first stop", at(18.00), stopA, 1},
+		{"north of the shape", geo.LatLng{Lat: lat + 0.0005, Lng: 18.015}, at(17.995).DistanceTo(at(18.015)), 2},
+		{"last stop", at(18.03), at(17.995).DistanceTo(at(18.03)), 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := m.Match("", "straight", tc.pos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !near(p.Distance, tc.distance, 1) || p.NextStop != tc.nextStop {
+				t.Fatalf("got distance %.1f and next stop %d, want %.1f and %d", p.Distance, p.NextStop, tc.distance, tc.nextStop)
+			}
+		})
+	}
+}
+
+func TestMatchOffRoute(t *testing.T) {
+	m := gtfs.NewMatcher(gtfs.NewShapes(testFeed()))
+	if _, err := m.Match("v", "straight", geo.LatLng{Lat: lat + 0.01, Lng: 18.01}); err == nil {
+		t.Fatal("matched a vehicle a kilometre off its route")
+	}
+	p, err := gtfs.NewMatcher(gtfs.NewShapes(testFeed()), gtfs.WithMaxOffset(2000)).Match("v", "straight", geo.LatLng{Lat: lat + 0.01, Lng: 18.01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(p.Offset, at(18.01).DistanceTo(geo.LatLng{Lat: lat + 0.01, Lng: 18.01}), 1) {
+		t.Fatalf("offset = %.1f", p.Offset)
+	}
+	if _, err := m.Match("v", "unknown", at(18.01)); err == nil {
+		t.Fatal("matched an unknown trip")
+	}
+}
+
+func TestMatchLoop(t *testing.T) {
+	m := gtfs.NewMatcher(gtfs.NewShapes(testFeed()))
+	leg := at(18.00).DistanceTo(at(18.01))
+
+	// Without a vehicle the first pass of the street wins.
+	p, err := m.Match("", "loop", at(18.01))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(p.Distance, leg, 1) {
+		t.Fatalf("distance without a vehicle = %.1f, want %.1f", p.Distance, leg)
+	}
+
+	// A vehicle that reached the turnaround matches the way back.
+	if _, err := m.Match("v", "loop", at(18.02)); err != nil {
+		t.Fatal(err)
+	}
+	p, err = m.Match("v", "loop", at(18.01))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(p.Distance, 3*leg, 1) || p.NextStop != 4 {
+		t.Fatalf("distance on the way back = %.1f and next stop %d, want %.1f and 4", p.Distance, p.NextStop, 3*leg)
+	}
+	if n, err := m.StopsAway(p, "a"); err != nil || n != 0 {
+		t.Fatalf("StopsAway(a) = %d, %v, want 0", n, err)
+	}
+	if _, err := m.StopsAway(p, "c"); err == nil {
+		t.Fatal("found the turnaround ahead of a vehicle on the way back")
+	}
+
+	// After a forgotten vehicle starts over, the first pass wins again.
+	m.Forget("v")
+	p, err = m.Match("v", "loop", at(18.01))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !near(p.Distance, leg, 1) {
+		t.Fatalf("distance after Forget = %.1f, want %.1f", p.Distance, leg)
+	}
+}
+
+func TestETA(t *testing.T) {
+	m := gtfs.NewMatcher(gtfs.NewShapes(testFeed()))
+	p, err := m.Match("v", "straight", at(18.015))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		stop string
+		want time.Duration
+	}{
+		{"c", time.Minute},
+		{"d", 3 * time.Minute},
+	} {
+		eta, err := m.ETA(p, tc.stop)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !near(eta.Seconds(), tc.want.Seconds(), 1) {
+			t.Fatalf("ETA(%s) = %s, want %s", tc.stop, eta, tc.want)
+		}
+	}
+	if d, err := m.DistanceTo(p, "d"); err != nil || !near(d, at(18.015).DistanceTo(at(18.03)), 1) {
+		t.Fatalf("DistanceTo(d) = %.1f, %v", d, err)
+	}
+	if _, err := m.ETA(p, "b"); err == nil {
+		t.Fatal("ETA to a passed stop succeeded")
+	}
+
+	start, err := m.Match("w", "straight", at(17.995))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ETA(start, "b"); err == nil {
+		t.Fatal("ETA of a vehicle before the first stop succeeded")
+	}
+}
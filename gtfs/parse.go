@@ -0,0 +1,434 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+type parseConfig struct {
+	agencies map[string]bool
+}
+
+type ParseOption func(*parseConfig)
+
+// WithAgencies only keeps the routes of the given agencies, with their
+// trips, stop times, services and the stops they use. Used to scope the
+// national feed to single operators.
+func WithAgencies(ids ...string) ParseOption {
+	return func(c *parseConfig) {
+		if c.agencies == nil {
+			c.agencies = map[string]bool{}
+		}
+		for _, id := range ids {
+			c.agencies[id] = true
+		}
+	}
+}
+
+// Parse reads a GTFS zip archive of size bytes.
+func Parse(r io.ReaderAt, size int64, opts ...ParseOption) (*Feed, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	return ParseFS(zr, opts...)
+}
+
+// ParseFS reads the GTFS files in the root of fsys, e.g. an unpacked feed
+// opened with os.DirFS.
+func ParseFS(fsys fs.FS, opts ...ParseOption) (*Feed, error) {
+	cfg := &parseConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	p := &parser{fsys: fsys, cfg: cfg, feed: &Feed{}}
+
+	// Order matters when filtering by agency: every file only keeps rows
+	// referenced by the files before it.
+	steps := []struct {
+		name     string
+		required bool
+		fn       func(row) error
+	}{
+		{"agency.txt", true, p.agency},
+		{"routes.txt", true, p.route},
+		{"trips.txt", true, p.trip},
+		{"stop_times.txt", true, p.stopTime},
+		{"stops.txt", true, p.stop},
+		{"calendar.txt", false, p.calendar},
+		{"calendar_dates.txt", false, p.calendarDate},
+		{"shapes.txt", false, p.shape},
+		{"feed_info.txt", false, p.feedInfo},
+	}
+	for _, s := range steps {
+		if err := p.readFile(s.name, s.required, s.fn); err != nil {
+			return nil, err
+		}
+		if s.name == "stops.txt" {
+			p.keepParentStations()
+		}
+	}
+	return p.feed, nil
+}
+
+type parser struct {
+	fsys fs.FS
+	cfg  *parseConfig
+	feed *Feed
+
+	// agencyIDs are all agencies of the feed, filtered or not.
+	agencyIDs []string
+
+	// Set when filtering by agency.
+	routes   map[string]bool
+	trips    map[string]bool
+	services map[string]bool
+	stops    map[string]bool
+	shapes   map[string]bool
+}
+
+func (p *parser) filtering() bool {
+	return p.cfg.agencies != nil
+}
+
+func (p *parser) readFile(name string, required bool, fn func(row) error) error {
+	f, err := p.fsys.Open(name)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer f.Close()
+	if err := readCSV(f, fn); err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return nil
+}
+
+func (p *parser) agency(r row) error {
+	a := Agency{
+		ID:       r.get("agency_id"),
+		Name:     r.get("agency_name"),
+		URL:      r.get("agency_url"),
+		Timezone: r.get("agency_timezone"),
+	}
+	p.agencyIDs = append(p.agencyIDs, a.ID)
+	if p.filtering() && !p.cfg.agencies[a.ID] {
+		return nil
+	}
+	p.feed.Agencies = append(p.feed.Agencies, a)
+	return nil
+}
+
+func (p *parser) route(r row) error {
+	typ, err := r.int("route_type")
+	if err != nil {
+		return err
+	}
+	rt := Route{
+		ID:        r.get("route_id"),
+		AgencyID:  r.get("agency_id"),
+		ShortName: r.get("route_short_name"),
+		LongName:  r.get("route_long_name"),
+		Type:      typ,
+		Desc:      r.get("route_desc"),
+	}
+	if p.filtering() {
+		agencyID := rt.AgencyID
+		// agency_id is optional in routes.txt when the feed has a
+		// single agency.
+		if agencyID == "" && len(p.agencyIDs) == 1 {
+			agencyID = p.agencyIDs[0]
+		}
+		if !p.cfg.agencies[agencyID] {
+			return nil
+		}
+		if p.routes == nil {
+			p.routes = map[string]bool{}
+		}
+		p.routes[rt.ID] = true
+	}
+	p.feed.Routes = append(p.feed.Routes, rt)
+	return nil
+}
+
+func (p *parser) trip(r row) error {
+	dir, err := r.int("direction_id")
+	if err != nil {
+		return err
+	}
+	t := Trip{
+		ID:          r.get("trip_id"),
+		RouteID:     r.get("route_id"),
+		ServiceID:   r.get("service_id"),
+		Headsign:    r.get("trip_headsign"),
+		ShortName:   r.get("trip_short_name"),
+		DirectionID: dir,
+		ShapeID:     r.get("shape_id"),
+	}
+	if p.filtering() {
+		if !p.routes[t.RouteID] {
+			return nil
+		}
+		if p.trips == nil {
+			p.trips = map[string]bool{}
+			p.services = map[string]bool{}
+		}
+		if p.shapes == nil {
+			p.shapes = map[string]bool{}
+		}
+		p.trips[t.ID] = true
+		p.services[t.ServiceID] = true
+		p.shapes[t.ShapeID] = true
+	}
+	p.feed.Trips = append(p.feed.Trips, t)
+	return nil
+}
+
+func (p *parser) stopTime(r row) error {
+	tripID := r.get("trip_id")
+	if p.filtering() && !p.trips[tripID] {
+		return nil
+	}
+	seq, err := r.int("stop_sequence")
+	if err != nil {
+		return err
+	}
+	pickup, err := r.int("pickup_type")
+	if err != nil {
+		return err
+	}
+	dropOff, err := r.int("drop_off_type")
+	if err != nil {
+		return err
+	}
+	dist, err := r.float("shape_dist_traveled")
+	if err != nil {
+		return err
+	}
+	st := StopTime{
+		TripID:            tripID,
+		ArrivalTime:       r.get("arrival_time"),
+		DepartureTime:     r.get("departure_time"),
+		StopID:            r.get("stop_id"),
+		StopSequence:      seq,
+		Headsign:          r.get("stop_headsign"),
+		PickupType:        pickup,
+		DropOffType:       dropOff,
+		ShapeDistTraveled: dist,
+	}
+	if p.filtering() {
+		if p.stops == nil {
+			p.stops = map[string]bool{}
+		}
+		p.stops[st.StopID] = true
+	}
+	p.feed.StopTimes = append(p.feed.StopTimes, st)
+	return nil
+}
+
+func (p *parser) stop(r row) error {
+	lat, err := r.float("stop_lat")
+	if err != nil {
+		return err
+	}
+	lon, err := r.float("stop_lon")
+	if err != nil {
+		return err
+	}
+	locType, err := r.int("location_type")
+	if err != nil {
+		return err
+	}
+	p.feed.Stops = append(p.feed.Stops, Stop{
+		ID:            r.get("stop_id"),
+		Code:          r.get("stop_code"),
+		Name:          r.get("stop_name"),
+		Lat:           lat,
+		Lon:           lon,
+		LocationType:  locType,
+		ParentStation: r.get("parent_station"),
+		PlatformCode:  r.get("platform_code"),
+		ZoneID:        r.get("zone_id"),
+	})
+	return nil
+}
+
+// keepParentStations drops the stops not used by the kept stop times,
+// except the parent stations of those that are.
+func (p *parser) keepParentStations() {
+	if !p.filtering() {
+		return
+	}
+	keep := map[string]bool{}
+	for _, s := range p.feed.Stops {
+		if p.stops[s.ID] {
+			keep[s.ID] = true
+			if s.ParentStation != "" {
+				keep[s.ParentStation] = true
+			}
+		}
+	}
+	stops := p.feed.Stops[:0]
+	for _, s := range p.feed.Stops {
+		if keep[s.ID] {
+			stops = append(stops, s)
+		}
+	}
+	p.feed.Stops = stops
+}
+
+func (p *parser) calendar(r row) error {
+	c := Calendar{
+		ServiceID: r.get("service_id"),
+		StartDate: r.get("start_date"),
+		EndDate:   r.get("end_date"),
+	}
+	if p.filtering() && !p.services[c.ServiceID] {
+		return nil
+	}
+	days := []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+	for i, day := range days {
+		c.Weekdays[i] = r.get(day) == "1"
+	}
+	p.feed.Calendars = append(p.feed.Calendars, c)
+	return nil
+}
+
+func (p *parser) calendarDate(r row) error {
+	typ, err := r.int("exception_type")
+	if err != nil {
+		return err
+	}
+	cd := CalendarDate{
+		ServiceID:     r.get("service_id"),
+		Date:          r.get("date"),
+		ExceptionType: typ,
+	}
+	if p.filtering() && !p.services[cd.ServiceID] {
+		return nil
+	}
+	p.feed.CalendarDates = append(p.feed.CalendarDates, cd)
+	return nil
+}
+
+func (p *parser) shape(r row) error {
+	shapeID := r.get("shape_id")
+	if p.filtering() && !p.shapes[shapeID] {
+		return nil
+	}
+	lat, err := r.float("shape_pt_lat")
+	if err != nil {
+		return err
+	}
+	lon, err := r.float("shape_pt_lon")
+	if err != nil {
+		return err
+	}
+	seq, err := r.int("shape_pt_sequence")
+	if err != nil {
+		return err
+	}
+	dist, err := r.float("shape_dist_traveled")
+	if err != nil {
+		return err
+	}
+	p.feed.Shapes = append(p.feed.Shapes, ShapePoint{
+		ShapeID:      shapeID,
+		Lat:          lat,
+		Lon:          lon,
+		Sequence:     seq,
+		DistTraveled: dist,
+	})
+	return nil
+}
+
+func (p *parser) feedInfo(r row) error {
+	p.feed.FeedInfo = &FeedInfo{
+		PublisherName: r.get("feed_publisher_name"),
+		PublisherURL:  r.get("feed_publisher_url"),
+		Lang:          r.get("feed_lang"),
+		StartDate:     r.get("feed_start_date"),
+		EndDate:       r.get("feed_end_date"),
+		Version:       r.get("feed_version"),
+	}
+	return nil
+}
+
+// row is a csv record with access by column name.
+type row struct {
+	columns map[string]int
+	record  []string
+}
+
+func (r row) get(name string) string {
+	i, ok := r.columns[name]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+	return r.record[i]
+}
+
+// int parses an optional integer column, 0 if empty.
+func (r row) int(name string) (int, error) {
+	v := r.get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return n, nil
+}
+
+func (r row) float(name string) (float64, error) {
+	v := r.get(name)
+	if v == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return f, nil
+}
+
+func readCSV(r io.Reader, fn func(row) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read header: %w", err)
+	}
+	// GTFS files are often saved with a byte order mark
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))] = i
+	}
+
+	line := 1
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		line++
+		if err != nil {
+			return fmt.Errorf("failed to read line %d: %w", line, err)
+		}
+		if err := fn(row{columns: columns, record: record}); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
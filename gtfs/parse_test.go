@@ -0,0 +1,42 @@
+package gtfs_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/nobina/go-trafiklab/gtfs"
+)
+
+func singleAgencyFeed() fstest.MapFS {
+	return fstest.MapFS{
+		"agency.txt": {Data: []byte("agency_id,agency_name,agency_url,agency_timezone\n" +
+			"waxholm,Waxholmsbolaget,https://waxholmsbolaget.se,Europe/Stockholm\n")},
+		"routes.txt": {Data: []byte("route_id,route_short_name,route_type\n" +
+			"r1,80,4\n")},
+		"trips.txt": {Data: []byte("route_id,service_id,trip_id\n" +
+			"r1,s1,t1\n")},
+		"stop_times.txt": {Data: []byte("trip_id,arrival_time,departure_time,stop_id,stop_sequence\n" +
+			"t1,08:00:00,08:00:00,a,1\n")},
+		"stops.txt": {Data: []byte("stop_id,stop_name,stop_lat,stop_lon\n" +
+			"a,Strömkajen,59.3297,18.0765\n")},
+	}
+}
+
+func TestParseAgencyFilterSingleAgency(t *testing.T) {
+	feed, err := gtfs.ParseFS(singleAgencyFeed(), gtfs.WithAgencies("waxholm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Routes) != 1 || len(feed.Trips) != 1 || len(feed.StopTimes) != 1 || len(feed.Stops) != 1 {
+		t.Fatalf("got %d routes, %d trips, %d stop times and %d stops, want 1 of each",
+			len(feed.Routes), len(feed.Trips), len(feed.StopTimes), len(feed.Stops))
+	}
+
+	feed, err = gtfs.ParseFS(singleAgencyFeed(), gtfs.WithAgencies("sl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Routes) != 0 {
+		t.Fatalf("got %d routes for another agency", len(feed.Routes))
+	}
+}
@@ -0,0 +1,134 @@
+package gtfs
+
+import (
+	"sort"
+	"time"
+
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+// maxServiceSpan is how far past midnight a timetable time may run, so
+// that trips of the previous service days are considered.
+const maxServiceSpan = 48 * time.Hour
+
+// Schedule answers timetable queries over a parsed feed. It is safe for
+// concurrent use.
+type Schedule struct {
+	trips      map[string]*Trip
+	routes     map[string]*Route
+	stopTimes  map[string][]*StopTime
+	calendars  map[string]*Calendar
+	exceptions map[string]map[string]int
+	lastSeq    map[string]int
+}
+
+func NewSchedule(feed *Feed) *Schedule {
+	s := &Schedule{
+		trips:      map[string]*Trip{},
+		routes:     map[string]*Route{},
+		stopTimes:  map[string][]*StopTime{},
+		calendars:  map[string]*Calendar{},
+		exceptions: map[string]map[string]int{},
+		lastSeq:    map[string]int{},
+	}
+	for i := range feed.Trips {
+		s.trips[feed.Trips[i].ID] = &feed.Trips[i]
+	}
+	for i := range feed.Routes {
+		s.routes[feed.Routes[i].ID] = &feed.Routes[i]
+	}
+	for i := range feed.StopTimes {
+		st := &feed.StopTimes[i]
+		s.stopTimes[st.StopID] = append(s.stopTimes[st.StopID], st)
+		s.lastSeq[st.TripID] = max(s.lastSeq[st.TripID], st.StopSequence)
+	}
+	for i := range feed.Calendars {
+		s.calendars[feed.Calendars[i].ServiceID] = &feed.Calendars[i]
+	}
+	for _, cd := range feed.CalendarDates {
+		if s.exceptions[cd.ServiceID] == nil {
+			s.exceptions[cd.ServiceID] = map[string]int{}
+		}
+		s.exceptions[cd.ServiceID][cd.Date] = cd.ExceptionType
+	}
+	return s
+}
+
+// RunsOn reports whether service runs on the service day of day.
+func (s *Schedule) RunsOn(serviceID string, day time.Time) bool {
+	date := day.In(timeutils.EuropeStockholm()).Format("20060102")
+	switch s.exceptions[serviceID][date] {
+	case ServiceAdded:
+		return true
+	case ServiceRemoved:
+		return false
+	}
+	c, ok := s.calendars[serviceID]
+	if !ok {
+		return false
+	}
+	return c.Weekdays[day.In(timeutils.EuropeStockholm()).Weekday()] && c.StartDate <= date && date <= c.EndDate
+}
+
+// ScheduledDeparture is a departure according to the timetable only.
+type ScheduledDeparture struct {
+	Time     time.Time
+	Day      time.Time
+	StopTime *StopTime
+	Trip     *Trip
+	Route    *Route
+}
+
+// Headsign returns the headsign of the stop time, falling back to the
+// trip's.
+func (d *ScheduledDeparture) Headsign() string {
+	if d.StopTime.Headsign != "" {
+		return d.StopTime.Headsign
+	}
+	return d.Trip.Headsign
+}
+
+// Departures returns the departures from stopIDs in [from, from+window),
+// ordered by time. Stop times where boarding is not allowed and the last
+// stop of each trip are skipped.
+func (s *Schedule) Departures(stopIDs []string, from time.Time, window time.Duration) []ScheduledDeparture {
+	to := from.Add(window)
+	first := timeutils.ServiceDay(from.Add(-maxServiceSpan))
+	var days []time.Time
+	for day := first; !day.After(to); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+
+	var deps []ScheduledDeparture
+	for _, stopID := range stopIDs {
+		for _, st := range s.stopTimes[stopID] {
+			if st.PickupType == 1 || st.DepartureTime == "" {
+				continue
+			}
+			trip, ok := s.trips[st.TripID]
+			if !ok || st.StopSequence >= s.lastSeq[st.TripID] {
+				continue
+			}
+			for _, day := range days {
+				t, err := timeutils.ServiceTime(day, st.DepartureTime)
+				if err != nil || t.Before(from) || !t.Before(to) {
+					continue
+				}
+				if !s.RunsOn(trip.ServiceID, day) {
+					continue
+				}
+				deps = append(deps, ScheduledDeparture{
+					Time:     t,
+					Day:      day,
+					StopTime: st,
+					Trip:     trip,
+					Route:    s.routes[trip.RouteID],
+				})
+			}
+		}
+	}
+	sort.SliceStable(deps, func(i, j int) bool {
+		return deps[i].Time.Before(deps[j].Time)
+	})
+	return deps
+}
@@ -0,0 +1,145 @@
+package gtfs
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nobina/go-trafiklab/geo"
+)
+
+// Shapes looks up the geometry travelled between two stops of a trip. It
+// is safe for concurrent use.
+type Shapes struct {
+	shapes    map[string][]ShapePoint
+	trips     map[string]*Trip
+	stops     map[string]*Stop
+	stopTimes map[string][]StopTime
+	routes    map[string][]string
+}
+
+func NewShapes(feed *Feed) *Shapes {
+	s := &Shapes{
+		shapes:    map[string][]ShapePoint{},
+		trips:     map[string]*Trip{},
+		stops:     map[string]*Stop{},
+		stopTimes: map[string][]StopTime{},
+		routes:    map[string][]string{},
+	}
+	for _, p := range feed.Shapes {
+		s.shapes[p.ShapeID] = append(s.shapes[p.ShapeID], p)
+	}
+	for _, points := range s.shapes {
+		sort.Slice(points, func(i, j int) bool {
+			return points[i].Sequence < points[j].Sequence
+		})
+	}
+	for i := range feed.Trips {
+		t := &feed.Trips[i]
+		s.trips[t.ID] = t
+		if t.ShapeID != "" {
+			s.routes[t.RouteID] = append(s.routes[t.RouteID], t.ID)
+		}
+	}
+	for i := range feed.Stops {
+		s.stops[feed.Stops[i].ID] = &feed.Stops[i]
+	}
+	for _, st := range feed.StopTimes {
+		s.stopTimes[st.TripID] = append(s.stopTimes[st.TripID], st)
+	}
+	for _, sts := range s.stopTimes {
+		sort.Slice(sts, func(i, j int) bool {
+			return sts[i].StopSequence < sts[j].StopSequence
+		})
+	}
+	return s
+}
+
+// Shape returns the points of a shape in order.
+func (s *Shapes) Shape(shapeID string) []ShapePoint {
+	return s.shapes[shapeID]
+}
+
+// Trip returns the part of the trip's shape between two of its stops.
+func (s *Shapes) Trip(tripID, fromStopID, toStopID string) ([]ShapePoint, error) {
+	trip, ok := s.trips[tripID]
+	if !ok {
+		return nil, fmt.Errorf("unknown trip %q", tripID)
+	}
+	points := s.shapes[trip.ShapeID]
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no shape for trip %q", tripID)
+	}
+	from, to, ok := s.stopPair(tripID, fromStopID, toStopID)
+	if !ok {
+		return nil, fmt.Errorf("trip %q does not go from %q to %q", tripID, fromStopID, toStopID)
+	}
+
+	if from.ShapeDistTraveled > 0 || to.ShapeDistTraveled > 0 {
+		var segment []ShapePoint
+		for _, p := range points {
+			if p.DistTraveled >= from.ShapeDistTraveled && p.DistTraveled <= to.ShapeDistTraveled {
+				segment = append(segment, p)
+			}
+		}
+		if len(segment) >= 2 {
+			return segment, nil
+		}
+	}
+
+	// Without distances, cut the shape at the points nearest the stops.
+	start := s.nearest(points, from.StopID, 0)
+	end := s.nearest(points, to.StopID, start)
+	return points[start : end+1], nil
+}
+
+// Route returns the shape between two stops of any trip of the route that
+// passes both in that order, for legs that don't carry a trip id.
+func (s *Shapes) Route(routeID, fromStopID, toStopID string) ([]ShapePoint, error) {
+	for _, tripID := range s.routes[routeID] {
+		if _, _, ok := s.stopPair(tripID, fromStopID, toStopID); ok {
+			return s.Trip(tripID, fromStopID, toStopID)
+		}
+	}
+	return nil, fmt.Errorf("no trip of route %q goes from %q to %q", routeID, fromStopID, toStopID)
+}
+
+// stopPair finds the stop times of from and a later to on the trip. Stop
+// ids match the stop or its parent station.
+func (s *Shapes) stopPair(tripID, fromStopID, toStopID string) (from, to StopTime, ok bool) {
+	fromIdx := -1
+	for i, st := range s.stopTimes[tripID] {
+		if fromIdx < 0 && s.matches(st.StopID, fromStopID) {
+			fromIdx = i
+			from = st
+			continue
+		}
+		if fromIdx >= 0 && s.matches(st.StopID, toStopID) {
+			return from, st, true
+		}
+	}
+	return StopTime{}, StopTime{}, false
+}
+
+func (s *Shapes) matches(stopID, id string) bool {
+	if stopID == id {
+		return true
+	}
+	stop, ok := s.stops[stopID]
+	return ok && stop.ParentStation == id
+}
+
+// nearest returns the index of the point from start on nearest the stop.
+func (s *Shapes) nearest(points []ShapePoint, stopID string, start int) int {
+	stop, ok := s.stops[stopID]
+	if !ok {
+		return start
+	}
+	best, bestDist := start, -1.0
+	for i := start; i < len(points); i++ {
+		d := geo.Distance(stop.Lat, stop.Lon, points[i].Lat, points[i].Lon)
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
@@ -0,0 +1,81 @@
+package gtfsrt
+
+import (
+	"context"
+
+	gtfsproto "github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+
+	"github.com/nobina/go-trafiklab/alerts"
+)
+
+// ServiceAlerts fetches the service alerts of operator as unified alerts.
+func (c *Client) ServiceAlerts(ctx context.Context, operator string) ([]alerts.Alert, error) {
+	msg, err := c.Feed(ctx, operator, FeedServiceAlerts)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeServiceAlerts(msg), nil
+}
+
+// DecodeServiceAlerts converts the alert entities of msg. Lines are route
+// ids and stops are stop ids of the static feed.
+func DecodeServiceAlerts(msg *gtfsproto.FeedMessage) []alerts.Alert {
+	var out []alerts.Alert
+	for _, e := range msg.GetEntity() {
+		al := e.GetAlert()
+		if al == nil || e.GetIsDeleted() {
+			continue
+		}
+		a := alerts.Alert{
+			ID:       e.GetId(),
+			Source:   alerts.SourceGTFSRT,
+			Texts:    texts(al),
+			Cause:    al.GetCause().String(),
+			Effect:   al.GetEffect().String(),
+			Severity: severity(al.GetSeverityLevel()),
+		}
+		for _, p := range al.GetActivePeriod() {
+			a.Periods = append(a.Periods, alerts.Period{
+				Start: unixTime(p.GetStart()),
+				End:   unixTime(p.GetEnd()),
+			})
+		}
+		for _, sel := range al.GetInformedEntity() {
+			if id := sel.GetRouteId(); id != "" {
+				a.Lines = append(a.Lines, id)
+			}
+			if id := sel.GetStopId(); id != "" {
+				a.Stops = append(a.Stops, id)
+			}
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+func texts(al *gtfsproto.Alert) map[string]alerts.Text {
+	texts := map[string]alerts.Text{}
+	set := func(s *gtfsproto.TranslatedString, fn func(*alerts.Text, string)) {
+		for _, tr := range s.GetTranslation() {
+			t := texts[tr.GetLanguage()]
+			fn(&t, tr.GetText())
+			texts[tr.GetLanguage()] = t
+		}
+	}
+	set(al.GetHeaderText(), func(t *alerts.Text, s string) { t.Header = s })
+	set(al.GetDescriptionText(), func(t *alerts.Text, s string) { t.Description = s })
+	set(al.GetUrl(), func(t *alerts.Text, s string) { t.URL = s })
+	return texts
+}
+
+func severity(level gtfsproto.Alert_SeverityLevel) alerts.Severity {
+	switch level {
+	case gtfsproto.Alert_INFO:
+		return alerts.SeverityInfo
+	case gtfsproto.Alert_WARNING:
+		return alerts.SeverityWarning
+	case gtfsproto.Alert_SEVERE:
+		return alerts.SeveritySevere
+	}
+	return alerts.SeverityUnknown
+}
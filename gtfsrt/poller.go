@@ -0,0 +1,189 @@
+package gtfsrt
+
+import (
+	"context"
+	"crypto/sha256"
+	"time"
+
+	gtfsproto "github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+// MinRefreshInterval is the shortest interval a Poller polls at. The feeds
+// aren't regenerated more often and polling faster only burns quota.
+const MinRefreshInterval = 3 * time.Second
+
+const DefaultRefreshInterval = 15 * time.Second
+
+// Update is an entity that is new or changed since the previous poll, or
+// removed from the feed if Deleted is set.
+type Update struct {
+	Entity        *gtfsproto.FeedEntity
+	Deleted       bool
+	FeedTimestamp time.Time
+}
+
+type entityState struct {
+	timestamp uint64
+	hash      [sha256.Size]byte
+}
+
+// Poller polls one feed and emits only the entities that changed.
+type Poller struct {
+	client   *Client
+	operator string
+	feed     FeedType
+	interval time.Duration
+	clock    timeutils.Clock
+	onError  func(error)
+
+	validators    validators
+	feedTimestamp uint64
+	entities      map[string]entityState
+}
+
+type PollerOption func(*Poller)
+
+// WithInterval sets the poll interval, at least MinRefreshInterval.
+func WithInterval(d time.Duration) PollerOption {
+	return func(p *Poller) {
+		p.interval = max(d, MinRefreshInterval)
+	}
+}
+
+// WithPollerClock sets the clock that paces polls.
+func WithPollerClock(clock timeutils.Clock) PollerOption {
+	return func(p *Poller) {
+		p.clock = clock
+	}
+}
+
+// WithPollErrorHandler is called when a poll fails. The poller keeps
+// polling.
+func WithPollErrorHandler(fn func(error)) PollerOption {
+	return func(p *Poller) {
+		p.onError = fn
+	}
+}
+
+func NewPoller(client *Client, operator string, feed FeedType, opts ...PollerOption) *Poller {
+	p := &Poller{
+		client:   client,
+		operator: operator,
+		feed:     feed,
+		interval: DefaultRefreshInterval,
+		clock:    timeutils.SystemClock,
+		onError:  func(error) {},
+		entities: map[string]entityState{},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Run polls until ctx is done, sending changed entities on updates. The
+// first poll emits every entity.
+func (p *Poller) Run(ctx context.Context, updates chan<- Update) error {
+	for {
+		if err := p.poll(ctx, updates); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			p.onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.clock.After(p.interval):
+		}
+	}
+}
+
+func (p *Poller) poll(ctx context.Context, updates chan<- Update) error {
+	msg, v, err := p.client.fetch(ctx, p.operator, p.feed, p.validators)
+	if err != nil {
+		return err
+	}
+	p.validators = v
+	if msg == nil {
+		return nil
+	}
+	ts := msg.GetHeader().GetTimestamp()
+	if ts != 0 && ts == p.feedTimestamp {
+		return nil
+	}
+	p.feedTimestamp = ts
+	feedTime := unixTime(ts)
+
+	seen := make(map[string]bool, len(msg.GetEntity()))
+	for _, e := range msg.GetEntity() {
+		id := e.GetId()
+		seen[id] = true
+		if e.GetIsDeleted() {
+			if _, ok := p.entities[id]; ok {
+				delete(p.entities, id)
+				if err := send(ctx, updates, Update{Entity: e, Deleted: true, FeedTimestamp: feedTime}); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		state, err := stateOf(e)
+		if err != nil {
+			return err
+		}
+		if prev, ok := p.entities[id]; ok && prev == state {
+			continue
+		}
+		p.entities[id] = state
+		if err := send(ctx, updates, Update{Entity: e, FeedTimestamp: feedTime}); err != nil {
+			return err
+		}
+	}
+	for id := range p.entities {
+		if seen[id] {
+			continue
+		}
+		delete(p.entities, id)
+		e := &gtfsproto.FeedEntity{Id: proto.String(id), IsDeleted: proto.Bool(true)}
+		if err := send(ctx, updates, Update{Entity: e, Deleted: true, FeedTimestamp: feedTime}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stateOf uses the timestamp of the entity when it has one and a hash of
+// its content otherwise.
+func stateOf(e *gtfsproto.FeedEntity) (entityState, error) {
+	var ts uint64
+	switch {
+	case e.GetVehicle() != nil:
+		ts = e.GetVehicle().GetTimestamp()
+	case e.GetTripUpdate() != nil:
+		ts = e.GetTripUpdate().GetTimestamp()
+	}
+	if ts != 0 {
+		return entityState{timestamp: ts}, nil
+	}
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(e)
+	if err != nil {
+		return entityState{}, err
+	}
+	return entityState{hash: sha256.Sum256(b)}, nil
+}
+
+func send(ctx context.Context, updates chan<- Update, u Update) error {
+	select {
+	case updates <- u:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
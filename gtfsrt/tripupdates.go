@@ -0,0 +1,125 @@
+package gtfsrt
+
+import (
+	"context"
+	"time"
+
+	gtfsproto "github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+)
+
+// StopTimeEvent is the predicted arrival or departure at a stop. Delay
+// is nil when only Time is reported, and Time is zero when only Delay is.
+type StopTimeEvent struct {
+	Delay *time.Duration
+	Time  time.Time
+}
+
+type StopTimeUpdate struct {
+	StopSequence         int
+	StopID               string
+	Arrival              *StopTimeEvent
+	Departure            *StopTimeEvent
+	ScheduleRelationship string
+}
+
+// Skipped reports whether the vehicle won't stop.
+func (u *StopTimeUpdate) Skipped() bool {
+	return u.ScheduleRelationship == gtfsproto.TripUpdate_StopTimeUpdate_SKIPPED.String()
+}
+
+type TripUpdate struct {
+	EntityID  string
+	Trip      TripDescriptor
+	VehicleID string
+	// Delay is the trip level delay, nil when not reported.
+	Delay           *time.Duration
+	StopTimeUpdates []StopTimeUpdate
+	Timestamp       time.Time
+}
+
+// Cancelled reports whether the whole trip is cancelled.
+func (u *TripUpdate) Cancelled() bool {
+	return u.Trip.ScheduleRelationship == gtfsproto.TripDescriptor_CANCELED.String()
+}
+
+// Stop returns the update of the stop with id, if any.
+func (u *TripUpdate) Stop(stopID string) (*StopTimeUpdate, bool) {
+	for i := range u.StopTimeUpdates {
+		if u.StopTimeUpdates[i].StopID == stopID {
+			return &u.StopTimeUpdates[i], true
+		}
+	}
+	return nil, false
+}
+
+type TripUpdates struct {
+	Timestamp time.Time
+	Updates   []TripUpdate
+}
+
+// ByTripID indexes the updates by trip id.
+func (t *TripUpdates) ByTripID() map[string]*TripUpdate {
+	m := make(map[string]*TripUpdate, len(t.Updates))
+	for i := range t.Updates {
+		m[t.Updates[i].Trip.TripID] = &t.Updates[i]
+	}
+	return m
+}
+
+// TripUpdates fetches the current trip updates of operator.
+func (c *Client) TripUpdates(ctx context.Context, operator string) (*TripUpdates, error) {
+	msg, err := c.Feed(ctx, operator, FeedTripUpdates)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeTripUpdates(msg), nil
+}
+
+// DecodeTripUpdates converts the trip update entities of msg.
+func DecodeTripUpdates(msg *gtfsproto.FeedMessage) *TripUpdates {
+	tu := &TripUpdates{
+		Timestamp: unixTime(msg.GetHeader().GetTimestamp()),
+	}
+	for _, e := range msg.GetEntity() {
+		u := e.GetTripUpdate()
+		if u == nil || e.GetIsDeleted() {
+			continue
+		}
+		update := TripUpdate{
+			EntityID:  e.GetId(),
+			Trip:      tripDescriptor(u.GetTrip()),
+			VehicleID: u.GetVehicle().GetId(),
+			Timestamp: unixTime(u.GetTimestamp()),
+		}
+		if u.Delay != nil {
+			d := time.Duration(u.GetDelay()) * time.Second
+			update.Delay = &d
+		}
+		for _, stu := range u.GetStopTimeUpdate() {
+			update.StopTimeUpdates = append(update.StopTimeUpdates, StopTimeUpdate{
+				StopSequence:         int(stu.GetStopSequence()),
+				StopID:               stu.GetStopId(),
+				Arrival:              stopTimeEvent(stu.GetArrival()),
+				Departure:            stopTimeEvent(stu.GetDeparture()),
+				ScheduleRelationship: stu.GetScheduleRelationship().String(),
+			})
+		}
+		tu.Updates = append(tu.Updates, update)
+	}
+	return tu
+}
+
+func stopTimeEvent(e *gtfsproto.TripUpdate_StopTimeEvent) *StopTimeEvent {
+	if e == nil {
+		return nil
+	}
+	ev := &StopTimeEvent{}
+	if e.Delay != nil {
+		d := time.Duration(e.GetDelay()) * time.Second
+		ev.Delay = &d
+	}
+	if e.Time != nil {
+		ev.Time = time.Unix(e.GetTime(), 0)
+	}
+	return ev
+}
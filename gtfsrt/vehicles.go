@@ -0,0 +1,117 @@
+package gtfsrt
+
+import (
+	"context"
+	"time"
+
+	gtfsproto "github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+)
+
+// TripDescriptor identifies the trip an entity refers to. StartDate is
+// formatted 20060102 and StartTime may exceed 24:00.
+type TripDescriptor struct {
+	TripID               string
+	RouteID              string
+	DirectionID          int
+	StartDate            string
+	StartTime            string
+	ScheduleRelationship string
+}
+
+func tripDescriptor(t *gtfsproto.TripDescriptor) TripDescriptor {
+	if t == nil {
+		return TripDescriptor{}
+	}
+	return TripDescriptor{
+		TripID:               t.GetTripId(),
+		RouteID:              t.GetRouteId(),
+		DirectionID:          int(t.GetDirectionId()),
+		StartDate:            t.GetStartDate(),
+		StartTime:            t.GetStartTime(),
+		ScheduleRelationship: t.GetScheduleRelationship().String(),
+	}
+}
+
+// Occupancy levels as reported by the feeds.
+const (
+	OccupancyEmpty                   = "EMPTY"
+	OccupancyManySeatsAvailable      = "MANY_SEATS_AVAILABLE"
+	OccupancyFewSeatsAvailable       = "FEW_SEATS_AVAILABLE"
+	OccupancyStandingRoomOnly        = "STANDING_ROOM_ONLY"
+	OccupancyCrushedStandingRoomOnly = "CRUSHED_STANDING_ROOM_ONLY"
+	OccupancyFull                    = "FULL"
+	OccupancyNotAcceptingPassengers  = "NOT_ACCEPTING_PASSENGERS"
+)
+
+type VehiclePosition struct {
+	EntityID     string
+	Trip         TripDescriptor
+	VehicleID    string
+	VehicleLabel string
+	Lat          float64
+	Lng          float64
+	// Bearing in degrees clockwise from north and Speed in meters per
+	// second are nil when not reported.
+	Bearing *float32
+	Speed   *float32
+	// Occupancy is one of the Occupancy constants, empty if not reported.
+	Occupancy     string
+	CurrentStatus string
+	StopID        string
+	Timestamp     time.Time
+}
+
+type VehiclePositions struct {
+	Timestamp time.Time
+	Vehicles  []VehiclePosition
+}
+
+// VehiclePositions fetches the current vehicle positions of operator.
+func (c *Client) VehiclePositions(ctx context.Context, operator string) (*VehiclePositions, error) {
+	msg, err := c.Feed(ctx, operator, FeedVehiclePositions)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeVehiclePositions(msg), nil
+}
+
+// DecodeVehiclePositions converts the vehicle entities of msg.
+func DecodeVehiclePositions(msg *gtfsproto.FeedMessage) *VehiclePositions {
+	vp := &VehiclePositions{
+		Timestamp: unixTime(msg.GetHeader().GetTimestamp()),
+	}
+	for _, e := range msg.GetEntity() {
+		v := e.GetVehicle()
+		if v == nil || e.GetIsDeleted() {
+			continue
+		}
+		pos := v.GetPosition()
+		p := VehiclePosition{
+			EntityID:      e.GetId(),
+			Trip:          tripDescriptor(v.GetTrip()),
+			VehicleID:     v.GetVehicle().GetId(),
+			VehicleLabel:  v.GetVehicle().GetLabel(),
+			Lat:           float64(pos.GetLatitude()),
+			Lng:           float64(pos.GetLongitude()),
+			CurrentStatus: v.GetCurrentStatus().String(),
+			StopID:        v.GetStopId(),
+			Timestamp:     unixTime(v.GetTimestamp()),
+		}
+		if pos != nil {
+			p.Bearing = pos.Bearing
+			p.Speed = pos.Speed
+		}
+		if v.OccupancyStatus != nil {
+			p.Occupancy = v.GetOccupancyStatus().String()
+		}
+		vp.Vehicles = append(vp.Vehicles, p)
+	}
+	return vp
+}
+
+func unixTime(ts uint64) time.Time {
+	if ts == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(ts), 0)
+}
@@ -0,0 +1,27 @@
+package normalize
+
+import (
+	"strings"
+	"unicode"
+)
+
+var foldReplacer = strings.NewReplacer(
+	"å", "a", "ä", "a", "á", "a", "à", "a", "â", "a", "ã", "a",
+	"ö", "o", "ó", "o", "ò", "o", "ô", "o", "õ", "o", "ø", "o",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"ü", "u", "ú", "u", "ù", "u", "û", "u",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ç", "c", "ñ", "n", "ß", "ss", "æ", "ae",
+)
+
+// Fold lowercases s, strips the diacritics common in Swedish and other
+// European languages and replaces punctuation with spaces.
+func Fold(s string) string {
+	s = foldReplacer.Replace(strings.ToLower(s))
+	return strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) {
+			return ' '
+		}
+		return r
+	}, s)
+}
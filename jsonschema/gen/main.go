@@ -0,0 +1,78 @@
+// Command gen writes the OpenAPI components of the request and response
+// types of the SDK.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/nobina/go-trafiklab/jsonschema"
+	"github.com/nobina/go-trafiklab/resrobot"
+	"github.com/nobina/go-trafiklab/sl/deviations"
+	"github.com/nobina/go-trafiklab/sl/stops"
+	"github.com/nobina/go-trafiklab/sl/stopsnearby"
+	"github.com/nobina/go-trafiklab/sl/trafficstatus"
+	"github.com/nobina/go-trafiklab/sl/transport"
+	"github.com/nobina/go-trafiklab/sl/travelplanner"
+)
+
+var types = []any{
+	resrobot.TripRequest{},
+	resrobot.TripResponse{},
+	resrobot.BoardRequest{},
+	resrobot.DepartureBoard{},
+	resrobot.ArrivalBoard{},
+	resrobot.NearbyStopsRequest{},
+	resrobot.NameRequest{},
+	resrobot.LocationResponse{},
+	deviations.DeviationsRequest{},
+	deviations.DeviationsResponse{},
+	stops.StopsQueryRequest{},
+	stops.TypeaheadResponse{},
+	stopsnearby.StopsNearbyRequest{},
+	stopsnearby.NearbyResponse{},
+	trafficstatus.TrafficStatusResponse{},
+	trafficstatus.View{},
+	transport.DeparturesRequest{},
+	transport.DepartureResponse{},
+	travelplanner.JourneyDetailRequest{},
+	travelplanner.TripsRequest{},
+	travelplanner.TripsResp{},
+	travelplanner.TripResp{},
+	travelplanner.LegResp{},
+}
+
+type document struct {
+	OpenAPI    string   `json:"openapi"`
+	Info       info     `json:"info"`
+	Paths      struct{} `json:"paths"`
+	Components struct {
+		Schemas map[string]*jsonschema.Schema `json:"schemas"`
+	} `json:"components"`
+}
+
+type info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+func main() {
+	out := flag.String("o", "openapi.json", "output file")
+	flag.Parse()
+
+	doc := document{
+		OpenAPI: "3.1.0",
+		Info:    info{Title: "go-trafiklab", Version: "1"},
+	}
+	doc.Components.Schemas = jsonschema.Components(types...)
+
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, append(b, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,236 @@
+// Package jsonschema generates JSON Schemas from Go types, following the
+// rules of encoding/json, so the request and response types of the SDK
+// can be validated and documented by gateways wrapping it. Properties are
+// never required, as encoding/json doesn't require them. openapi.json
+// holds the generated components of all exported request and response
+// types.
+package jsonschema
+
+//go:generate go run ./gen -o openapi.json
+
+import (
+	_ "embed"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// OpenAPI is the generated OpenAPI document in openapi.json.
+//
+//go:embed openapi.json
+var OpenAPI []byte
+
+const (
+	// OpenAPIRefPrefix references OpenAPI components.
+	OpenAPIRefPrefix = "#/components/schemas/"
+	// DefsRefPrefix references the $defs of a JSON Schema document.
+	DefsRefPrefix = "#/$defs/"
+
+	Draft = "https://json-schema.org/draft/2020-12/schema"
+)
+
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// Generator collects the schemas of named struct types as definitions,
+// referenced with refPrefix.
+type Generator struct {
+	refPrefix string
+	defs      map[string]*Schema
+	names     map[reflect.Type]string
+}
+
+func NewGenerator(refPrefix string) *Generator {
+	return &Generator{
+		refPrefix: refPrefix,
+		defs:      map[string]*Schema{},
+		names:     map[reflect.Type]string{},
+	}
+}
+
+// Add returns the schema of the type of v, adding the struct types it uses
+// to the definitions.
+func (g *Generator) Add(v any) *Schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+// Definitions returns the definitions by name, e.g. "transport.Departure".
+func (g *Generator) Definitions() map[string]*Schema {
+	return g.defs
+}
+
+// For returns a standalone JSON Schema of the type of v.
+func For(v any) *Schema {
+	g := NewGenerator(DefsRefPrefix)
+	s := g.Add(v)
+	return &Schema{
+		Schema: Draft,
+		Ref:    s.Ref,
+		Type:   s.Type,
+		Format: s.Format,
+		Items:  s.Items,
+		Defs:   g.defs,
+	}
+}
+
+// Components returns the OpenAPI component schemas of the types of values.
+func Components(values ...any) map[string]*Schema {
+	g := NewGenerator(OpenAPIRefPrefix)
+	for _, v := range values {
+		g.Add(v)
+	}
+	return g.defs
+}
+
+func (g *Generator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return &Schema{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return &Schema{Ref: g.refPrefix + g.define(t)}
+	}
+	return &Schema{}
+}
+
+// define adds the schema of the named struct t once, returning its name.
+func (g *Generator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := typeName(t)
+	if _, taken := g.defs[name]; taken {
+		name = strings.NewReplacer("/", ".", "[", "_", "]", "_").Replace(t.PkgPath()) + "." + t.Name()
+	}
+	g.names[t] = name
+	// Reserve the name before recursing, for recursive types.
+	g.defs[name] = &Schema{}
+	*g.defs[name] = *g.object(t)
+	return name
+}
+
+func typeName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	if pkg == "" {
+		return t.Name()
+	}
+	return pkg + "." + t.Name()
+}
+
+func (g *Generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.fields(t, s, map[string]bool{})
+	return s
+}
+
+// fields adds the properties of the fields of t to s. Fields of embedded
+// structs are promoted unless set, outer fields taking precedence.
+func (g *Generator) fields(t reflect.Type, s *Schema, outer map[string]bool) {
+	var embedded []reflect.Type
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			embedded = append(embedded, ft)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if outer[name] {
+			continue
+		}
+		names[name] = true
+
+		prop := g.schema(f.Type)
+		if hasOption(opts, "string") && isScalar(ft) {
+			prop = &Schema{Type: "string"}
+		}
+		s.Properties[name] = prop
+	}
+
+	for name := range outer {
+		names[name] = true
+	}
+	for _, et := range embedded {
+		g.fields(et, s, names)
+	}
+}
+
+func hasOption(opts, opt string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+func isScalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+// Package koda downloads historical GTFS and GTFS-RT archives from
+// Trafiklab's KoDa API.
+package koda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/nobina/go-trafiklab/requests"
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+const DefaultBaseURL = "https://api.koda.trafiklab.se/KoDa/api/v2"
+
+// DefaultPollInterval is how often a preparing archive is checked when the
+// API doesn't send Retry-After.
+const DefaultPollInterval = 30 * time.Second
+
+// Feed is a GTFS-RT feed type, as named by KoDa.
+type Feed string
+
+const (
+	FeedTripUpdates      Feed = "TripUpdates"
+	FeedVehiclePositions Feed = "VehiclePositions"
+	FeedServiceAlerts    Feed = "ServiceAlerts"
+)
+
+type Config struct {
+	BaseURL       string
+	APIKey        string
+	AllowInsecure requests.AllowInsecure
+}
+
+func (cfg *Config) Valid() error {
+	if cfg.BaseURL == "" {
+		return fmt.Errorf("missing base url")
+	}
+	if cfg.APIKey == "" {
+		return fmt.Errorf("missing api key")
+	}
+	return requests.ValidateBaseURL(cfg.BaseURL, cfg.AllowInsecure)
+}
+
+type Client struct {
+	httpClient   *http.Client
+	baseURL      string
+	apiKey       string
+	pollInterval time.Duration
+	clock        timeutils.Clock
+}
+
+func NewClient(cfg *Config, client *http.Client, opts ...Option) *Client {
+	c := &Client{
+		httpClient:   client,
+		baseURL:      cfg.BaseURL,
+		apiKey:       cfg.APIKey,
+		pollInterval: DefaultPollInterval,
+		clock:        timeutils.SystemClock,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.httpClient = requests.WrapClient(c.httpClient, requests.UserAgent(""))
+
+	return c
+}
+
+type Option func(*Client)
+
+// WithPollInterval sets how often preparing archives are checked.
+func WithPollInterval(d time.Duration) Option {
+	return func(c *Client) {
+		c.pollInterval = d
+	}
+}
+
+// WithClock sets the clock that paces polling.
+func WithClock(clock timeutils.Clock) Option {
+	return func(c *Client) {
+		c.clock = clock
+	}
+}
+
+// GTFSStatic writes the static GTFS archive of operator, e.g. "sl", valid
+// on date to w.
+func (c *Client) GTFSStatic(ctx context.Context, operator string, date time.Time, w io.Writer) error {
+	q := url.Values{"date": {timeutils.FormatServiceDate(date)}}
+	return c.download(ctx, "/gtfs-static/"+operator, q, w)
+}
+
+// GTFSRealtime writes the archive of all feed messages of operator during
+// the given hour of date, 0-23 in Stockholm time, to w. A negative hour
+// fetches the whole day.
+func (c *Client) GTFSRealtime(ctx context.Context, operator string, feed Feed, date time.Time, hour int, w io.Writer) error {
+	q := url.Values{"date": {timeutils.FormatServiceDate(date)}}
+	if hour >= 0 {
+		q.Set("hour", strconv.Itoa(hour))
+	}
+	return c.download(ctx, "/gtfs-rt/"+operator+"/"+string(feed), q, w)
+}
+
+// Range calls fn for every day from from to to, both inclusive, e.g. to
+// download an archive per day with GTFSStatic or GTFSRealtime.
+func Range(from, to time.Time, fn func(date time.Time) error) error {
+	y, m, d := from.In(timeutils.EuropeStockholm()).Date()
+	for day := timeutils.Date(y, m, d); !day.After(to); day = day.AddDate(0, 0, 1) {
+		if err := fn(day); err != nil {
+			return fmt.Errorf("%s: %w", timeutils.FormatServiceDate(day), err)
+		}
+	}
+	return nil
+}
+
+// download requests path until the archive is ready and streams it to w.
+// KoDa answers 202 Accepted while an archive is being prepared, which can
+// take minutes for large operators.
+func (c *Client) download(ctx context.Context, path string, q url.Values, w io.Writer) error {
+	q.Set("key", c.apiKey)
+	for {
+		var wait time.Duration
+		err := requests.Download(ctx, c.httpClient, c.baseURL+path, q, w,
+			requests.OnResponse(func(res *http.Response) {
+				if res.StatusCode == http.StatusAccepted {
+					wait = c.retryAfter(res)
+				}
+			}))
+		var apiErr *requests.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusAccepted {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.clock.After(wait):
+		}
+	}
+}
+
+func (c *Client) retryAfter(res *http.Response) time.Duration {
+	if s, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	return c.pollInterval
+}
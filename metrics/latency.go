@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nobina/go-trafiklab/requests"
+)
+
+// DefaultLatencyBuckets are the upper bounds of the latency histogram
+// buckets, suited to APIs answering in tens of milliseconds to seconds.
+var DefaultLatencyBuckets = []time.Duration{
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Latency is the latency histogram of one endpoint. Counts[i] is the
+// number of requests up to Buckets[i]; the last count holds the requests
+// slower than every bucket.
+type Latency struct {
+	Buckets []time.Duration
+	Counts  []uint64
+	Count   uint64
+	Sum     time.Duration
+	Max     time.Duration
+}
+
+func (l Latency) Mean() time.Duration {
+	if l.Count == 0 {
+		return 0
+	}
+	return l.Sum / time.Duration(l.Count)
+}
+
+// Quantile returns the upper bound of the bucket holding the q quantile,
+// e.g. 0.95, or Max if it is beyond the last bucket.
+func (l Latency) Quantile(q float64) time.Duration {
+	if l.Count == 0 {
+		return 0
+	}
+	rank := uint64(q * float64(l.Count))
+	seen := uint64(0)
+	for i, c := range l.Counts[:len(l.Buckets)] {
+		seen += c
+		if seen > rank {
+			return l.Buckets[i]
+		}
+	}
+	return l.Max
+}
+
+// LatencyTracker is a Recorder keeping a latency histogram per endpoint in
+// memory, for services without a metrics system. It is safe for
+// concurrent use.
+type LatencyTracker struct {
+	buckets []time.Duration
+
+	mu        sync.Mutex
+	endpoints map[string]*Latency
+}
+
+// NewLatencyTracker uses DefaultLatencyBuckets if no buckets are given.
+func NewLatencyTracker(buckets ...time.Duration) *LatencyTracker {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]time.Duration(nil), buckets...)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+	return &LatencyTracker{
+		buckets:   buckets,
+		endpoints: map[string]*Latency{},
+	}
+}
+
+func (t *LatencyTracker) ObserveRequest(endpoint string, statusCode int, duration time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l, ok := t.endpoints[endpoint]
+	if !ok {
+		l = &Latency{Buckets: t.buckets, Counts: make([]uint64, len(t.buckets)+1)}
+		t.endpoints[endpoint] = l
+	}
+	i := sort.Search(len(t.buckets), func(i int) bool { return duration <= t.buckets[i] })
+	l.Counts[i]++
+	l.Count++
+	l.Sum += duration
+	l.Max = max(l.Max, duration)
+}
+
+// Latencies returns a copy of the histograms by endpoint.
+func (t *LatencyTracker) Latencies() map[string]Latency {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	latencies := make(map[string]Latency, len(t.endpoints))
+	for endpoint, l := range t.endpoints {
+		c := *l
+		c.Counts = append([]uint64(nil), l.Counts...)
+		latencies[endpoint] = c
+	}
+	return latencies
+}
+
+// SlowRequest describes a request that took longer than the threshold of
+// SlowRequests.
+type SlowRequest struct {
+	Endpoint   string
+	Duration   time.Duration
+	StatusCode int
+	// CorrelationID is the id the API assigned to the request, if any, to
+	// quote when reporting it.
+	CorrelationID string
+	Err           error
+}
+
+// SlowRequests calls fn for every request that takes longer than
+// threshold, including those that fail.
+func SlowRequests(threshold time.Duration, fn func(SlowRequest)) requests.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return requests.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			res, err := next.RoundTrip(req)
+			if d := time.Since(start); d > threshold {
+				slow := SlowRequest{Endpoint: Endpoint(req), Duration: d, Err: err}
+				if res != nil {
+					slow.StatusCode = res.StatusCode
+					slow.CorrelationID = requests.CorrelationID(res.Header)
+				}
+				fn(slow)
+			}
+			return res, err
+		})
+	}
+}
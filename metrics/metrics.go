@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nobina/go-trafiklab/requests"
+)
+
+// Recorder receives one observation per request made by a client. err is
+// set if no response was received, in which case statusCode is 0.
+type Recorder interface {
+	ObserveRequest(endpoint string, statusCode int, duration time.Duration, err error)
+}
+
+// Middleware reports every request to r.
+func Middleware(r Recorder) requests.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return requests.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			res, err := next.RoundTrip(req)
+			statusCode := 0
+			if res != nil {
+				statusCode = res.StatusCode
+			}
+			r.ObserveRequest(Endpoint(req), statusCode, time.Since(start), err)
+			return res, err
+		})
+	}
+}
+
+// Endpoint returns host and path of req with numeric path segments, such
+// as site ids, replaced by ":id" to keep the number of endpoints bounded.
+func Endpoint(req *http.Request) string {
+	segments := strings.Split(req.URL.Path, "/")
+	for i, s := range segments {
+		if s != "" && strings.Trim(s, "0123456789") == "" {
+			segments[i] = ":id"
+		}
+	}
+	return req.URL.Host + strings.Join(segments, "/")
+}
@@ -0,0 +1,57 @@
+package prometheus
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Recorder implements metrics.Recorder with Prometheus collectors.
+type Recorder struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// New creates a Recorder and registers its collectors with reg.
+func New(reg prometheus.Registerer, namespace string) (*Recorder, error) {
+	r := &Recorder{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "trafiklab",
+			Name:      "requests_total",
+			Help:      "Requests made to the Trafiklab APIs by endpoint and status code.",
+		}, []string{"endpoint", "status"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "trafiklab",
+			Name:      "request_errors_total",
+			Help:      "Requests to the Trafiklab APIs that failed without a response.",
+		}, []string{"endpoint"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "trafiklab",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests to the Trafiklab APIs.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint"}),
+	}
+
+	for _, c := range []prometheus.Collector{r.requests, r.errors, r.latency} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *Recorder) ObserveRequest(endpoint string, statusCode int, duration time.Duration, err error) {
+	status := strconv.Itoa(statusCode)
+	if err != nil {
+		status = "error"
+		r.errors.WithLabelValues(endpoint).Inc()
+	}
+	r.requests.WithLabelValues(endpoint, status).Inc()
+	r.latency.WithLabelValues(endpoint).Observe(duration.Seconds())
+}
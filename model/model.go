@@ -0,0 +1,110 @@
+// Package model is a source independent model of stops, lines, departures
+// and journeys, so applications using both SL and ResRobot don't need a
+// set of structs per API. Alerts are modelled by package alerts.
+package model
+
+import (
+	"time"
+
+	"github.com/nobina/go-trafiklab/alerts"
+	"github.com/nobina/go-trafiklab/slidentifiers"
+)
+
+type Alert = alerts.Alert
+
+type Stop struct {
+	// Ref is zero for addresses and coordinates.
+	Ref  slidentifiers.StopRef
+	Name string
+	Lat  float64
+	Lon  float64
+	// Platform is the track or stop point designation, empty if unknown.
+	Platform string
+}
+
+type Line struct {
+	Designation string
+	Name        string
+	// Mode is one of the transport.TransportMode constants, empty if
+	// unknown.
+	Mode     string
+	Operator string
+}
+
+type Departure struct {
+	Stop      Stop
+	Line      Line
+	Direction string
+	Scheduled time.Time
+	// Expected equals Scheduled without realtime data.
+	Expected  time.Time
+	Cancelled bool
+}
+
+// Delay is the difference between the expected and scheduled time.
+func (d Departure) Delay() time.Duration {
+	return d.Expected.Sub(d.Scheduled)
+}
+
+type Leg struct {
+	// Line is nil for walks and transfers.
+	Line        *Line
+	Direction   string
+	Origin      Stop
+	Destination Stop
+	// Expected times equal the scheduled ones without realtime data.
+	ScheduledDeparture time.Time
+	ExpectedDeparture  time.Time
+	ScheduledArrival   time.Time
+	ExpectedArrival    time.Time
+	Cancelled          bool
+}
+
+// Walk reports whether the leg is on foot.
+func (l Leg) Walk() bool {
+	return l.Line == nil
+}
+
+type Journey struct {
+	Legs []Leg
+}
+
+// Times returns the expected departure of the first leg and arrival of the
+// last, zero if the journey has no legs.
+func (j Journey) Times() (dep, arr time.Time) {
+	if len(j.Legs) == 0 {
+		return time.Time{}, time.Time{}
+	}
+	return j.Legs[0].ExpectedDeparture, j.Legs[len(j.Legs)-1].ExpectedArrival
+}
+
+// Changes returns the number of changes between vehicles.
+func (j Journey) Changes() int {
+	rides := 0
+	for _, leg := range j.Legs {
+		if !leg.Walk() {
+			rides++
+		}
+	}
+	return max(rides-1, 0)
+}
+
+// Cancelled reports whether any leg is cancelled.
+func (j Journey) Cancelled() bool {
+	for _, leg := range j.Legs {
+		if leg.Cancelled {
+			return true
+		}
+	}
+	return false
+}
+
+// stopRef detects the kind of id, returning a zero ref for ids of unknown
+// kind such as addresses.
+func stopRef(id string) slidentifiers.StopRef {
+	ref, err := slidentifiers.NewStopRef(id)
+	if err != nil {
+		return slidentifiers.StopRef{}
+	}
+	return ref
+}
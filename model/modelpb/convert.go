@@ -0,0 +1,191 @@
+// Package modelpb holds the protobuf messages of the model package, for
+// passing stops, departures, journeys and alerts between services.
+package modelpb
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative model/modelpb/model.proto
+
+import (
+	"time"
+
+	"github.com/nobina/go-trafiklab/alerts"
+	"github.com/nobina/go-trafiklab/model"
+	"github.com/nobina/go-trafiklab/slidentifiers"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// timestamp returns nil for the zero time.
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// asTime returns the zero time for nil, as opposed to AsTime.
+func asTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func FromStop(s model.Stop) *Stop {
+	pb := &Stop{
+		Name:     s.Name,
+		Lat:      s.Lat,
+		Lon:      s.Lon,
+		Platform: s.Platform,
+	}
+	if s.Ref.ID != "" {
+		pb.Ref = s.Ref.String()
+	}
+	return pb
+}
+
+// Model converts s back. A ref that can't be parsed is left zero.
+func (s *Stop) Model() model.Stop {
+	ref, _ := slidentifiers.ParseStopRef(s.GetRef())
+	return model.Stop{
+		Ref:      ref,
+		Name:     s.GetName(),
+		Lat:      s.GetLat(),
+		Lon:      s.GetLon(),
+		Platform: s.GetPlatform(),
+	}
+}
+
+func FromLine(l model.Line) *Line {
+	return &Line{
+		Designation: l.Designation,
+		Name:        l.Name,
+		Mode:        l.Mode,
+		Operator:    l.Operator,
+	}
+}
+
+func (l *Line) Model() model.Line {
+	return model.Line{
+		Designation: l.GetDesignation(),
+		Name:        l.GetName(),
+		Mode:        l.GetMode(),
+		Operator:    l.GetOperator(),
+	}
+}
+
+func FromDeparture(d model.Departure) *Departure {
+	return &Departure{
+		Stop:      FromStop(d.Stop),
+		Line:      FromLine(d.Line),
+		Direction: d.Direction,
+		Scheduled: timestamp(d.Scheduled),
+		Expected:  timestamp(d.Expected),
+		Cancelled: d.Cancelled,
+	}
+}
+
+func (d *Departure) Model() model.Departure {
+	return model.Departure{
+		Stop:      d.GetStop().Model(),
+		Line:      d.GetLine().Model(),
+		Direction: d.GetDirection(),
+		Scheduled: asTime(d.GetScheduled()),
+		Expected:  asTime(d.GetExpected()),
+		Cancelled: d.GetCancelled(),
+	}
+}
+
+func FromLeg(l model.Leg) *Leg {
+	pb := &Leg{
+		Direction:          l.Direction,
+		Origin:             FromStop(l.Origin),
+		Destination:        FromStop(l.Destination),
+		ScheduledDeparture: timestamp(l.ScheduledDeparture),
+		ExpectedDeparture:  timestamp(l.ExpectedDeparture),
+		ScheduledArrival:   timestamp(l.ScheduledArrival),
+		ExpectedArrival:    timestamp(l.ExpectedArrival),
+		Cancelled:          l.Cancelled,
+	}
+	if l.Line != nil {
+		pb.Line = FromLine(*l.Line)
+	}
+	return pb
+}
+
+func (l *Leg) Model() model.Leg {
+	leg := model.Leg{
+		Direction:          l.GetDirection(),
+		Origin:             l.GetOrigin().Model(),
+		Destination:        l.GetDestination().Model(),
+		ScheduledDeparture: asTime(l.GetScheduledDeparture()),
+		ExpectedDeparture:  asTime(l.GetExpectedDeparture()),
+		ScheduledArrival:   asTime(l.GetScheduledArrival()),
+		ExpectedArrival:    asTime(l.GetExpectedArrival()),
+		Cancelled:          l.GetCancelled(),
+	}
+	if l.GetLine() != nil {
+		line := l.GetLine().Model()
+		leg.Line = &line
+	}
+	return leg
+}
+
+func FromJourney(j model.Journey) *Journey {
+	pb := &Journey{Legs: make([]*Leg, 0, len(j.Legs))}
+	for _, l := range j.Legs {
+		pb.Legs = append(pb.Legs, FromLeg(l))
+	}
+	return pb
+}
+
+func (j *Journey) Model() model.Journey {
+	legs := j.GetLegs()
+	journey := model.Journey{Legs: make([]model.Leg, 0, len(legs))}
+	for _, l := range legs {
+		journey.Legs = append(journey.Legs, l.Model())
+	}
+	return journey
+}
+
+func FromAlert(a *model.Alert) *Alert {
+	pb := &Alert{
+		Id:       a.ID,
+		Source:   string(a.Source),
+		Lines:    a.Lines,
+		Stops:    a.Stops,
+		Cause:    a.Cause,
+		Effect:   a.Effect,
+		Severity: Severity(a.Severity),
+	}
+	if len(a.Texts) > 0 {
+		pb.Texts = make(map[string]*Text, len(a.Texts))
+		for lang, t := range a.Texts {
+			pb.Texts[lang] = &Text{Header: t.Header, Description: t.Description, Url: t.URL}
+		}
+	}
+	for _, p := range a.Periods {
+		pb.Periods = append(pb.Periods, &Period{Start: timestamp(p.Start), End: timestamp(p.End)})
+	}
+	return pb
+}
+
+func (a *Alert) Model() model.Alert {
+	alert := model.Alert{
+		ID:       a.GetId(),
+		Source:   alerts.Source(a.GetSource()),
+		Lines:    a.GetLines(),
+		Stops:    a.GetStops(),
+		Cause:    a.GetCause(),
+		Effect:   a.GetEffect(),
+		Severity: alerts.Severity(a.GetSeverity()),
+	}
+	if len(a.GetTexts()) > 0 {
+		alert.Texts = make(map[string]alerts.Text, len(a.GetTexts()))
+		for lang, t := range a.GetTexts() {
+			alert.Texts[lang] = alerts.Text{Header: t.GetHeader(), Description: t.GetDescription(), URL: t.GetUrl()}
+		}
+	}
+	for _, p := range a.GetPeriods() {
+		alert.Periods = append(alert.Periods, alerts.Period{Start: asTime(p.GetStart()), End: asTime(p.GetEnd())})
+	}
+	return alert
+}
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nobina/go-trafiklab/resrobot"
+	"github.com/nobina/go-trafiklab/sl/transport"
+)
+
+// FromResRobotStop converts a stop of a ResRobot location search.
+func FromResRobotStop(s *resrobot.StopLocation) Stop {
+	return Stop{
+		Ref:  s.Ref(),
+		Name: s.Name,
+		Lat:  s.Lat,
+		Lon:  s.Lon,
+	}
+}
+
+// FromResRobotDeparture converts an entry of a ResRobot departure board.
+func FromResRobotDeparture(e *resrobot.BoardEntry) (Departure, error) {
+	scheduled, err := e.ScheduledTime()
+	if err != nil {
+		return Departure{}, err
+	}
+	expected, err := e.ExpectedTime()
+	if err != nil {
+		return Departure{}, err
+	}
+	platform := e.RtTrack
+	if platform == "" {
+		platform = e.Track
+	}
+	return Departure{
+		Stop: Stop{
+			Ref:      stopRef(e.StopExtID),
+			Name:     e.Stop,
+			Platform: platform,
+		},
+		Line:      fromResRobotProduct(e.Product),
+		Direction: e.Direction,
+		Scheduled: scheduled,
+		Expected:  expected,
+		Cancelled: e.Cancelled,
+	}, nil
+}
+
+// FromResRobotTrip converts a ResRobot trip.
+func FromResRobotTrip(t *resrobot.Trip) (Journey, error) {
+	legs := t.Legs()
+	j := Journey{Legs: make([]Leg, 0, len(legs))}
+	for i := range legs {
+		leg, err := fromResRobotLeg(&legs[i])
+		if err != nil {
+			return Journey{}, fmt.Errorf("failed to convert leg %d: %w", i, err)
+		}
+		j.Legs = append(j.Legs, leg)
+	}
+	return j, nil
+}
+
+func fromResRobotLeg(l *resrobot.Leg) (Leg, error) {
+	leg := Leg{
+		Direction:   l.Direction,
+		Origin:      fromResRobotTripStop(l.Origin),
+		Destination: fromResRobotTripStop(l.Destination),
+		Cancelled:   l.Cancelled,
+	}
+	var err error
+	leg.ScheduledDeparture, leg.ExpectedDeparture, err = l.Origin.Times()
+	if err != nil {
+		return Leg{}, fmt.Errorf("failed to parse departure: %w", err)
+	}
+	leg.ScheduledArrival, leg.ExpectedArrival, err = l.Destination.Times()
+	if err != nil {
+		return Leg{}, fmt.Errorf("failed to parse arrival: %w", err)
+	}
+	if l.Type == resrobot.LegJourney && len(l.Products) > 0 {
+		line := fromResRobotProduct(l.Products[0])
+		leg.Line = &line
+	}
+	return leg, nil
+}
+
+func fromResRobotTripStop(s resrobot.Stop) Stop {
+	platform := s.RtTrack
+	if platform == "" {
+		platform = s.Track
+	}
+	return Stop{
+		Ref:      stopRef(s.ExtID),
+		Name:     s.Name,
+		Lat:      s.Lat,
+		Lon:      s.Lon,
+		Platform: platform,
+	}
+}
+
+func fromResRobotProduct(p resrobot.Product) Line {
+	designation := p.DisplayNumber
+	if designation == "" {
+		designation = p.Line
+	}
+	return Line{
+		Designation: designation,
+		Name:        p.Name,
+		Mode:        resRobotMode(p.CatCode),
+		Operator:    p.Operator,
+	}
+}
+
+// resRobotMode maps a category code, the bit of the product in the
+// products mask, to a transport mode.
+func resRobotMode(catCode string) string {
+	n, err := strconv.Atoi(catCode)
+	if err != nil || n < 0 || n > 30 {
+		return ""
+	}
+	switch resrobot.ProductRef(1 << n) {
+	case resrobot.ProductHighSpeedTrain, resrobot.ProductRegionalTrain, resrobot.ProductLocalTrain:
+		return transport.TransportModeTrain
+	case resrobot.ProductExpressBus, resrobot.ProductBus:
+		return transport.TransportModeBus
+	case resrobot.ProductMetro:
+		return transport.TransportModeMetro
+	case resrobot.ProductTram:
+		return transport.TransportModeTram
+	case resrobot.ProductFerry:
+		return transport.TransportModeFerry
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nobina/go-trafiklab/sl/stopsnearby"
+	"github.com/nobina/go-trafiklab/sl/transport"
+	"github.com/nobina/go-trafiklab/sl/travelplanner"
+	"github.com/nobina/go-trafiklab/slidentifiers"
+)
+
+// FromSLDeparture converts a departure of SL Transport. The stop is the
+// stop area, with the stop point designation as platform. Departures don't
+// carry the site they were requested for, and the stop area id is not a
+// site id, so the stop ref is built from siteID and left zero if it is 0.
+func FromSLDeparture(d *transport.Departure, siteID int) (Departure, error) {
+	scheduled, err := d.ScheduledTime()
+	if err != nil {
+		return Departure{}, fmt.Errorf("failed to parse scheduled time: %w", err)
+	}
+	expected, err := d.ExpectedTime()
+	if err != nil {
+		return Departure{}, fmt.Errorf("failed to parse expected time: %w", err)
+	}
+	dep := Departure{
+		Stop: Stop{
+			Name:     d.StopArea.Name,
+			Platform: d.StopPoint.Designation,
+		},
+		Line: Line{
+			Designation: d.Line.Designation,
+			Name:        d.Line.GroupOfLines,
+			Mode:        d.Line.TransportMode,
+		},
+		Direction: d.Destination,
+		Scheduled: scheduled,
+		Expected:  expected,
+		Cancelled: d.State == "CANCELLED" || d.Journey.State == "CANCELLED",
+	}
+	if siteID != 0 {
+		dep.Stop.Ref = slidentifiers.StopRef{Kind: slidentifiers.KindSiteID, ID: strconv.Itoa(siteID)}
+	}
+	return dep, nil
+}
+
+// FromSLNearbyStop converts a stop of the XML nearby stops API, using the
+// converted GID when set.
+func FromSLNearbyStop(s stopsnearby.StopLocation) Stop {
+	id := s.GID
+	if id == "" {
+		id = s.ExtID
+	}
+	return Stop{
+		Ref:  stopRef(id),
+		Name: s.Name,
+		Lat:  s.Lat,
+		Lon:  s.Lon,
+	}
+}
+
+// FromTravelPlannerTrip converts a trip of the XML travel planner.
+func FromTravelPlannerTrip(t *travelplanner.Trip) (Journey, error) {
+	j := Journey{Legs: make([]Leg, 0, len(t.Legs))}
+	for i := range t.Legs {
+		leg, err := fromTravelPlannerLeg(&t.Legs[i])
+		if err != nil {
+			return Journey{}, fmt.Errorf("failed to convert leg %d: %w", i, err)
+		}
+		j.Legs = append(j.Legs, leg)
+	}
+	return j, nil
+}
+
+func fromTravelPlannerLeg(l *travelplanner.Leg) (Leg, error) {
+	leg := Leg{
+		Direction:   l.Direction,
+		Origin:      fromTravelPlannerLocation(l.Origin),
+		Destination: fromTravelPlannerLocation(l.Destination),
+		Cancelled:   l.Cancelled,
+	}
+	var err error
+	leg.ScheduledDeparture, leg.ExpectedDeparture, err = l.Origin.ParseTime()
+	if err != nil {
+		return Leg{}, fmt.Errorf("failed to parse departure: %w", err)
+	}
+	leg.ScheduledArrival, leg.ExpectedArrival, err = l.Destination.ParseTime()
+	if err != nil {
+		return Leg{}, fmt.Errorf("failed to parse arrival: %w", err)
+	}
+	if l.Type != "JNY" {
+		return leg, nil
+	}
+
+	line := &Line{Name: l.Name}
+	if l.Product != nil {
+		line.Designation = l.Product.Line
+		line.Mode = travelPlannerMode(l.Product.CategoryCode)
+		line.Operator = l.Product.Operator
+	}
+	if line.Designation == "" && l.Number != 0 {
+		line.Designation = strconv.Itoa(l.Number)
+	}
+	leg.Line = line
+	return leg, nil
+}
+
+func fromTravelPlannerLocation(l travelplanner.Location) Stop {
+	return Stop{
+		Ref:      stopRef(l.ExtID),
+		Name:     l.Name,
+		Lat:      l.Lat,
+		Lon:      l.Lon,
+		Platform: l.Track,
+	}
+}
+
+// travelPlannerMode maps a category code, the bit of the product in the
+// products mask, to a transport mode.
+func travelPlannerMode(catCode int) string {
+	if catCode < 0 || catCode > 30 {
+		return ""
+	}
+	product := travelplanner.ProductRef(1 << catCode)
+	switch {
+	case product == travelplanner.ProductRefTrain, product == travelplanner.ProductRefCommute:
+		return transport.TransportModeTrain
+	case product == travelplanner.ProductRefMetro:
+		return transport.TransportModeMetro
+	case product == travelplanner.ProductRefTram:
+		return transport.TransportModeTram
+	case product == travelplanner.ProductRefBus:
+		return transport.TransportModeBus
+	case product&travelplanner.ProductRefBoat != 0:
+		return transport.TransportModeShip
+	}
+	return ""
+}
@@ -0,0 +1,196 @@
+// Package nearby answers "what's leaving near me": it finds the sites
+// nearest a coordinate and merges their departures into one board.
+package nearby
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/nobina/go-trafiklab/geo"
+	"github.com/nobina/go-trafiklab/sl/stopindex"
+	"github.com/nobina/go-trafiklab/sl/stopsnearby"
+	"github.com/nobina/go-trafiklab/sl/transport"
+	"github.com/nobina/go-trafiklab/slidentifiers"
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+// Defaults for the number of sites and the search radius in meters.
+const (
+	DefaultSites  = 3
+	DefaultRadius = 1000
+)
+
+// Site is a site near the coordinate. ID is the SL site id used for
+// departures.
+type Site struct {
+	ID       string
+	Name     string
+	Distance float64
+}
+
+// SiteFinder finds at most max sites within radius meters of a coordinate,
+// nearest first.
+type SiteFinder interface {
+	NearestSites(ctx context.Context, lat, lng, radius float64, max int) ([]Site, error)
+}
+
+type SiteFinderFunc func(ctx context.Context, lat, lng, radius float64, max int) ([]Site, error)
+
+func (f SiteFinderFunc) NearestSites(ctx context.Context, lat, lng, radius float64, max int) ([]Site, error) {
+	return f(ctx, lat, lng, radius, max)
+}
+
+// IndexFinder finds sites in an offline stop index, without calling an API.
+func IndexFinder(idx *stopindex.Index) SiteFinder {
+	return SiteFinderFunc(func(ctx context.Context, lat, lng, radius float64, max int) ([]Site, error) {
+		sites := []Site{}
+		for _, s := range idx.Nearby(lat, lng, radius, max) {
+			sites = append(sites, Site{ID: strconv.Itoa(s.Site.ID), Name: s.Site.Name, Distance: s.Distance})
+		}
+		return sites, nil
+	})
+}
+
+// StopsNearbyFinder finds sites with the nearby stops API. Stop points are
+// deduped per site, and stops whose ids can't be converted to site ids are
+// skipped.
+func StopsNearbyFinder(client *stopsnearby.Client) SiteFinder {
+	return SiteFinderFunc(func(ctx context.Context, lat, lng, radius float64, max int) ([]Site, error) {
+		resp, err := client.Nearby(ctx, &stopsnearby.StopsNearbyRequest{
+			OriginCoordLat:  strconv.FormatFloat(lat, 'f', -1, 64),
+			OriginCoordLong: strconv.FormatFloat(lng, 'f', -1, 64),
+			Radius:          strconv.Itoa(int(radius)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find nearby stops: %w", err)
+		}
+		sites := []Site{}
+		for _, s := range stopsnearby.Nearest(resp.Stops(), 0) {
+			extID := s.MainMastExtID
+			if extID == "" {
+				extID = s.ExtID
+			}
+			id, err := slidentifiers.ConvertHAFASToSiteID(extID)
+			if err != nil {
+				continue
+			}
+			sites = append(sites, Site{ID: id, Name: s.Name, Distance: float64(s.Distance)})
+			if max > 0 && len(sites) == max {
+				break
+			}
+		}
+		return sites, nil
+	})
+}
+
+// Departure is a departure on the board with the site it leaves from.
+type Departure struct {
+	transport.BoardDeparture
+	Site Site
+}
+
+// Board is the merged board of the nearest sites, ordered by expected
+// time.
+type Board struct {
+	Sites      []Site
+	Departures []Departure
+}
+
+// Finder builds boards for coordinates.
+type Finder struct {
+	sites     SiteFinder
+	transport *transport.Client
+	max       int
+	radius    float64
+	forecast  int
+	filter    transport.BoardFilter
+	clock     timeutils.Clock
+}
+
+type Option func(*Finder)
+
+// WithMaxSites overrides DefaultSites.
+func WithMaxSites(n int) Option {
+	return func(f *Finder) {
+		f.max = n
+	}
+}
+
+// WithRadius overrides DefaultRadius.
+func WithRadius(meters float64) Option {
+	return func(f *Finder) {
+		f.radius = meters
+	}
+}
+
+// WithForecast sets the time window in minutes requested for each site.
+func WithForecast(minutes int) Option {
+	return func(f *Finder) {
+		f.forecast = minutes
+	}
+}
+
+// WithFilter only keeps departures matching filter.
+func WithFilter(filter transport.BoardFilter) Option {
+	return func(f *Finder) {
+		f.filter = filter
+	}
+}
+
+// WithClock sets the clock used to drop departures that have left.
+func WithClock(clock timeutils.Clock) Option {
+	return func(f *Finder) {
+		f.clock = clock
+	}
+}
+
+func New(sites SiteFinder, client *transport.Client, opts ...Option) *Finder {
+	f := &Finder{
+		sites:     sites,
+		transport: client,
+		max:       DefaultSites,
+		radius:    DefaultRadius,
+		clock:     timeutils.SystemClock,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Departures returns the board of the sites nearest lat/lng. The sites are
+// fetched concurrently; if some fail, the board of the others is returned
+// together with the error.
+func (f *Finder) Departures(ctx context.Context, lat, lng float64) (*Board, error) {
+	if err := geo.ValidateCoordinate(lat, lng, geo.BoundsSweden); err != nil {
+		return nil, err
+	}
+	sites, err := f.sites.NearestSites(ctx, lat, lng, f.radius, f.max)
+	if err != nil {
+		return nil, err
+	}
+	board := &Board{Sites: sites, Departures: []Departure{}}
+	if len(sites) == 0 {
+		return board, nil
+	}
+
+	byID := map[string]Site{}
+	ids := make([]string, 0, len(sites))
+	for _, s := range sites {
+		byID[s.ID] = s
+		ids = append(ids, s.ID)
+	}
+	agg := transport.NewAggregator(f.transport, ids, 0,
+		transport.WithBoardFilter(f.filter),
+		transport.WithForecast(f.forecast),
+		transport.WithAggregatorClock(f.clock))
+	_, err = agg.Refresh(ctx)
+	for _, d := range agg.Snapshot() {
+		board.Departures = append(board.Departures, Departure{BoardDeparture: d, Site: byID[d.SiteID]})
+	}
+	if err != nil {
+		return board, fmt.Errorf("failed to fetch departures: %w", err)
+	}
+	return board, nil
+}
@@ -0,0 +1,86 @@
+package netex
+
+import (
+	"strconv"
+
+	"github.com/nobina/go-trafiklab/sl/stopindex"
+	"github.com/nobina/go-trafiklab/slidentifiers"
+)
+
+// Dataset holds all stop places and lines of a parsed dataset.
+type Dataset struct {
+	StopPlaces []*StopPlace
+	Lines      []*Line
+}
+
+func (d *Dataset) handler() Handler {
+	return Handler{
+		StopPlace: func(sp *StopPlace) error {
+			d.StopPlaces = append(d.StopPlaces, sp)
+			return nil
+		},
+		Line: func(l *Line) error {
+			d.Lines = append(d.Lines, l)
+			return nil
+		},
+	}
+}
+
+// AddToMapping marks the local ids of all stop places and quays as
+// published ids.
+func (d *Dataset) AddToMapping(m *slidentifiers.MappingTable) {
+	for _, sp := range d.StopPlaces {
+		m.AddPublished(LocalID(sp.ID))
+		for _, q := range sp.Quays {
+			m.AddPublished(LocalID(q.ID))
+		}
+	}
+}
+
+// AddToHierarchy registers every quay as a child of its stop place, and
+// every stop place with a parent site as a child of that site.
+func (d *Dataset) AddToHierarchy(h *slidentifiers.Hierarchy) {
+	for _, sp := range d.StopPlaces {
+		id := LocalID(sp.ID)
+		for _, q := range sp.Quays {
+			h.Add(LocalID(q.ID), id)
+		}
+		if sp.ParentSiteRef.Ref != "" {
+			h.Add(id, LocalID(sp.ParentSiteRef.Ref))
+		}
+	}
+}
+
+// Sites returns the stop places with SL site GIDs as stop index sites.
+// Other stop places are skipped.
+func (d *Dataset) Sites() []stopindex.Site {
+	var sites []stopindex.Site
+	for _, sp := range d.StopPlaces {
+		gid := LocalID(sp.ID)
+		siteID, entity, err := slidentifiers.ConvertEFAToSiteID(gid)
+		if err != nil || entity != slidentifiers.EntitySite {
+			continue
+		}
+		id, err := strconv.Atoi(siteID)
+		if err != nil {
+			continue
+		}
+		g, err := strconv.ParseInt(gid, 10, 64)
+		if err != nil {
+			continue
+		}
+		var aliases []string
+		if sp.ShortName != "" {
+			aliases = append(aliases, sp.ShortName)
+		}
+		sites = append(sites, stopindex.Site{
+			ID:      id,
+			GID:     g,
+			Name:    sp.Name,
+			Aliases: aliases,
+			Lat:     sp.Location.Lat,
+			Lon:     sp.Location.Lon,
+		})
+	}
+	return sites
+}
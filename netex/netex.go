@@ -0,0 +1,80 @@
+// Package netex downloads and parses the NeTEx Regional dataset published
+// on Trafiklab, one archive of NeTEx XML files per operator.
+package netex
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/nobina/go-trafiklab/requests"
+)
+
+const DefaultBaseURL = "https://opendata.samtrafiken.se"
+
+type Config struct {
+	BaseURL       string
+	APIKey        string
+	AllowInsecure requests.AllowInsecure
+}
+
+func (cfg *Config) Valid() error {
+	if cfg.BaseURL == "" {
+		return fmt.Errorf("missing base url")
+	}
+	if cfg.APIKey == "" {
+		return fmt.Errorf("missing api key")
+	}
+	return requests.ValidateBaseURL(cfg.BaseURL, cfg.AllowInsecure)
+}
+
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+}
+
+func NewClient(cfg *Config, client *http.Client, opts ...Option) *Client {
+	c := &Client{
+		httpClient: client,
+		baseURL:    cfg.BaseURL,
+		apiKey:     cfg.APIKey,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.httpClient = requests.WrapClient(c.httpClient, requests.UserAgent(""))
+
+	return c
+}
+
+type Option func(*Client)
+
+// DownloadRegional writes the NeTEx Regional zip of operator, e.g. "sl",
+// to w.
+func (c *Client) DownloadRegional(ctx context.Context, operator string, w io.Writer) error {
+	path := "/netex/" + operator + "/" + operator + ".zip"
+	return requests.Download(ctx, c.httpClient, c.baseURL+path, url.Values{"key": {c.apiKey}}, w)
+}
+
+// Regional downloads the NeTEx Regional dataset of operator and streams
+// its stop places and lines to h.
+func (c *Client) Regional(ctx context.Context, operator string, h Handler) error {
+	return requests.DownloadTemp("netex-*.zip", func(w io.Writer) error {
+		return c.DownloadRegional(ctx, operator, w)
+	}, func(r io.ReaderAt, size int64) error {
+		return Parse(r, size, h)
+	})
+}
+
+// Load downloads and collects the NeTEx Regional dataset of operator.
+func (c *Client) Load(ctx context.Context, operator string) (*Dataset, error) {
+	d := &Dataset{}
+	if err := c.Regional(ctx, operator, d.handler()); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
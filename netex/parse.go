@@ -0,0 +1,140 @@
+package netex
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/nobina/go-trafiklab/requests"
+)
+
+// ErrStop can be returned by a Handler func to stop parsing without an
+// error.
+var ErrStop = requests.ErrStop
+
+// Handler receives the entities of a dataset as they are parsed. Nil
+// funcs skip the entity.
+type Handler struct {
+	StopPlace func(*StopPlace) error
+	Line      func(*Line) error
+}
+
+type Location struct {
+	Lat float64 `xml:"Latitude"`
+	Lon float64 `xml:"Longitude"`
+}
+
+type Ref struct {
+	Ref string `xml:"ref,attr"`
+}
+
+type KeyValue struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+type StopPlace struct {
+	ID            string     `xml:"id,attr"`
+	Name          string     `xml:"Name"`
+	ShortName     string     `xml:"ShortName"`
+	Location      Location   `xml:"Centroid>Location"`
+	TransportMode string     `xml:"TransportMode"`
+	StopPlaceType string     `xml:"StopPlaceType"`
+	ParentSiteRef Ref        `xml:"ParentSiteRef"`
+	Quays         []Quay     `xml:"quays>Quay"`
+	TariffZones   []Ref      `xml:"tariffZones>TariffZoneRef"`
+	KeyList       []KeyValue `xml:"keyList>KeyValue"`
+}
+
+type Quay struct {
+	ID         string     `xml:"id,attr"`
+	Name       string     `xml:"Name"`
+	PublicCode string     `xml:"PublicCode"`
+	Location   Location   `xml:"Centroid>Location"`
+	KeyList    []KeyValue `xml:"keyList>KeyValue"`
+}
+
+type Line struct {
+	ID            string `xml:"id,attr"`
+	Name          string `xml:"Name"`
+	ShortName     string `xml:"ShortName"`
+	TransportMode string `xml:"TransportMode"`
+	PublicCode    string `xml:"PublicCode"`
+	PrivateCode   string `xml:"PrivateCode"`
+	OperatorRef   Ref    `xml:"OperatorRef"`
+	AuthorityRef  Ref    `xml:"AuthorityRef"`
+}
+
+// LocalID returns the last part of a NeTEx id, e.g. 9021001000009192 for
+// SE:050:StopPlace:9021001000009192.
+func LocalID(id string) string {
+	return id[strings.LastIndex(id, ":")+1:]
+}
+
+// Parse streams the stop places and lines of a NeTEx zip archive of size
+// bytes to h.
+func Parse(r io.ReaderAt, size int64, h Handler) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	for _, f := range zr.File {
+		if path.Ext(f.Name) != ".xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", f.Name, err)
+		}
+		err = ParseXML(rc, h)
+		rc.Close()
+		if errors.Is(err, ErrStop) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// ParseXML streams the stop places and lines of a single NeTEx file to h.
+// Errors returned by h are returned as is.
+func ParseXML(r io.Reader, h Handler) error {
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch {
+		case start.Name.Local == "StopPlace" && h.StopPlace != nil:
+			var sp StopPlace
+			if err := dec.DecodeElement(&sp, &start); err != nil {
+				return err
+			}
+			if err := h.StopPlace(&sp); err != nil {
+				return err
+			}
+		case start.Name.Local == "Line" && h.Line != nil:
+			var l Line
+			if err := dec.DecodeElement(&l, &start); err != nil {
+				return err
+			}
+			if err := h.Line(&l); err != nil {
+				return err
+			}
+		}
+	}
+}
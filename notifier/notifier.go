@@ -0,0 +1,492 @@
+// Package notifier turns SL deviations and traffic status events into
+// notifications for users' favorite sites and lines.
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nobina/go-trafiklab/alerts"
+	"github.com/nobina/go-trafiklab/sl/deviations"
+	"github.com/nobina/go-trafiklab/sl/trafficstatus"
+	"github.com/nobina/go-trafiklab/slidentifiers"
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+// Favorite is a set of sites and lines a user wants to hear about.
+type Favorite struct {
+	ID string
+	// Sites may be site ids, HAFAS ids or EFA GIDs.
+	Sites []string
+	// Lines are line designations, e.g. "14" or "43X".
+	Lines []string
+	// Filter drops messages before they are sent. A favorite without
+	// Sites and Lines gets every message passing its filter, e.g. major
+	// disruptions on the metro.
+	Filter Filter
+}
+
+// Filter narrows the messages sent for a favorite. Empty fields match
+// everything.
+type Filter struct {
+	// MinImportance is the lowest deviation importance level sent, from 1
+	// to 9. Traffic status events have no importance and need at least
+	// minor severity from alerts.WarningImportanceLevel and major severity
+	// from alerts.SevereImportanceLevel.
+	MinImportance int
+	// TransportModes are transport.TransportMode constants, matched
+	// against the lines of deviations and the traffic type of traffic
+	// status events.
+	TransportModes []string
+	// Lines are line designations.
+	Lines []string
+}
+
+// all reports whether the favorite subscribes to every message.
+func (f Favorite) all() bool {
+	return len(f.Sites) == 0 && len(f.Lines) == 0
+}
+
+func (f Filter) matchDeviation(d *deviations.DeviationsResponse) bool {
+	if d.Priority.ImportanceLevel < f.MinImportance {
+		return false
+	}
+	if len(f.TransportModes) == 0 && len(f.Lines) == 0 {
+		return true
+	}
+	for _, l := range d.Scope.Lines {
+		if f.matchLine(l.TransportMode, l.Designation) {
+			return true
+		}
+	}
+	return false
+}
+
+// minSeverity maps MinImportance to the lowest traffic status event
+// severity sent, treating severe deviations as major events and warnings
+// as minor ones.
+func (f Filter) minSeverity() trafficstatus.Severity {
+	switch {
+	case f.MinImportance >= alerts.SevereImportanceLevel:
+		return trafficstatus.SeverityMajor
+	case f.MinImportance >= alerts.WarningImportanceLevel:
+		return trafficstatus.SeverityMinor
+	}
+	return trafficstatus.SeverityUnknown
+}
+
+func (f Filter) matchEvent(st trafficstatus.Status, e trafficstatus.Event) bool {
+	if !e.Severity.AtLeast(f.minSeverity()) {
+		return false
+	}
+	if len(f.TransportModes) > 0 && !containsFold(f.TransportModes, st.TransportMode()) {
+		return false
+	}
+	if len(f.Lines) == 0 {
+		return true
+	}
+	for _, l := range f.Lines {
+		if e.Mentions(l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) matchLine(mode, designation string) bool {
+	if len(f.TransportModes) > 0 && !containsFold(f.TransportModes, mode) {
+		return false
+	}
+	return len(f.Lines) == 0 || containsFold(f.Lines, designation)
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+type Kind int
+
+const (
+	KindNew Kind = iota + 1
+	// KindUpdated is sent when the text of a message changes. New versions
+	// with the same text are not sent again.
+	KindUpdated
+)
+
+type Source string
+
+const (
+	SourceDeviations    Source = "deviations"
+	SourceTrafficStatus Source = "traffic-status"
+)
+
+type Notification struct {
+	Favorite string
+	Kind     Kind
+	Source   Source
+	// MessageID is the deviation case id or traffic status event id.
+	MessageID string
+	Header    string
+	Details   string
+	URL       string
+	// Sites and Lines are the favorite's sites and lines the message
+	// matched.
+	Sites []string
+	Lines []string
+}
+
+// Notifier polls deviations and traffic status for the registered
+// favorites. Either client may be nil.
+type Notifier struct {
+	deviations    *deviations.Client
+	trafficStatus *trafficstatus.Client
+	interval      time.Duration
+	lang          string
+	clock         timeutils.Clock
+	onError       func(error)
+
+	mu        sync.Mutex
+	favorites map[string]Favorite
+	// seen holds the text hash of every message sent per favorite, until
+	// the message no longer appears in a poll.
+	seen map[string]uint64
+}
+
+type Option func(*Notifier)
+
+// WithLanguage picks the message variant language of deviations, "sv" by
+// default.
+func WithLanguage(lang string) Option {
+	return func(n *Notifier) {
+		n.lang = lang
+	}
+}
+
+func WithClock(clock timeutils.Clock) Option {
+	return func(n *Notifier) {
+		n.clock = clock
+	}
+}
+
+// WithErrorHandler is called when a poll fails.
+func WithErrorHandler(fn func(error)) Option {
+	return func(n *Notifier) {
+		n.onError = fn
+	}
+}
+
+func New(dev *deviations.Client, ts *trafficstatus.Client, interval time.Duration, opts ...Option) *Notifier {
+	n := &Notifier{
+		deviations:    dev,
+		trafficStatus: ts,
+		interval:      interval,
+		lang:          "sv",
+		clock:         timeutils.SystemClock,
+		onError:       func(error) {},
+		favorites:     map[string]Favorite{},
+		seen:          map[string]uint64{},
+	}
+
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	return n
+}
+
+// Add registers or replaces a favorite, normalizing its site ids.
+func (n *Notifier) Add(f Favorite) error {
+	sites := make([]string, 0, len(f.Sites))
+	for _, s := range f.Sites {
+		siteID, err := slidentifiers.Normalize(s, slidentifiers.KindSiteID)
+		if err != nil {
+			return fmt.Errorf("invalid site %q: %w", s, err)
+		}
+		sites = append(sites, siteID)
+	}
+	f.Sites = sites
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.favorites[f.ID] = f
+	return nil
+}
+
+// Remove unregisters a favorite and forgets what was sent for it.
+func (n *Notifier) Remove(id string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.favorites, id)
+	for k := range n.seen {
+		if strings.HasPrefix(k, id+"\x00") {
+			delete(n.seen, k)
+		}
+	}
+}
+
+// Poll fetches the current messages and returns the notifications not
+// sent before.
+func (n *Notifier) Poll(ctx context.Context) ([]Notification, error) {
+	n.mu.Lock()
+	favorites := make([]Favorite, 0, len(n.favorites))
+	for _, f := range n.favorites {
+		favorites = append(favorites, f)
+	}
+	n.mu.Unlock()
+	sort.Slice(favorites, func(i, j int) bool { return favorites[i].ID < favorites[j].ID })
+
+	var candidates []Notification
+	var errs []error
+	// polled holds the sources fetched without errors, whose messages
+	// missing from candidates are gone.
+	polled := map[Source]bool{}
+	if n.deviations != nil {
+		c, err := n.pollDeviations(ctx, favorites)
+		candidates = append(candidates, c...)
+		errs = append(errs, err)
+		polled[SourceDeviations] = err == nil
+	}
+	if n.trafficStatus != nil {
+		c, err := n.pollTrafficStatus(ctx, favorites)
+		candidates = append(candidates, c...)
+		errs = append(errs, err)
+		polled[SourceTrafficStatus] = err == nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	current := map[string]bool{}
+	var notifications []Notification
+	for _, c := range candidates {
+		if _, ok := n.favorites[c.Favorite]; !ok {
+			continue
+		}
+		k := seenKey(c)
+		current[k] = true
+		h := textHash(c.Header, c.Details)
+		prev, ok := n.seen[k]
+		if ok && prev == h {
+			continue
+		}
+		if ok {
+			c.Kind = KindUpdated
+		}
+		n.seen[k] = h
+		notifications = append(notifications, c)
+	}
+	for k := range n.seen {
+		parts := strings.SplitN(k, "\x00", 3)
+		if len(parts) == 3 && polled[Source(parts[1])] && !current[k] {
+			delete(n.seen, k)
+		}
+	}
+	return notifications, errors.Join(errs...)
+}
+
+// seenKey identifies a message sent for a favorite in Notifier.seen.
+func seenKey(m Notification) string {
+	return m.Favorite + "\x00" + string(m.Source) + "\x00" + m.MessageID
+}
+
+func (n *Notifier) pollDeviations(ctx context.Context, favorites []Favorite) ([]Notification, error) {
+	// Deviations are fetched per site, since the response doesn't say
+	// which requested site a deviation matched.
+	bySite := map[string][]*deviations.DeviationsResponse{}
+	// Lines are queried by number. Designations such as "43X" can't be,
+	// so if a favorite has one every deviation is fetched instead and
+	// matched by designation.
+	lines := map[int]bool{}
+	fetchAll := false
+	for _, f := range favorites {
+		for _, s := range f.Sites {
+			bySite[s] = nil
+		}
+		for _, l := range f.Lines {
+			num, err := strconv.Atoi(l)
+			if err != nil {
+				fetchAll = true
+				continue
+			}
+			lines[num] = true
+		}
+		fetchAll = fetchAll || f.all()
+	}
+
+	var errs []error
+	for site := range bySite {
+		id, _ := strconv.Atoi(site)
+		devs, err := n.deviations.Deviations(ctx, &deviations.DeviationsRequest{SiteIDs: []int{id}})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to fetch deviations for site %s: %w", site, err))
+			continue
+		}
+		bySite[site] = devs
+	}
+	var allDevs, lineDevs []*deviations.DeviationsResponse
+	if fetchAll {
+		devs, err := n.deviations.Deviations(ctx, &deviations.DeviationsRequest{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to fetch deviations: %w", err))
+		}
+		allDevs, lineDevs = devs, devs
+	} else if len(lines) > 0 {
+		req := &deviations.DeviationsRequest{}
+		for l := range lines {
+			req.LineNumbers = append(req.LineNumbers, l)
+		}
+		sort.Ints(req.LineNumbers)
+		devs, err := n.deviations.Deviations(ctx, req)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to fetch deviations for lines: %w", err))
+		}
+		lineDevs = devs
+	}
+
+	var notifications []Notification
+	for _, f := range favorites {
+		matches := map[int]*Notification{}
+		match := func(d *deviations.DeviationsResponse) *Notification {
+			if !f.Filter.matchDeviation(d) {
+				return nil
+			}
+			m, ok := matches[d.DeviationCaseID]
+			if !ok {
+				m = n.deviationNotification(f.ID, d)
+				matches[d.DeviationCaseID] = m
+			}
+			return m
+		}
+		for _, s := range f.Sites {
+			for _, d := range bySite[s] {
+				if m := match(d); m != nil {
+					m.Sites = append(m.Sites, s)
+				}
+			}
+		}
+		for _, d := range lineDevs {
+			for _, l := range d.Scope.Lines {
+				for _, fl := range f.Lines {
+					if !strings.EqualFold(l.Designation, fl) {
+						continue
+					}
+					if m := match(d); m != nil {
+						m.Lines = append(m.Lines, fl)
+					}
+				}
+			}
+		}
+		if f.all() {
+			for _, d := range allDevs {
+				match(d)
+			}
+		}
+		ids := make([]int, 0, len(matches))
+		for id := range matches {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		for _, id := range ids {
+			notifications = append(notifications, *matches[id])
+		}
+	}
+	return notifications, errors.Join(errs...)
+}
+
+func (n *Notifier) deviationNotification(favorite string, d *deviations.DeviationsResponse) *Notification {
+	m := &Notification{
+		Favorite:  favorite,
+		Kind:      KindNew,
+		Source:    SourceDeviations,
+		MessageID: strconv.Itoa(d.DeviationCaseID),
+	}
+	for i, v := range d.MessageVariants {
+		if i == 0 || v.Language == n.lang {
+			m.Header, m.Details, m.URL = v.Header, v.Details, v.Weblink
+		}
+	}
+	return m
+}
+
+func (n *Notifier) pollTrafficStatus(ctx context.Context, favorites []Favorite) ([]Notification, error) {
+	resp, err := n.trafficStatus.TrafficStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch traffic status: %w", err)
+	}
+	var notifications []Notification
+	for _, f := range favorites {
+		for _, st := range resp.ResponseData.TrafficTypes {
+			for _, e := range st.Events {
+				if !f.Filter.matchEvent(st, e) {
+					continue
+				}
+				var lines []string
+				for _, l := range f.Lines {
+					if e.Mentions(l) {
+						lines = append(lines, l)
+					}
+				}
+				if len(lines) == 0 && !f.all() {
+					continue
+				}
+				notifications = append(notifications, Notification{
+					Favorite:  f.ID,
+					Kind:      KindNew,
+					Source:    SourceTrafficStatus,
+					MessageID: strconv.Itoa(e.EventID),
+					Header:    e.Message,
+					URL:       e.EventInfoURL,
+					Lines:     lines,
+				})
+			}
+		}
+	}
+	return notifications, nil
+}
+
+func textHash(parts ...string) uint64 {
+	h := fnv.New64a()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// Run polls until ctx is done, sending new and updated notifications.
+// Messages present at the first poll are sent too.
+func (n *Notifier) Run(ctx context.Context, notifications chan<- Notification) error {
+	for {
+		ns, err := n.Poll(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			n.onError(err)
+		}
+		for _, m := range ns {
+			select {
+			case notifications <- m:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-n.clock.After(n.interval):
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package trafiklab
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+type Profile string
+
+const (
+	ProfileProduction Profile = "production"
+	// ProfileIntegration reads the base urls from the environment, see
+	// BaseURLsFromEnv. It never falls back to production: every url must
+	// be set, in the environment or in Config.BaseURLs.
+	ProfileIntegration Profile = "integration"
+)
+
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[Profile]BaseURLs{
+		ProfileProduction: {
+			TravelPlanner: DefaultTravelPlannerURL,
+			Transport:     DefaultTransportURL,
+			Deviations:    DefaultDeviationsURL,
+			Stops:         DefaultStopsURL,
+			StopsNearby:   DefaultStopsNearbyURL,
+			TrafficStatus: DefaultTrafficStatusURL,
+			GTFS:          DefaultGTFSURL,
+		},
+	}
+)
+
+// RegisterProfile adds or replaces a named set of base urls, e.g. one
+// pointing every API at a mock server.
+func RegisterProfile(p Profile, urls BaseURLs) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	profiles[p] = urls
+}
+
+// ProfileBaseURLs returns the base urls of p, with unset urls taken from
+// production. The urls of ProfileIntegration are returned as set in the
+// environment, unset ones left empty.
+func ProfileBaseURLs(p Profile) (BaseURLs, error) {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	if p == ProfileIntegration {
+		return BaseURLsFromEnv(), nil
+	}
+	urls, ok := profiles[p]
+	if !ok {
+		return BaseURLs{}, fmt.Errorf("unknown profile: %s", p)
+	}
+	return urls.merge(profiles[ProfileProduction]), nil
+}
+
+// BaseURLsFromEnv reads base urls from TRAFIKLAB_TRAVELPLANNER_URL,
+// TRAFIKLAB_TRANSPORT_URL, TRAFIKLAB_DEVIATIONS_URL, TRAFIKLAB_STOPS_URL,
+// TRAFIKLAB_STOPSNEARBY_URL, TRAFIKLAB_TRAFFICSTATUS_URL and
+// TRAFIKLAB_GTFS_URL.
+func BaseURLsFromEnv() BaseURLs {
+	return BaseURLs{
+		TravelPlanner: os.Getenv("TRAFIKLAB_TRAVELPLANNER_URL"),
+		Transport:     os.Getenv("TRAFIKLAB_TRANSPORT_URL"),
+		Deviations:    os.Getenv("TRAFIKLAB_DEVIATIONS_URL"),
+		Stops:         os.Getenv("TRAFIKLAB_STOPS_URL"),
+		StopsNearby:   os.Getenv("TRAFIKLAB_STOPSNEARBY_URL"),
+		TrafficStatus: os.Getenv("TRAFIKLAB_TRAFFICSTATUS_URL"),
+		GTFS:          os.Getenv("TRAFIKLAB_GTFS_URL"),
+	}
+}
+
+// missingEnv returns the environment variables of the urls that are unset
+// in b.
+func (b BaseURLs) missingEnv() []string {
+	var missing []string
+	for _, u := range []struct{ url, env string }{
+		{b.TravelPlanner, "TRAFIKLAB_TRAVELPLANNER_URL"},
+		{b.Transport, "TRAFIKLAB_TRANSPORT_URL"},
+		{b.Deviations, "TRAFIKLAB_DEVIATIONS_URL"},
+		{b.Stops, "TRAFIKLAB_STOPS_URL"},
+		{b.StopsNearby, "TRAFIKLAB_STOPSNEARBY_URL"},
+		{b.TrafficStatus, "TRAFIKLAB_TRAFFICSTATUS_URL"},
+		{b.GTFS, "TRAFIKLAB_GTFS_URL"},
+	} {
+		if u.url == "" {
+			missing = append(missing, u.env)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,196 @@
+// Package replan finds alternative journeys when a planned travel planner
+// trip is disrupted, excluding the affected lines and stops.
+package replan
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nobina/go-trafiklab/alerts"
+	"github.com/nobina/go-trafiklab/sl/travelplanner"
+	"github.com/nobina/go-trafiklab/slidentifiers"
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+// Disruption is what makes a trip impossible.
+type Disruption struct {
+	// Lines are line designations.
+	Lines []string
+	// Stops are stop ids in any format understood by the travel planner.
+	Stops []string
+	// Cancelled is set when a leg of the trip is cancelled.
+	Cancelled bool
+}
+
+func (d Disruption) empty() bool {
+	return len(d.Lines) == 0 && len(d.Stops) == 0 && !d.Cancelled
+}
+
+// legLine returns the line designation of a leg.
+func legLine(leg *travelplanner.Leg) string {
+	if leg.Product != nil && leg.Product.Line != "" {
+		return leg.Product.Line
+	}
+	return ""
+}
+
+func legStops(leg *travelplanner.Leg) []string {
+	stops := []string{leg.Origin.ExtID, leg.Destination.ExtID}
+	for _, s := range leg.Stops {
+		stops = append(stops, s.ExtId)
+	}
+	return stops
+}
+
+// Check returns the disruption of trip given the alerts active at t.
+// Only severe alerts are considered. Alert stops are compared with the
+// site ids of the trip's stops, so deviations, which list stop areas, only
+// match by line.
+func Check(trip *travelplanner.Trip, active []alerts.Alert, t time.Time) (Disruption, bool) {
+	var d Disruption
+	for i := range trip.Legs {
+		leg := &trip.Legs[i]
+		if leg.Type == "WALK" {
+			continue
+		}
+		if leg.Cancelled {
+			d.Cancelled = true
+			if line := legLine(leg); line != "" && !slices.Contains(d.Lines, line) {
+				d.Lines = append(d.Lines, line)
+			}
+		}
+		line := legLine(leg)
+		sites := map[string]string{}
+		for _, id := range legStops(leg) {
+			if site, err := slidentifiers.Normalize(id, slidentifiers.KindSiteID); err == nil {
+				sites[site] = id
+			}
+		}
+		for _, a := range active {
+			if a.Severity != alerts.SeveritySevere || !a.ActiveAt(t) {
+				continue
+			}
+			if line != "" && slices.Contains(a.Lines, line) && !slices.Contains(d.Lines, line) {
+				d.Lines = append(d.Lines, line)
+			}
+			for _, s := range a.Stops {
+				if id, ok := sites[s]; ok && !slices.Contains(d.Stops, id) {
+					d.Stops = append(d.Stops, id)
+				}
+			}
+		}
+	}
+	return d, !d.empty()
+}
+
+// Alternative is a replacement trip.
+type Alternative struct {
+	Trip      *travelplanner.Trip
+	Departure time.Time
+	Arrival   time.Time
+	Changes   int
+}
+
+// Replanner searches alternatives with the travel planner.
+type Replanner struct {
+	client *travelplanner.TravelPlannerClient
+	clock  timeutils.Clock
+}
+
+type Option func(*Replanner)
+
+func WithClock(clock timeutils.Clock) Option {
+	return func(r *Replanner) {
+		r.clock = clock
+	}
+}
+
+func New(client *travelplanner.TravelPlannerClient, opts ...Option) *Replanner {
+	r := &Replanner{
+		client: client,
+		clock:  timeutils.SystemClock,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Alternatives repeats the original search from now, excluding the lines
+// of d and avoiding its first stop, since the travel planner only takes
+// one stop to avoid. Trips still using an excluded line are dropped. The
+// rest are ranked by arrival, then changes.
+func (r *Replanner) Alternatives(ctx context.Context, original travelplanner.TripsRequest, d Disruption) ([]Alternative, error) {
+	req := original
+	req.Context = ""
+	req.SearchForArrival = false
+	req.Time = r.clock.Now()
+	lines := slices.Clone(original.Lines)
+	for _, l := range d.Lines {
+		lines = append(lines, "!"+l)
+	}
+	req.Lines = lines
+	if len(d.Stops) > 0 {
+		req.AvoidID = d.Stops[0]
+	}
+
+	resp, err := r.client.Trips(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search alternatives: %w", err)
+	}
+
+	var alternatives []Alternative
+	for i := range resp.Trips {
+		trip := &resp.Trips[i]
+		if usesLine(trip, d.Lines) {
+			continue
+		}
+		dep, arr, err := trip.Times()
+		if err != nil {
+			continue
+		}
+		alternatives = append(alternatives, Alternative{
+			Trip:      trip,
+			Departure: dep,
+			Arrival:   arr,
+			Changes:   trip.Changes(),
+		})
+	}
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		if !alternatives[i].Arrival.Equal(alternatives[j].Arrival) {
+			return alternatives[i].Arrival.Before(alternatives[j].Arrival)
+		}
+		return alternatives[i].Changes < alternatives[j].Changes
+	})
+	return alternatives, nil
+}
+
+func usesLine(trip *travelplanner.Trip, lines []string) bool {
+	for i := range trip.Legs {
+		line := legLine(&trip.Legs[i])
+		for _, l := range lines {
+			if strings.EqualFold(line, l) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Replan checks trip and searches alternatives if it is disrupted. It
+// returns nil alternatives if the trip is fine.
+func (r *Replanner) Replan(ctx context.Context, original travelplanner.TripsRequest, trip *travelplanner.Trip, active []alerts.Alert) ([]Alternative, *Disruption, error) {
+	d, disrupted := Check(trip, active, r.clock.Now())
+	if !disrupted {
+		return nil, nil, nil
+	}
+	alternatives, err := r.Alternatives(ctx, original, d)
+	if err != nil {
+		return nil, &d, err
+	}
+	return alternatives, &d, nil
+}
@@ -0,0 +1,46 @@
+package requests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// Download requests rawURL with params added to its query and copies a
+// successful response to w. Other status codes return an APIError. The
+// body isn't size limited, since it is meant for archives such as GTFS
+// and NeTEx feeds.
+func Download(ctx context.Context, client *http.Client, rawURL string, params url.Values, w io.Writer, opts ...GetOption) error {
+	opts = append([]GetOption{MaxResponseSize(0)}, opts...)
+	return do(ctx, client, rawURL, params, "*/*", opts, func(u *url.URL, body io.Reader) error {
+		if _, err := io.Copy(w, body); err != nil {
+			return fmt.Errorf("failed to download %s: %w", RedactURL(u, redactedParams...), err)
+		}
+		return nil
+	})
+}
+
+// DownloadTemp calls download with a temporary file named after pattern,
+// then read with the file and the number of bytes written, e.g. to open a
+// zip archive, which needs random access, without holding it in memory.
+// The file is removed afterwards.
+func DownloadTemp(pattern string, download func(io.Writer) error, read func(r io.ReaderAt, size int64) error) error {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := download(f); err != nil {
+		return err
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("failed to read temp file: %w", err)
+	}
+	return read(f, size)
+}
@@ -0,0 +1,109 @@
+package requests
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// DefaultDumpBodySize is the number of body bytes kept in dumps when no
+// other limit is given.
+const DefaultDumpBodySize = 4 << 10
+
+const redacted = "REDACTED"
+
+// redactedParams are query parameters holding API keys.
+var redactedParams = []string{"key", "accessId"}
+
+var redactedHeaders = append([]string{"Authorization"}, correlationHeaders...)
+
+// DumpRequest returns req as sent on the wire with API keys and
+// correlation ids redacted. The body is not included.
+func DumpRequest(req *http.Request) ([]byte, error) {
+	r := req.Clone(req.Context())
+	r.URL.RawQuery = redactQuery(req.URL)
+	r.Header = redactHeaders(req.Header)
+	return httputil.DumpRequest(r, false)
+}
+
+// DumpResponse returns res with correlation ids redacted and at most
+// maxBody bytes of the body, DefaultDumpBodySize if maxBody is zero. The
+// body of res is left readable.
+func DumpResponse(res *http.Response, maxBody int) ([]byte, error) {
+	if maxBody == 0 {
+		maxBody = DefaultDumpBodySize
+	}
+	r := *res
+	r.Header = redactHeaders(res.Header)
+	b, err := httputil.DumpResponse(&r, false)
+	if err != nil {
+		return nil, err
+	}
+	if res.Body == nil || maxBody < 0 {
+		return b, nil
+	}
+
+	head := make([]byte, maxBody+1)
+	n, err := io.ReadFull(res.Body, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	head = head[:n]
+	res.Body = readCloser{io.MultiReader(bytes.NewReader(head), res.Body), res.Body}
+
+	if n > maxBody {
+		return fmt.Appendf(b, "%s\n[truncated after %d bytes]", head[:maxBody], maxBody), nil
+	}
+	return append(b, head...), nil
+}
+
+// Dump logs every request and response, redacted and capped at maxBody
+// bytes of response body.
+func Dump(logger Logger, maxBody int) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if b, err := DumpRequest(req); err == nil {
+				logger.Printf("request: %s", b)
+			}
+			res, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+			b, err := DumpResponse(res, maxBody)
+			if err != nil {
+				logger.Printf("failed to dump response: %v", err)
+				return res, nil
+			}
+			logger.Printf("response: %s", b)
+			return res, nil
+		})
+	}
+}
+
+func redactHeaders(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range redactedHeaders {
+		if h.Get(name) != "" {
+			h.Set(name, redacted)
+		}
+	}
+	return h
+}
+
+func redactQuery(u *url.URL) string {
+	q := u.Query()
+	for _, p := range redactedParams {
+		if q.Has(p) {
+			q.Set(p, redacted)
+		}
+	}
+	return q.Encode()
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
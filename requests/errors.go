@@ -0,0 +1,100 @@
+package requests
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Categories of APIError, usable with errors.Is.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrUpstreamDown = errors.New("upstream down")
+	ErrUnexpected   = errors.New("unexpected status")
+)
+
+const snippetSize = 512
+
+var correlationHeaders = []string{
+	"X-Correlation-Id",
+	"X-Request-Id",
+	"Request-Id",
+}
+
+// CorrelationID returns the id the API assigned to a request, if any of
+// the usual headers is set.
+func CorrelationID(h http.Header) string {
+	for _, name := range correlationHeaders {
+		if v := h.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// APIError is returned by the clients when an API responds with an
+// unexpected status code.
+type APIError struct {
+	// Endpoint is the request url without query, which may contain keys.
+	Endpoint      string
+	StatusCode    int
+	Snippet       string
+	CorrelationID string
+	Category      error
+}
+
+// NewAPIError builds an APIError from res, reading at most a short snippet
+// of the body.
+func NewAPIError(res *http.Response) *APIError {
+	e := &APIError{
+		StatusCode: res.StatusCode,
+		Category:   categoryFor(res.StatusCode),
+	}
+	if res.Request != nil && res.Request.URL != nil {
+		u := *res.Request.URL
+		u.RawQuery = ""
+		e.Endpoint = u.String()
+	}
+	e.CorrelationID = CorrelationID(res.Header)
+	if res.Body != nil {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, snippetSize))
+		e.Snippet = strings.TrimSpace(string(b))
+	}
+	return e
+}
+
+func categoryFor(statusCode int) error {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode == http.StatusNotFound:
+		return ErrNotFound
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case statusCode >= 500:
+		return ErrUpstreamDown
+	case statusCode >= 400:
+		return ErrBadRequest
+	}
+	return ErrUnexpected
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%v: status code %d from %s", e.Category, e.StatusCode, e.Endpoint)
+	if e.CorrelationID != "" {
+		msg += ", correlation id " + e.CorrelationID
+	}
+	if e.Snippet != "" {
+		msg += ": " + e.Snippet
+	}
+	return msg
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Category
+}
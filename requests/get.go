@@ -0,0 +1,137 @@
+package requests
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// DefaultMaxResponseSize is the largest body GetJSON and GetXML decode
+// unless changed with MaxResponseSize.
+const DefaultMaxResponseSize = 32 << 20
+
+// ErrResponseTooLarge is returned when a body exceeds the size limit.
+var ErrResponseTooLarge = errors.New("response too large")
+
+type getConfig struct {
+	maxSize    int64
+	onResponse func(*http.Response)
+}
+
+// GetOption configures GetJSON and GetXML.
+type GetOption func(*getConfig)
+
+// MaxResponseSize limits the decoded body to n bytes.
+func MaxResponseSize(n int64) GetOption {
+	return func(c *getConfig) {
+		c.maxSize = n
+	}
+}
+
+// OnResponse calls fn with every response before its status is checked,
+// e.g. to report key usage or dump the response when debugging.
+func OnResponse(fn func(*http.Response)) GetOption {
+	return func(c *getConfig) {
+		c.onResponse = fn
+	}
+}
+
+// GetJSON requests rawURL with params added to its query and decodes a
+// successful JSON response into T. Other status codes return an APIError.
+func GetJSON[T any](ctx context.Context, client *http.Client, rawURL string, params url.Values, opts ...GetOption) (T, error) {
+	return get[T](ctx, client, rawURL, params, "application/json", func(r io.Reader, v any) error {
+		return json.NewDecoder(r).Decode(v)
+	}, opts)
+}
+
+// GetXML is GetJSON for XML responses.
+func GetXML[T any](ctx context.Context, client *http.Client, rawURL string, params url.Values, opts ...GetOption) (T, error) {
+	return get[T](ctx, client, rawURL, params, "application/xml", func(r io.Reader, v any) error {
+		return xml.NewDecoder(r).Decode(v)
+	}, opts)
+}
+
+func get[T any](ctx context.Context, client *http.Client, rawURL string, params url.Values, accept string, decode func(io.Reader, any) error, opts []GetOption) (T, error) {
+	var v T
+	err := do(ctx, client, rawURL, params, accept, opts, func(u *url.URL, body io.Reader) error {
+		if err := decode(body, &v); err != nil {
+			return fmt.Errorf("failed to decode response from %s: %w", RedactURL(u, redactedParams...), err)
+		}
+		return nil
+	})
+	return v, err
+}
+
+// do makes a GET request and calls read with the body of a successful
+// response.
+func do(ctx context.Context, client *http.Client, rawURL string, params url.Values, accept string, opts []GetOption, read func(*url.URL, io.Reader) error) error {
+	cfg := getConfig{maxSize: DefaultMaxResponseSize}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", RedactURLError(err, redactedParams...))
+	}
+	q := u.Query()
+	for k, vals := range params {
+		q[k] = vals
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", RedactURLError(err, redactedParams...))
+	}
+	req.Header.Set("Accept", accept)
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed request: %w", RedactURLError(err, redactedParams...))
+	}
+	defer res.Body.Close()
+	if cfg.onResponse != nil {
+		cfg.onResponse(res)
+	}
+	if res.StatusCode != http.StatusOK {
+		return NewAPIError(res)
+	}
+
+	body := io.Reader(res.Body)
+	if cfg.maxSize > 0 {
+		body = &limitedReader{r: res.Body, n: cfg.maxSize}
+	}
+	return read(u, body)
+}
+
+// limitedReader fails with ErrResponseTooLarge instead of truncating, so
+// an oversized body isn't mistaken for a malformed one.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		var probe [1]byte
+		if n, _ := l.r.Read(probe[:]); n == 0 {
+			return 0, io.EOF
+		}
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
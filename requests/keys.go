@@ -0,0 +1,112 @@
+package requests
+
+import (
+	"net/http"
+	"sync"
+)
+
+type Rotation int
+
+const (
+	// RotateRoundRobin uses the next key for every request.
+	RotateRoundRobin Rotation = iota
+	// RotateOnRateLimit keeps using a key until it is rate limited.
+	RotateOnRateLimit
+)
+
+// KeyPool hands out api keys for a service and rotates between them.
+type KeyPool struct {
+	mu       sync.Mutex
+	rotation Rotation
+	keys     []string
+	next     int
+	usage    map[string]*KeyUsage
+}
+
+type KeyUsage struct {
+	Key         string
+	Requests    int64
+	RateLimited int64
+}
+
+func NewKeyPool(rotation Rotation, keys ...string) *KeyPool {
+	p := &KeyPool{
+		rotation: rotation,
+		keys:     keys,
+		usage:    map[string]*KeyUsage{},
+	}
+	for _, k := range keys {
+		p.usage[k] = &KeyUsage{Key: k}
+	}
+	return p
+}
+
+func (p *KeyPool) Len() int {
+	return len(p.keys)
+}
+
+// Key returns the key to use for the next request.
+func (p *KeyPool) Key() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.keys) == 0 {
+		return ""
+	}
+	key := p.keys[p.next]
+	p.usage[key].Requests++
+	if p.rotation == RotateRoundRobin {
+		p.next = (p.next + 1) % len(p.keys)
+	}
+	return key
+}
+
+// Report records the status code of a request made with key, moving on to
+// the next key if it was rate limited.
+func (p *KeyPool) Report(key string, statusCode int) {
+	if statusCode != http.StatusTooManyRequests {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	u, ok := p.usage[key]
+	if !ok {
+		return
+	}
+	u.RateLimited++
+	if p.rotation == RotateOnRateLimit && p.keys[p.next] == key {
+		p.next = (p.next + 1) % len(p.keys)
+	}
+}
+
+// Usage returns the usage counters per key, in the order the keys were
+// given.
+func (p *KeyPool) Usage() []KeyUsage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	usage := make([]KeyUsage, 0, len(p.keys))
+	for _, k := range p.keys {
+		usage = append(usage, *p.usage[k])
+	}
+	return usage
+}
+
+// RotateKeys sets query parameter param of every request to a key from
+// pool, replacing the configured key, and reports the status code of the
+// response back to the pool.
+func RotateKeys(pool *KeyPool, param string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			key := pool.Key()
+			req = req.Clone(req.Context())
+			q := req.URL.Query()
+			q.Set(param, key)
+			req.URL.RawQuery = q.Encode()
+			res, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+			pool.Report(key, res.StatusCode)
+			return res, nil
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package requests
+
+import (
+	"net/http"
+	"time"
+)
+
+// Middleware wraps a RoundTripper, e.g. to add headers, logging or
+// retries to every request made through an http.Client.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain wraps base with middlewares. The first middleware is the outermost
+// and sees the request first.
+func Chain(base http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		base = middlewares[i](base)
+	}
+	return base
+}
+
+// WrapClient returns a copy of client with its transport wrapped by
+// middlewares. A nil client is treated as http.DefaultClient.
+func WrapClient(client *http.Client, middlewares ...Middleware) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	wrapped := *client
+	wrapped.Transport = Chain(client.Transport, middlewares...)
+	return &wrapped
+}
+
+// SetHeader sets header key to value on every request that doesn't already
+// have it.
+func SetHeader(key, value string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(key) == "" {
+				req = req.Clone(req.Context())
+				req.Header.Set(key, value)
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// LogRequests logs method, url, status and duration of every request. Query
+// strings are left out since they can contain api keys.
+func LogRequests(logger Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			res, err := next.RoundTrip(req)
+			u := *req.URL
+			u.RawQuery = ""
+			if err != nil {
+				logger.Printf("%s %s failed after %s: %v", req.Method, u.String(), time.Since(start), err)
+				return nil, err
+			}
+			logger.Printf("%s %s %d in %s", req.Method, u.String(), res.StatusCode, time.Since(start))
+			return res, nil
+		})
+	}
+}
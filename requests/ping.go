@@ -0,0 +1,24 @@
+package requests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultPingTimeout bounds health check requests made with Ping.
+const DefaultPingTimeout = 5 * time.Second
+
+// Ping requests rawURL and discards the body, returning an APIError for any
+// status but 200. The request is bounded by DefaultPingTimeout unless ctx
+// has an earlier deadline.
+func Ping(ctx context.Context, client *http.Client, rawURL string, params url.Values, opts ...GetOption) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultPingTimeout)
+	defer cancel()
+	return do(ctx, client, rawURL, params, "*/*", opts, func(_ *url.URL, body io.Reader) error {
+		_, err := io.Copy(io.Discard, body)
+		return err
+	})
+}
@@ -0,0 +1,121 @@
+package requests
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Quota is the usage of one API host as seen by a QuotaTracker. Limit and
+// Remaining are only set if the API sends rate limit headers.
+type Quota struct {
+	Requests    int64
+	RateLimited int64
+	Limit       int
+	Remaining   int
+	Reset       string
+	UpdatedAt   time.Time
+}
+
+// Utilization returns the used share of the limit, or 0 if the limit is
+// unknown.
+func (q Quota) Utilization() float64 {
+	if q.Limit <= 0 {
+		return 0
+	}
+	return float64(q.Limit-q.Remaining) / float64(q.Limit)
+}
+
+// QuotaTracker counts requests per host and records rate limit headers.
+type QuotaTracker struct {
+	mu          sync.Mutex
+	quotas      map[string]*Quota
+	crossed     map[string]bool
+	threshold   float64
+	onThreshold func(host string, q Quota)
+}
+
+// NewQuotaTracker calls onThreshold, if set, when the utilization of a host
+// reaches threshold. It is called again only after utilization has dropped
+// below threshold.
+func NewQuotaTracker(threshold float64, onThreshold func(host string, q Quota)) *QuotaTracker {
+	return &QuotaTracker{
+		quotas:      map[string]*Quota{},
+		crossed:     map[string]bool{},
+		threshold:   threshold,
+		onThreshold: onThreshold,
+	}
+}
+
+func (t *QuotaTracker) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			res, err := next.RoundTrip(req)
+			t.record(req.URL.Host, res)
+			return res, err
+		})
+	}
+}
+
+func headerInt(h http.Header, keys ...string) (int, bool) {
+	for _, k := range keys {
+		if v := h.Get(k); v != "" {
+			if n, err := strconv.Atoi(v); err == nil {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (t *QuotaTracker) record(host string, res *http.Response) {
+	t.mu.Lock()
+	q, ok := t.quotas[host]
+	if !ok {
+		q = &Quota{}
+		t.quotas[host] = q
+	}
+	q.Requests++
+	q.UpdatedAt = time.Now()
+	if res != nil {
+		if res.StatusCode == http.StatusTooManyRequests {
+			q.RateLimited++
+		}
+		if n, ok := headerInt(res.Header, "X-RateLimit-Limit", "RateLimit-Limit"); ok {
+			q.Limit = n
+		}
+		if n, ok := headerInt(res.Header, "X-RateLimit-Remaining", "RateLimit-Remaining"); ok {
+			q.Remaining = n
+		}
+		if v := res.Header.Get("X-RateLimit-Reset"); v != "" {
+			q.Reset = v
+		} else if v := res.Header.Get("RateLimit-Reset"); v != "" {
+			q.Reset = v
+		}
+	}
+
+	snapshot := *q
+	notify := false
+	if t.threshold > 0 && q.Limit > 0 {
+		above := q.Utilization() >= t.threshold
+		notify = above && !t.crossed[host]
+		t.crossed[host] = above
+	}
+	t.mu.Unlock()
+
+	if notify && t.onThreshold != nil {
+		t.onThreshold(host, snapshot)
+	}
+}
+
+// Quota returns a snapshot of the usage per host.
+func (t *QuotaTracker) Quota() map[string]Quota {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	quotas := make(map[string]Quota, len(t.quotas))
+	for h, q := range t.quotas {
+		quotas[h] = *q
+	}
+	return quotas
+}
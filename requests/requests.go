@@ -2,9 +2,11 @@ package requests
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 )
 
 // JSON creates a request with default JSON headers
@@ -16,3 +18,67 @@ func JSON(ctx context.Context, method, url string, body io.Reader) (*http.Reques
 	req.Header.Set("Content-Type", "application/json")
 	return req, nil
 }
+
+// Logger is the logging interface accepted by the clients, satisfied by
+// *log.Logger.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// ErrInsecureBaseURL is returned by ValidateBaseURL for http urls.
+var ErrInsecureBaseURL = errors.New("base url must use https")
+
+// AllowInsecure is the AllowInsecure field of the client configs. When
+// set, http base urls are permitted. API keys are sent in the query
+// string, so this should only be used against local mock servers.
+type AllowInsecure bool
+
+// ValidateBaseURL checks that raw is an absolute url using https, or http
+// if allowInsecure is set.
+func ValidateBaseURL(raw string, allowInsecure AllowInsecure) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid base url %q: %w", raw, err)
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("invalid base url %q: must be absolute", raw)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if allowInsecure {
+			return nil
+		}
+		return fmt.Errorf("%w: %q", ErrInsecureBaseURL, raw)
+	}
+	return fmt.Errorf("invalid base url %q: unsupported scheme %s", raw, u.Scheme)
+}
+
+// RedactURL returns u as a string with the values of params replaced, for
+// use in logs and errors.
+func RedactURL(u *url.URL, params ...string) string {
+	q := u.Query()
+	for _, p := range params {
+		if q.Has(p) {
+			q.Set(p, "REDACTED")
+		}
+	}
+	c := *u
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// RedactURLError redacts params in the url of a *url.Error, as returned by
+// http.Client.Do, which otherwise includes api keys.
+func RedactURLError(err error, params ...string) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	u, perr := url.Parse(urlErr.URL)
+	if perr != nil {
+		return &url.Error{Op: urlErr.Op, URL: "REDACTED", Err: urlErr.Err}
+	}
+	return &url.Error{Op: urlErr.Op, URL: RedactURL(u, params...), Err: urlErr.Err}
+}
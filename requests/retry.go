@@ -0,0 +1,123 @@
+package requests
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy retries failed requests with exponential backoff.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// RetryableStatusCodes are retried in addition to transport errors.
+	RetryableStatusCodes []int
+	// RetryableMethodStatusCodes replace RetryableStatusCodes for the
+	// given methods, e.g. to not retry gateway errors of a POST that may
+	// already have been processed.
+	RetryableMethodStatusCodes map[string][]int
+	// RespectRetryAfter waits for the Retry-After header, capped by
+	// MaxBackoff, instead of the computed backoff when it is present.
+	RespectRetryAfter bool
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryableMethodStatusCodes: map[string][]int{
+			http.MethodPost: {
+				http.StatusTooManyRequests,
+				http.StatusServiceUnavailable,
+			},
+		},
+		RespectRetryAfter: true,
+	}
+}
+
+func (p RetryPolicy) retryable(method string, statusCode int) bool {
+	codes, ok := p.RetryableMethodStatusCodes[method]
+	if !ok {
+		codes = p.RetryableStatusCodes
+	}
+	return slices.Contains(codes, statusCode)
+}
+
+func (p RetryPolicy) backoff(attempt int, res *http.Response) time.Duration {
+	d := p.InitialBackoff << attempt
+	if p.RespectRetryAfter && res != nil {
+		if ra, ok := retryAfter(res.Header.Get("Retry-After")); ok {
+			d = ra
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
+
+// Middleware retries requests according to the policy. Requests with a
+// body are only retried if the body can be recreated through GetBody.
+func (p RetryPolicy) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			for attempt := 0; ; attempt++ {
+				res, err := next.RoundTrip(req)
+
+				last := attempt+1 >= p.MaxAttempts ||
+					req.Context().Err() != nil ||
+					(req.Body != nil && req.Body != http.NoBody && req.GetBody == nil)
+				if last || (err == nil && !p.retryable(req.Method, res.StatusCode)) {
+					return res, err
+				}
+
+				wait := p.backoff(attempt, res)
+				if res != nil {
+					io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+					res.Body.Close()
+				}
+
+				timer := time.NewTimer(wait)
+				select {
+				case <-req.Context().Done():
+					timer.Stop()
+					return nil, req.Context().Err()
+				case <-timer.C:
+				}
+
+				if req.GetBody != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, err
+					}
+					req = req.Clone(req.Context())
+					req.Body = body
+				}
+			}
+		})
+	}
+}
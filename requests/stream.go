@@ -0,0 +1,80 @@
+package requests
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ErrStop can be returned from a StreamJSON or StreamXML callback to stop
+// reading without an error.
+var ErrStop = errors.New("stop iteration")
+
+// StreamJSON requests rawURL like GetJSON, but expects a JSON array and
+// calls fn with each element as it is decoded, so the whole response is
+// never held in memory. Streamed responses have no size limit unless set
+// with MaxResponseSize.
+func StreamJSON[T any](ctx context.Context, client *http.Client, rawURL string, params url.Values, fn func(T) error, opts ...GetOption) error {
+	opts = append([]GetOption{MaxResponseSize(0)}, opts...)
+	return do(ctx, client, rawURL, params, "application/json", opts, func(u *url.URL, body io.Reader) error {
+		dec := json.NewDecoder(body)
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to decode response from %s: %w", RedactURL(u, redactedParams...), err)
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return fmt.Errorf("failed to decode response from %s: expected array, got %v", RedactURL(u, redactedParams...), tok)
+		}
+		for dec.More() {
+			var v T
+			if err := dec.Decode(&v); err != nil {
+				return fmt.Errorf("failed to decode response from %s: %w", RedactURL(u, redactedParams...), err)
+			}
+			if err := fn(v); err != nil {
+				if errors.Is(err, ErrStop) {
+					return nil
+				}
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// StreamXML requests rawURL like GetXML and calls fn with every element
+// named element, at any depth, as it is decoded. Everything else in the
+// document is skipped.
+func StreamXML[T any](ctx context.Context, client *http.Client, rawURL string, params url.Values, element string, fn func(T) error, opts ...GetOption) error {
+	opts = append([]GetOption{MaxResponseSize(0)}, opts...)
+	return do(ctx, client, rawURL, params, "application/xml", opts, func(u *url.URL, body io.Reader) error {
+		dec := xml.NewDecoder(body)
+		for {
+			tok, err := dec.Token()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to decode response from %s: %w", RedactURL(u, redactedParams...), err)
+			}
+			start, ok := tok.(xml.StartElement)
+			if !ok || start.Name.Local != element {
+				continue
+			}
+			var v T
+			if err := dec.DecodeElement(&v, &start); err != nil {
+				return fmt.Errorf("failed to decode response from %s: %w", RedactURL(u, redactedParams...), err)
+			}
+			if err := fn(v); err != nil {
+				if errors.Is(err, ErrStop) {
+					return nil
+				}
+				return err
+			}
+		}
+	})
+}
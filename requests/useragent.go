@@ -0,0 +1,28 @@
+package requests
+
+import "net/http"
+
+// Version is the library version reported in the default User-Agent.
+const Version = "0.1.0"
+
+// DefaultUserAgent identifies requests made by this library, as Trafiklab
+// asks integrators to do.
+const DefaultUserAgent = "go-trafiklab/" + Version
+
+// UserAgent sets the User-Agent header of every request to ua. With an
+// empty ua it sets DefaultUserAgent on requests that don't already have
+// one, which is what the clients wrap their http client with. A UserAgent
+// middleware on the http client passed to a client therefore takes
+// precedence over the default.
+func UserAgent(ua string) Middleware {
+	if ua == "" {
+		return SetHeader("User-Agent", DefaultUserAgent)
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("User-Agent", ua)
+			return next.RoundTrip(req)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package resrobot
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+type BoardRequest struct {
+	// ID is the stop id, e.g. 740000001 for Stockholm Centralstation.
+	ID string
+	// Time is the start of the board. Zero means now.
+	Time time.Time
+	// Duration is how far ahead to look, at most 24 hours. Zero uses the
+	// API default of one hour.
+	Duration time.Duration
+	Products []ProductRef
+	// MaxJourneys caps the number of entries, 0 meaning no limit.
+	MaxJourneys int
+	Passlist    bool
+}
+
+func (r BoardRequest) params() url.Values {
+	params := url.Values{}
+	params.Set("id", r.ID)
+	if !r.Time.IsZero() {
+		t := r.Time.In(timeutils.EuropeStockholm())
+		params.Set("date", t.Format("2006-01-02"))
+		params.Set("time", t.Format("15:04"))
+	}
+	if r.Duration > 0 {
+		params.Set("duration", strconv.Itoa(int(min(r.Duration, 24*time.Hour)/time.Minute)))
+	}
+	if mask := productMask(r.Products); mask != 0 {
+		params.Set("products", strconv.Itoa(mask))
+	}
+	if r.MaxJourneys > 0 {
+		params.Set("maxJourneys", strconv.Itoa(r.MaxJourneys))
+	}
+	if r.Passlist {
+		params.Set("passlist", "1")
+	} else {
+		params.Set("passlist", "0")
+	}
+	return params
+}
+
+type DepartureBoard struct {
+	ErrorCode  string       `json:"errorCode"`
+	ErrorText  string       `json:"errorText"`
+	Departures []BoardEntry `json:"Departure"`
+}
+
+type ArrivalBoard struct {
+	ErrorCode string       `json:"errorCode"`
+	ErrorText string       `json:"errorText"`
+	Arrivals  []BoardEntry `json:"Arrival"`
+}
+
+// BoardEntry is a departure or arrival at a stop. Direction is set on
+// departures and Origin on arrivals.
+type BoardEntry struct {
+	Name            string    `json:"name"`
+	Type            string    `json:"type"`
+	Stop            string    `json:"stop"`
+	StopID          string    `json:"stopid"`
+	StopExtID       string    `json:"stopExtId"`
+	Date            string    `json:"date"`
+	Time            string    `json:"time"`
+	RtDate          string    `json:"rtDate"`
+	RtTime          string    `json:"rtTime"`
+	Track           string    `json:"track"`
+	RtTrack         string    `json:"rtTrack"`
+	Direction       string    `json:"direction"`
+	Origin          string    `json:"origin"`
+	TransportNumber string    `json:"transportNumber"`
+	Cancelled       bool      `json:"cancelled"`
+	Product         Product   `json:"ProductAtStop"`
+	Products        []Product `json:"Product"`
+	Stops           *StopList `json:"Stops,omitempty"`
+}
+
+// ScheduledTime parses the planned time of the entry.
+func (e *BoardEntry) ScheduledTime() (time.Time, error) {
+	t, err := timeutils.Parse(e.Date + " " + e.Time)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse time: %w", err)
+	}
+	return t, nil
+}
+
+// ExpectedTime parses the realtime time of the entry, falling back to the
+// scheduled time.
+func (e *BoardEntry) ExpectedTime() (time.Time, error) {
+	if e.RtDate == "" || e.RtTime == "" {
+		return e.ScheduledTime()
+	}
+	t, err := timeutils.Parse(e.RtDate + " " + e.RtTime)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse realtime: %w", err)
+	}
+	return t, nil
+}
+
+// Delay is the difference between the expected and scheduled time.
+func (e *BoardEntry) Delay() (time.Duration, error) {
+	scheduled, err := e.ScheduledTime()
+	if err != nil {
+		return 0, err
+	}
+	expected, err := e.ExpectedTime()
+	if err != nil {
+		return 0, err
+	}
+	return expected.Sub(scheduled), nil
+}
+
+// Departures returns the departure board of a stop.
+func (c *Client) Departures(ctx context.Context, req *BoardRequest) (*DepartureBoard, error) {
+	resp, err := get[DepartureBoard](ctx, c, "/departureBoard", req.params())
+	if err != nil {
+		return nil, err
+	}
+	if resp.ErrorCode != "" {
+		return nil, &ResponseError{Code: resp.ErrorCode, Text: resp.ErrorText}
+	}
+	return resp, nil
+}
+
+// Arrivals returns the arrival board of a stop.
+func (c *Client) Arrivals(ctx context.Context, req *BoardRequest) (*ArrivalBoard, error) {
+	resp, err := get[ArrivalBoard](ctx, c, "/arrivalBoard", req.params())
+	if err != nil {
+		return nil, err
+	}
+	if resp.ErrorCode != "" {
+		return nil, &ResponseError{Code: resp.ErrorCode, Text: resp.ErrorText}
+	}
+	return resp, nil
+}
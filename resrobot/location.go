@@ -0,0 +1,165 @@
+package resrobot
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/nobina/go-trafiklab/geo"
+	"github.com/nobina/go-trafiklab/slidentifiers"
+)
+
+type NearbyStopsRequest struct {
+	Lat  float64
+	Long float64
+	// Radius in meters, at most 10000. Zero uses the API default of 1000.
+	Radius int
+	// MaxNo caps the number of stops, at most 1000.
+	MaxNo    int
+	Products []ProductRef
+}
+
+// Validate checks that the coordinate is a WGS84 coordinate in Sweden,
+// and not swapped.
+func (r NearbyStopsRequest) Validate() error {
+	return geo.ValidateCoordinate(r.Lat, r.Long, geo.BoundsSweden)
+}
+
+func (r NearbyStopsRequest) params() url.Values {
+	params := url.Values{}
+	params.Set("originCoordLat", formatCoord(r.Lat))
+	params.Set("originCoordLong", formatCoord(r.Long))
+	if r.Radius > 0 {
+		params.Set("r", strconv.Itoa(min(r.Radius, 10000)))
+	}
+	if r.MaxNo > 0 {
+		params.Set("maxNo", strconv.Itoa(min(r.MaxNo, 1000)))
+	}
+	if mask := productMask(r.Products); mask != 0 {
+		params.Set("products", strconv.Itoa(mask))
+	}
+	return params
+}
+
+type LocationResponse struct {
+	ErrorCode string          `json:"errorCode"`
+	ErrorText string          `json:"errorText"`
+	Locations []LocationEntry `json:"stopLocationOrCoordLocation"`
+}
+
+// LocationEntry holds either a stop or, for name searches, an address or
+// point of interest.
+type LocationEntry struct {
+	StopLocation  *StopLocation  `json:"StopLocation,omitempty"`
+	CoordLocation *CoordLocation `json:"CoordLocation,omitempty"`
+}
+
+type StopLocation struct {
+	ID     string  `json:"id"`
+	ExtID  string  `json:"extId"`
+	Name   string  `json:"name"`
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+	Weight int     `json:"weight"`
+	// Dist is the distance in meters, set for nearby searches.
+	Dist int `json:"dist"`
+	// Products is the product mask of the stop.
+	Products      int       `json:"products"`
+	ProductAtStop []Product `json:"productAtStop"`
+}
+
+// Serves reports whether any of products stops at s.
+func (s *StopLocation) Serves(products ...ProductRef) bool {
+	return s.Products&productMask(products) != 0
+}
+
+type CoordLocation struct {
+	ID   string  `json:"id"`
+	Name string  `json:"name"`
+	Type string  `json:"type"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+	Dist int     `json:"dist"`
+}
+
+// Stops returns the stops of the response, skipping other locations.
+func (r *LocationResponse) Stops() []*StopLocation {
+	var stops []*StopLocation
+	for _, l := range r.Locations {
+		if l.StopLocation != nil {
+			stops = append(stops, l.StopLocation)
+		}
+	}
+	return stops
+}
+
+// NearbyStops returns the stops around a coordinate, nearest first.
+func (c *Client) NearbyStops(ctx context.Context, req *NearbyStopsRequest) (*LocationResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	resp, err := get[LocationResponse](ctx, c, "/location.nearbystops", req.params())
+	if err != nil {
+		return nil, err
+	}
+	if resp.ErrorCode != "" {
+		return nil, &ResponseError{Code: resp.ErrorCode, Text: resp.ErrorText}
+	}
+	return resp, nil
+}
+
+// Location types for name searches.
+const (
+	LocationStops     = "S"
+	LocationAddresses = "A"
+	LocationPOIs      = "P"
+	LocationAll       = "ALL"
+)
+
+type NameRequest struct {
+	Input string
+	// Fuzzy matches Input as a prefix, for typeahead.
+	Fuzzy bool
+	// Type is one of the Location constants, stops by default.
+	Type     string
+	MaxNo    int
+	Products []ProductRef
+}
+
+func (r NameRequest) params() url.Values {
+	params := url.Values{}
+	input := r.Input
+	if r.Fuzzy {
+		input += "?"
+	}
+	params.Set("input", input)
+	if r.Type != "" {
+		params.Set("type", r.Type)
+	} else {
+		params.Set("type", LocationStops)
+	}
+	if r.MaxNo > 0 {
+		params.Set("maxNo", strconv.Itoa(min(r.MaxNo, 1000)))
+	}
+	if mask := productMask(r.Products); mask != 0 {
+		params.Set("products", strconv.Itoa(mask))
+	}
+	return params
+}
+
+// SearchName searches locations by name.
+func (c *Client) SearchName(ctx context.Context, req *NameRequest) (*LocationResponse, error) {
+	resp, err := get[LocationResponse](ctx, c, "/location.name", req.params())
+	if err != nil {
+		return nil, err
+	}
+	if resp.ErrorCode != "" {
+		return nil, &ResponseError{Code: resp.ErrorCode, Text: resp.ErrorText}
+	}
+	return resp, nil
+}
+
+// Ref returns the stop as a StopRef, to be stored along SL stops.
+func (s *StopLocation) Ref() slidentifiers.StopRef {
+	return slidentifiers.StopRef{Kind: slidentifiers.KindResRobot, ID: s.ExtID}
+}
@@ -0,0 +1,140 @@
+// Package resrobot is a client for Samtrafiken's ResRobot v2.1 APIs, which
+// cover public transport in all of Sweden.
+package resrobot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/nobina/go-trafiklab/requests"
+)
+
+const DefaultBaseURL = "https://api.resrobot.se/v2.1"
+
+type Config struct {
+	BaseURL       string
+	APIKey        string
+	AllowInsecure requests.AllowInsecure
+}
+
+func (cfg *Config) Valid() error {
+	if cfg.BaseURL == "" {
+		return fmt.Errorf("missing base url")
+	}
+	if cfg.APIKey == "" {
+		return fmt.Errorf("missing api key")
+	}
+	return requests.ValidateBaseURL(cfg.BaseURL, cfg.AllowInsecure)
+}
+
+type Client struct {
+	httpClient *http.Client
+	apiKey     string
+	baseURL    string
+	lang       string
+}
+
+func NewClient(cfg *Config, client *http.Client, opts ...Option) *Client {
+	c := &Client{
+		httpClient: client,
+		apiKey:     cfg.APIKey,
+		baseURL:    cfg.BaseURL,
+		lang:       "sv",
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.httpClient = requests.WrapClient(c.httpClient, requests.UserAgent(""))
+
+	return c
+}
+
+type Option func(*Client)
+
+// WithLang sets the language of names and notes, "sv", "en" or "de".
+func WithLang(lang string) Option {
+	return func(c *Client) {
+		c.lang = lang
+	}
+}
+
+// ResponseError is returned when a response reports an error code, either
+// in a successful response or in the body of an error status.
+type ResponseError struct {
+	Code string `json:"errorCode"`
+	Text string `json:"errorText"`
+
+	// apiErr is the error of the status code, if any.
+	apiErr *requests.APIError
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("resrobot error %s: %s", e.Code, e.Text)
+}
+
+// Unwrap returns the requests.APIError of an error status, so errors.Is
+// works with its category.
+func (e *ResponseError) Unwrap() error {
+	if e.apiErr == nil {
+		return nil
+	}
+	return e.apiErr
+}
+
+// get requests endpoint with the key, language and json format added to
+// params.
+func get[T any](ctx context.Context, c *Client, endpoint string, params url.Values) (*T, error) {
+	params.Set("accessId", c.apiKey)
+	params.Set("format", "json")
+	if params.Get("lang") == "" {
+		params.Set("lang", c.lang)
+	}
+	resp, err := requests.GetJSON[T](ctx, c.httpClient, c.baseURL+endpoint, params)
+	if err != nil {
+		return nil, responseError(err)
+	}
+	return &resp, nil
+}
+
+// responseError decodes the error code in the body of an error status,
+// which ResRobot sends e.g. for invalid parameters or keys.
+func responseError(err error) error {
+	var apiErr *requests.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	var respErr ResponseError
+	if json.Unmarshal([]byte(apiErr.Snippet), &respErr) != nil || respErr.Code == "" {
+		return err
+	}
+	respErr.apiErr = apiErr
+	return &respErr
+}
+
+// ProductRef is a bit in the product mask of ResRobot.
+type ProductRef int
+
+const (
+	ProductHighSpeedTrain ProductRef = 2
+	ProductRegionalTrain  ProductRef = 4
+	ProductExpressBus     ProductRef = 8
+	ProductLocalTrain     ProductRef = 16
+	ProductMetro          ProductRef = 32
+	ProductTram           ProductRef = 64
+	ProductBus            ProductRef = 128
+	ProductFerry          ProductRef = 256
+)
+
+// productMask combines products, 0 meaning all.
+func productMask(products []ProductRef) int {
+	mask := 0
+	for _, p := range products {
+		mask |= int(p)
+	}
+	return mask
+}
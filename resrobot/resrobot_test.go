@@ -0,0 +1,101 @@
+package resrobot_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nobina/go-trafiklab/requests"
+	"github.com/nobina/go-trafiklab/resrobot"
+	"github.com/nobina/go-trafiklab/vcr"
+)
+
+func replayClient(t *testing.T) *resrobot.Client {
+	return resrobot.NewClient(&resrobot.Config{BaseURL: resrobot.DefaultBaseURL, APIKey: "key"},
+		vcr.Golden(t, "testdata/resrobot.json", http.DefaultClient, vcr.WithRedactedParams("accessId")))
+}
+
+func TestTripsReplay(t *testing.T) {
+	res, err := replayClient(t).Trips(context.Background(), &resrobot.TripRequest{OriginID: "740000001", DestID: "740000005"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Trips) != 1 {
+		t.Fatalf("got %d trips, want 1", len(res.Trips))
+	}
+	trip := res.Trips[0]
+	d, err := resrobot.ParseDuration(trip.Duration)
+	if err != nil || d != 38*time.Minute {
+		t.Fatalf("duration %q = %s, %v", trip.Duration, d, err)
+	}
+	legs := trip.Legs()
+	if len(legs) != 1 || legs[0].Type != resrobot.LegJourney || legs[0].Products[0].Line != "40" {
+		t.Fatalf("unexpected legs: %+v", legs)
+	}
+	planned, realtime, err := legs[0].Origin.Times()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if realtime.Sub(planned) != 2*time.Minute {
+		t.Fatalf("origin delay = %s, want 2m", realtime.Sub(planned))
+	}
+}
+
+func TestDeparturesReplay(t *testing.T) {
+	res, err := replayClient(t).Departures(context.Background(), &resrobot.BoardRequest{ID: "740000005", MaxJourneys: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Departures) != 1 {
+		t.Fatalf("got %d departures, want 1", len(res.Departures))
+	}
+	dep := res.Departures[0]
+	if dep.Product.Operator != "UL" || dep.Direction != "Uppsala Gottsunda centrum" {
+		t.Fatalf("unexpected departure: %+v", dep)
+	}
+	delay, err := dep.Delay()
+	if err != nil || delay != 2*time.Minute {
+		t.Fatalf("delay = %s, %v, want 2m", delay, err)
+	}
+}
+
+func TestNearbyStopsReplay(t *testing.T) {
+	res, err := replayClient(t).NearbyStops(context.Background(), &resrobot.NearbyStopsRequest{Lat: 59.33, Long: 18.059})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Locations) != 2 {
+		t.Fatalf("got %d locations, want 2", len(res.Locations))
+	}
+	stops := res.Stops()
+	if len(stops) != 1 || stops[0].ExtID != "740000001" || stops[0].Dist != 112 {
+		t.Fatalf("unexpected stops: %+v", stops)
+	}
+	if !stops[0].Serves(resrobot.ProductRegionalTrain) || stops[0].Serves(resrobot.ProductFerry) {
+		t.Fatalf("unexpected products of %s: %d", stops[0].Name, stops[0].Products)
+	}
+}
+
+func TestResponseErrorReplay(t *testing.T) {
+	_, err := replayClient(t).Trips(context.Background(), &resrobot.TripRequest{OriginID: "1", DestID: "740000005"})
+	var respErr *resrobot.ResponseError
+	if !errors.As(err, &respErr) || respErr.Code != "SVC_LOC" {
+		t.Fatalf("trip from an unknown stop = %v, want SVC_LOC", err)
+	}
+}
+
+func TestErrorStatusReplay(t *testing.T) {
+	client := resrobot.NewClient(&resrobot.Config{BaseURL: resrobot.DefaultBaseURL, APIKey: "key"},
+		vcr.Golden(t, "testdata/resrobot.json", http.DefaultClient, vcr.WithRedactedParams("accessId")),
+		resrobot.WithLang("xx"))
+	_, err := client.Trips(context.Background(), &resrobot.TripRequest{OriginID: "740000001", DestID: "740000005"})
+	var respErr *resrobot.ResponseError
+	if !errors.As(err, &respErr) || respErr.Code != "API_PARAM" {
+		t.Fatalf("trip with an invalid language = %v, want API_PARAM", err)
+	}
+	if !errors.Is(err, requests.ErrBadRequest) {
+		t.Fatalf("error %v is not a bad request", err)
+	}
+}
@@ -0,0 +1,237 @@
+package resrobot
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+type TripRequest struct {
+	OriginID        string
+	OriginCoordLat  float64
+	OriginCoordLong float64
+	DestID          string
+	DestCoordLat    float64
+	DestCoordLong   float64
+	ViaID           string
+	// Time is the departure time, or arrival time if SearchForArrival is
+	// set. Zero means now.
+	Time             time.Time
+	SearchForArrival bool
+	Products         []ProductRef
+	NumF             int
+	NumB             int
+	// Context is the scrF or scrB of a previous response, to page.
+	Context  string
+	Passlist bool
+}
+
+func (r TripRequest) params() url.Values {
+	params := url.Values{}
+	if r.OriginID != "" {
+		params.Set("originId", r.OriginID)
+	} else if r.OriginCoordLat != 0 || r.OriginCoordLong != 0 {
+		params.Set("originCoordLat", formatCoord(r.OriginCoordLat))
+		params.Set("originCoordLong", formatCoord(r.OriginCoordLong))
+	}
+	if r.DestID != "" {
+		params.Set("destId", r.DestID)
+	} else if r.DestCoordLat != 0 || r.DestCoordLong != 0 {
+		params.Set("destCoordLat", formatCoord(r.DestCoordLat))
+		params.Set("destCoordLong", formatCoord(r.DestCoordLong))
+	}
+	if r.ViaID != "" {
+		params.Set("viaId", r.ViaID)
+	}
+	if !r.Time.IsZero() {
+		t := r.Time.In(timeutils.EuropeStockholm())
+		params.Set("date", t.Format("2006-01-02"))
+		params.Set("time", t.Format("15:04"))
+	}
+	if r.SearchForArrival {
+		params.Set("searchForArrival", "1")
+	}
+	if mask := productMask(r.Products); mask != 0 {
+		params.Set("products", strconv.Itoa(mask))
+	}
+	if r.NumF > 0 {
+		params.Set("numF", strconv.Itoa(r.NumF))
+	}
+	if r.NumB > 0 {
+		params.Set("numB", strconv.Itoa(r.NumB))
+	}
+	if r.Context != "" {
+		params.Set("context", r.Context)
+	}
+	if r.Passlist {
+		params.Set("passlist", "1")
+	} else {
+		params.Set("passlist", "0")
+	}
+	return params
+}
+
+func formatCoord(f float64) string {
+	return strconv.FormatFloat(f, 'f', 6, 64)
+}
+
+type TripResponse struct {
+	ErrorCode string `json:"errorCode"`
+	ErrorText string `json:"errorText"`
+	Trips     []Trip `json:"Trip"`
+	ScrB      string `json:"scrB"`
+	ScrF      string `json:"scrF"`
+}
+
+type Trip struct {
+	Idx      int     `json:"idx"`
+	TripID   string  `json:"tripId"`
+	CtxRecon string  `json:"ctxRecon"`
+	Duration string  `json:"duration"`
+	Origin   Stop    `json:"Origin"`
+	Dest     Stop    `json:"Destination"`
+	LegList  LegList `json:"LegList"`
+}
+
+type LegList struct {
+	Legs []Leg `json:"Leg"`
+}
+
+// Legs returns the legs of the trip.
+func (t *Trip) Legs() []Leg {
+	return t.LegList.Legs
+}
+
+// Leg types.
+const (
+	LegJourney  = "JNY"
+	LegWalk     = "WALK"
+	LegTransfer = "TRSF"
+)
+
+type Leg struct {
+	Idx         string    `json:"idx"`
+	Type        string    `json:"type"`
+	Name        string    `json:"name"`
+	Direction   string    `json:"direction"`
+	Duration    string    `json:"duration"`
+	Dist        int       `json:"dist"`
+	Cancelled   bool      `json:"cancelled"`
+	Origin      Stop      `json:"Origin"`
+	Destination Stop      `json:"Destination"`
+	Products    []Product `json:"Product"`
+	Stops       *StopList `json:"Stops,omitempty"`
+}
+
+type StopList struct {
+	Stops []Stop `json:"Stop"`
+}
+
+type Product struct {
+	Name          string `json:"name"`
+	InternalName  string `json:"internalName"`
+	DisplayNumber string `json:"displayNumber"`
+	Num           string `json:"num"`
+	Line          string `json:"line"`
+	CatCode       string `json:"catCode"`
+	CatOut        string `json:"catOut"`
+	CatOutS       string `json:"catOutS"`
+	CatOutL       string `json:"catOutL"`
+	Operator      string `json:"operator"`
+	OperatorCode  string `json:"operatorCode"`
+	OperatorURL   string `json:"operatorUrl"`
+}
+
+// Stop is a stop of a trip or board. Dep and arr fields are only set on
+// stops of a passlist.
+type Stop struct {
+	Name     string  `json:"name"`
+	ID       string  `json:"id"`
+	ExtID    string  `json:"extId"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	RouteIdx int     `json:"routeIdx"`
+	Date     string  `json:"date"`
+	Time     string  `json:"time"`
+	RtDate   string  `json:"rtDate"`
+	RtTime   string  `json:"rtTime"`
+	Track    string  `json:"track"`
+	RtTrack  string  `json:"rtTrack"`
+	DepDate  string  `json:"depDate"`
+	DepTime  string  `json:"depTime"`
+	ArrDate  string  `json:"arrDate"`
+	ArrTime  string  `json:"arrTime"`
+}
+
+// Times returns the planned time of the stop and the realtime time,
+// falling back to the planned one.
+func (s Stop) Times() (planned, realtime time.Time, err error) {
+	date, clock := s.Date, s.Time
+	if date == "" {
+		date, clock = s.DepDate, s.DepTime
+	}
+	if date == "" {
+		date, clock = s.ArrDate, s.ArrTime
+	}
+	if date == "" || clock == "" {
+		return time.Time{}, time.Time{}, nil
+	}
+	planned, err = timeutils.Parse(date + " " + clock)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	realtime = planned
+	if s.RtDate != "" && s.RtTime != "" {
+		realtime, err = timeutils.Parse(s.RtDate + " " + s.RtTime)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	return planned, realtime, nil
+}
+
+// Trips searches for trips between two stops or coordinates.
+func (c *Client) Trips(ctx context.Context, req *TripRequest) (*TripResponse, error) {
+	resp, err := get[TripResponse](ctx, c, "/trip", req.params())
+	if err != nil {
+		return nil, err
+	}
+	if resp.ErrorCode != "" {
+		return nil, &ResponseError{Code: resp.ErrorCode, Text: resp.ErrorText}
+	}
+	return resp, nil
+}
+
+// ParseDuration parses the ISO 8601 durations used by ResRobot, e.g.
+// PT1H5M.
+func ParseDuration(s string) (time.Duration, error) {
+	rest, ok := strings.CutPrefix(s, "PT")
+	if !ok {
+		rest, ok = strings.CutPrefix(s, "P")
+		if !ok {
+			return 0, fmt.Errorf("invalid duration: %q", s)
+		}
+	}
+	rest = strings.Replace(rest, "T", "", 1)
+	rest = strings.ToLower(rest)
+	if days, after, found := strings.Cut(rest, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		d, err := ParseDuration("PT" + after)
+		if after == "" {
+			d, err = 0, nil
+		}
+		return time.Duration(n)*24*time.Hour + d, err
+	}
+	if rest == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(rest)
+}
@@ -0,0 +1,113 @@
+package deviations
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/nobina/go-trafiklab/requests"
+)
+
+type cacheEntry struct {
+	etag         string
+	lastModified string
+	hash         [sha256.Size]byte
+	deviations   []*DeviationsResponse
+}
+
+func (e *cacheEntry) setConditionalHeaders(req *http.Request) {
+	if e == nil {
+		return
+	}
+	if e.etag != "" {
+		req.Header.Set("If-None-Match", e.etag)
+	}
+	if e.lastModified != "" {
+		req.Header.Set("If-Modified-Since", e.lastModified)
+	}
+}
+
+// responseCache holds the latest decoded response per query string.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{
+		entries: map[string]*cacheEntry{},
+	}
+}
+
+func (rc *responseCache) get(key string) *cacheEntry {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.entries[key]
+}
+
+// decode reads a 200 response and only decodes it if the body differs from
+// the cached one, since the API doesn't always send validators.
+func (rc *responseCache) decode(key string, res *http.Response) ([]*DeviationsResponse, error) {
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	hash := sha256.Sum256(b)
+
+	entry := &cacheEntry{
+		etag:         res.Header.Get("ETag"),
+		lastModified: res.Header.Get("Last-Modified"),
+		hash:         hash,
+	}
+
+	if cached := rc.get(key); cached != nil && cached.hash == hash {
+		entry.deviations = cached.deviations
+	} else {
+		deviationsResp := []*DeviationsResponse{}
+		err = json.NewDecoder(bytes.NewReader(b)).Decode(&deviationsResp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		entry.deviations = deviationsResp
+	}
+
+	rc.mu.Lock()
+	rc.entries[key] = entry
+	rc.mu.Unlock()
+
+	return entry.deviations, nil
+}
+
+// revalidate requests deviations with conditional headers from the cached
+// response for q, returning the cached deviations if unchanged.
+func (c *Client) revalidate(ctx context.Context, endpoint string, q url.Values) ([]*DeviationsResponse, error) {
+	req, err := requests.JSON(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = q.Encode()
+
+	cached := c.cache.get(req.URL.RawQuery)
+	cached.setConditionalHeaders(req)
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed request: %w", err)
+	}
+	defer res.Body.Close()
+	c.inspect(res)
+
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		return cached.deviations, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, requests.NewAPIError(res)
+	}
+	return c.cache.decode(req.URL.RawQuery, res)
+}
@@ -0,0 +1,49 @@
+package deviations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/nobina/go-trafiklab/requests"
+)
+
+// ErrStop can be returned from an EachChunk callback to stop iterating
+// without EachChunk returning an error.
+var ErrStop = requests.ErrStop
+
+// EachChunk streams the deviations matching payload and calls fn with at
+// most size deviations at a time. The messages endpoint has no paging, so
+// the response is decoded element by element to keep memory bounded.
+func (c *Client) EachChunk(ctx context.Context, payload *DeviationsRequest, size int, fn func([]*DeviationsResponse) error) error {
+	if size <= 0 {
+		return fmt.Errorf("invalid chunk size: %d", size)
+	}
+	url := c.baseURL + "/v1/messages"
+	q := payload.params()
+
+	if c.isDebug {
+		log.Printf("url: %s\n", url+"?"+q.Encode())
+	}
+
+	chunk := make([]*DeviationsResponse, 0, size)
+	err := requests.StreamJSON(ctx, c.httpClient, url, q, func(d *DeviationsResponse) error {
+		chunk = append(chunk, d)
+		if len(chunk) < size {
+			return nil
+		}
+		err := fn(chunk)
+		chunk = make([]*DeviationsResponse, 0, size)
+		return err
+	}, requests.OnResponse(c.inspect))
+	if err != nil {
+		return err
+	}
+	if len(chunk) > 0 {
+		if err := fn(chunk); err != nil && !errors.Is(err, ErrStop) {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,33 +2,38 @@ package deviations
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"strconv"
 	"time"
 
+	"github.com/nobina/go-trafiklab/cache"
+	"github.com/nobina/go-trafiklab/metrics"
 	"github.com/nobina/go-trafiklab/requests"
 )
 
+// pingSiteID is T-Centralen.
+const pingSiteID = "9001"
+
 type Config struct {
-	BaseURL string
+	BaseURL       string
+	AllowInsecure requests.AllowInsecure
 }
 
 func (cfg *Config) Valid() error {
 	if cfg.BaseURL == "" {
 		return fmt.Errorf("missing base url")
 	}
-	return nil
+	return requests.ValidateBaseURL(cfg.BaseURL, cfg.AllowInsecure)
 }
 
 type Client struct {
 	httpClient *http.Client
 	baseURL    string
 	isDebug    bool
+	cache      *responseCache
 }
 
 func NewClient(cfg *Config, client *http.Client, opts ...Option) *Client {
@@ -40,6 +45,7 @@ func NewClient(cfg *Config, client *http.Client, opts ...Option) *Client {
 	for _, opt := range opts {
 		opt(c)
 	}
+	c.httpClient = requests.WrapClient(c.httpClient, requests.UserAgent(""))
 
 	return c
 }
@@ -52,45 +58,68 @@ func WithDebug() Option {
 	}
 }
 
-func (c *Client) Deviations(ctx context.Context, payload *DeviationsRequest) ([]*DeviationsResponse, error) {
-	url := c.baseURL + "/v1/messages"
+// WithMetrics reports the requests of the client to r, e.g. a
+// metrics.LatencyTracker.
+func WithMetrics(r metrics.Recorder) Option {
+	return func(c *Client) {
+		c.httpClient = requests.WrapClient(c.httpClient, metrics.Middleware(r))
+	}
+}
 
-	req, err := requests.JSON(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// WithResponseCache caches deviations responses in c for ttl. Unlike
+// WithCache, no request is made while the response is cached.
+func WithResponseCache(c cache.Cache, ttl time.Duration) Option {
+	return func(cl *Client) {
+		cl.httpClient = requests.WrapClient(cl.httpClient, cache.Middleware(c, ttl, nil))
 	}
+}
+
+// WithCache keeps the last response per query and revalidates it with
+// conditional requests, so unchanged deviations are not decoded again.
+func WithCache() Option {
+	return func(c *Client) {
+		c.cache = newResponseCache()
+	}
+}
+
+func (c *Client) Deviations(ctx context.Context, payload *DeviationsRequest) ([]*DeviationsResponse, error) {
+	url := c.baseURL + "/v1/messages"
 	q := payload.params()
-	req.URL.RawQuery = q.Encode()
 
 	if c.isDebug {
-		log.Printf("url: %s\n", url+"?"+req.URL.RawQuery)
+		log.Printf("url: %s\n", url+"?"+q.Encode())
 	}
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed request: %w", err)
+	if c.cache != nil {
+		return c.revalidate(ctx, url, q)
 	}
-	defer res.Body.Close()
+	return requests.GetJSON[[]*DeviationsResponse](ctx, c.httpClient, url, q, requests.OnResponse(c.inspect))
+}
+
+// Ping checks that the deviations API responds, using the deviations of a
+// single site.
+func (c *Client) Ping(ctx context.Context) error {
+	return requests.Ping(ctx, c.httpClient, c.baseURL+"/v1/messages", url.Values{"site": {pingSiteID}})
+}
+
+// Healthy reports whether Ping succeeds.
+func (c *Client) Healthy(ctx context.Context) bool {
+	return c.Ping(ctx) == nil
+}
+
+// inspect dumps res in debug mode and logs unexpected status codes.
+func (c *Client) inspect(res *http.Response) {
 	if c.isDebug {
-		b, err := httputil.DumpResponse(res, true)
+		b, err := requests.DumpResponse(res, 0)
 		if err != nil {
 			log.Printf("failed to dump response: %v", err)
 		}
 		log.Printf("%s\n", b)
 	}
-	if res.StatusCode != http.StatusOK {
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotModified {
 		log.Printf("unexpected status code: %d", res.StatusCode)
-		log.Printf("url: %s\n", url+"?"+req.URL.RawQuery)
-		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
+		log.Printf("url: %s\n", requests.RedactURL(res.Request.URL))
 	}
-	deviationsResp := []*DeviationsResponse{}
-
-	err = json.NewDecoder(res.Body).Decode(&deviationsResp)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return deviationsResp, nil
 }
 
 type DeviationsRequest struct {
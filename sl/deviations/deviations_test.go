@@ -0,0 +1,57 @@
+package deviations_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nobina/go-trafiklab/sl/deviations"
+	"github.com/nobina/go-trafiklab/vcr"
+)
+
+func replayClient(t *testing.T) *deviations.Client {
+	return deviations.NewClient(&deviations.Config{BaseURL: "https://deviations.integration.sl.se"},
+		vcr.Golden(t, "testdata/messages.json", http.DefaultClient))
+}
+
+func TestDeviationsReplay(t *testing.T) {
+	devs, err := replayClient(t).Deviations(context.Background(), &deviations.DeviationsRequest{
+		LineNumbers:    []int{17},
+		TransportModes: []string{"METRO"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devs) != 1 {
+		t.Fatalf("got %d deviations, want 1", len(devs))
+	}
+	d := devs[0]
+	if d.DeviationCaseID != 9871234 || d.Priority.ImportanceLevel != 5 {
+		t.Fatalf("unexpected deviation: %+v", d)
+	}
+	if len(d.MessageVariants) != 2 || d.MessageVariants[1].Language != "en" {
+		t.Fatalf("unexpected message variants: %+v", d.MessageVariants)
+	}
+	if len(d.Scope.Lines) != 1 || d.Scope.Lines[0].Designation != "17" || len(d.Scope.StopAreas[0].StopPoints) != 1 {
+		t.Fatalf("unexpected scope: %+v", d.Scope)
+	}
+	if want := time.Date(2024, 5, 20, 2, 0, 0, 0, time.UTC); !d.Publish.Upto.Equal(want) {
+		t.Fatalf("publish upto = %s, want %s", d.Publish.Upto, want)
+	}
+}
+
+func TestPlannedWorksReplay(t *testing.T) {
+	req := &deviations.DeviationsRequest{LineNumbers: []int{17}, TransportModes: []string{"METRO"}}
+	before := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	devs, err := replayClient(t).PlannedWorks(context.Background(), req, before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devs) != 1 {
+		t.Fatalf("got %d planned works before the publish window, want 1", len(devs))
+	}
+	if req.Future {
+		t.Fatal("PlannedWorks modified the request")
+	}
+}
@@ -0,0 +1,36 @@
+package deviations_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/nobina/go-trafiklab/sl/deviations"
+	"github.com/nobina/go-trafiklab/sl/transport"
+)
+
+// The deviations of Waxholmsbolaget's boats, with the authority id looked
+// up in the transport API.
+func ExampleClient_Deviations_waxholmsbolaget() {
+	ctx := context.Background()
+	transportClient := transport.NewClient(&transport.Config{BaseURL: "https://transport.integration.sl.se"}, http.DefaultClient)
+	client := deviations.NewClient(&deviations.Config{BaseURL: "https://deviations.integration.sl.se"}, http.DefaultClient)
+
+	authority, err := transportClient.FindTransportAuthority(ctx, "waxholm")
+	if err != nil {
+		log.Fatal(err)
+	}
+	res, err := client.Deviations(ctx, &deviations.DeviationsRequest{
+		TransportAuthority: authority.ID,
+		TransportModes:     []string{transport.TransportModeShip},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, d := range res {
+		for _, m := range d.MessageVariants {
+			fmt.Println(m.Header)
+		}
+	}
+}
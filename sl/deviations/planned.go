@@ -0,0 +1,26 @@
+package deviations
+
+import (
+	"context"
+	"time"
+)
+
+// PlannedWorks returns the deviations published ahead of their start that
+// haven't started at now, such as planned engineering works. SL has no
+// separate planned works feed; they are deviations requested with Future
+// whose publish window lies ahead.
+func (c *Client) PlannedWorks(ctx context.Context, payload *DeviationsRequest, now time.Time) ([]*DeviationsResponse, error) {
+	req := *payload
+	req.Future = true
+	devs, err := c.Deviations(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+	var planned []*DeviationsResponse
+	for _, d := range devs {
+		if d.Publish.From.After(now) {
+			planned = append(planned, d)
+		}
+	}
+	return planned, nil
+}
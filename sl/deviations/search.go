@@ -0,0 +1,86 @@
+package deviations
+
+import (
+	"strings"
+
+	"github.com/nobina/go-trafiklab/internal/normalize"
+)
+
+// Index is an in-memory search index over fetched deviations. It matches
+// headers, details and scope names in every language variant.
+type Index struct {
+	entries []indexEntry
+}
+
+type indexEntry struct {
+	deviation *DeviationsResponse
+	text      string
+}
+
+func NewIndex(deviations []*DeviationsResponse) *Index {
+	idx := &Index{}
+	for _, d := range deviations {
+		idx.Add(d)
+	}
+	return idx
+}
+
+func (idx *Index) Add(d *DeviationsResponse) {
+	if d == nil {
+		return
+	}
+	var b strings.Builder
+	for _, v := range d.MessageVariants {
+		b.WriteString(v.Header)
+		b.WriteByte(' ')
+		b.WriteString(v.Details)
+		b.WriteByte(' ')
+		b.WriteString(v.ScopeAlias)
+		b.WriteByte(' ')
+	}
+	for _, sa := range d.Scope.StopAreas {
+		b.WriteString(sa.Name)
+		b.WriteByte(' ')
+		for _, sp := range sa.StopPoints {
+			b.WriteString(sp.Name)
+			b.WriteByte(' ')
+		}
+	}
+	for _, l := range d.Scope.Lines {
+		b.WriteString(l.Name)
+		b.WriteByte(' ')
+		b.WriteString(l.Designation)
+		b.WriteByte(' ')
+		b.WriteString(l.GroupOfLines)
+		b.WriteByte(' ')
+	}
+
+	idx.entries = append(idx.entries, indexEntry{
+		deviation: d,
+		text:      normalize.Fold(b.String()),
+	})
+}
+
+// Search returns the deviations containing every word in query, ignoring
+// case and diacritics, in the order they were added.
+func (idx *Index) Search(query string) []*DeviationsResponse {
+	terms := strings.Fields(normalize.Fold(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var res []*DeviationsResponse
+	for _, e := range idx.entries {
+		match := true
+		for _, t := range terms {
+			if !strings.Contains(e.text, t) {
+				match = false
+				break
+			}
+		}
+		if match {
+			res = append(res, e.deviation)
+		}
+	}
+	return res
+}
@@ -0,0 +1,197 @@
+package networkstatus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nobina/go-trafiklab/alerts"
+	"github.com/nobina/go-trafiklab/sl/deviations"
+	"github.com/nobina/go-trafiklab/sl/trafficstatus"
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+type NetworkStatus struct {
+	Modes map[string]*ModeHealth
+}
+
+type ModeHealth struct {
+	Mode     string
+	Severity trafficstatus.Severity
+	Events   []trafficstatus.Event
+	Lines    map[string]*LineHealth
+}
+
+type LineHealth struct {
+	Mode        string
+	Designation string
+	Severity    trafficstatus.Severity
+	Events      []trafficstatus.Event
+	Deviations  []*deviations.DeviationsResponse
+}
+
+func (h *LineHealth) OK() bool {
+	return !h.Severity.Disrupted()
+}
+
+// Line returns the health of the line with designation, e.g. "14". Lines
+// not mentioned by any event or deviation are not found.
+func (n *NetworkStatus) Line(designation string) (*LineHealth, bool) {
+	for _, m := range n.Modes {
+		if l, ok := m.Lines[designation]; ok {
+			return l, true
+		}
+	}
+	return nil, false
+}
+
+func (n *NetworkStatus) Mode(mode string) (*ModeHealth, bool) {
+	m, ok := n.Modes[strings.ToUpper(mode)]
+	return m, ok
+}
+
+func (n *NetworkStatus) mode(mode string) *ModeHealth {
+	m, ok := n.Modes[mode]
+	if !ok {
+		m = &ModeHealth{
+			Mode:     mode,
+			Severity: trafficstatus.SeverityGood,
+			Lines:    map[string]*LineHealth{},
+		}
+		n.Modes[mode] = m
+	}
+	return m
+}
+
+func (m *ModeHealth) line(designation string) *LineHealth {
+	l, ok := m.Lines[designation]
+	if !ok {
+		l = &LineHealth{
+			Mode:        m.Mode,
+			Designation: designation,
+			Severity:    trafficstatus.SeverityGood,
+		}
+		m.Lines[designation] = l
+	}
+	return l
+}
+
+// Combine merges a traffic status response with deviations. Only
+// deviations published at now are included.
+func Combine(status *trafficstatus.TrafficStatusResponse, devs []*deviations.DeviationsResponse, now time.Time) *NetworkStatus {
+	n := &NetworkStatus{
+		Modes: map[string]*ModeHealth{},
+	}
+
+	if status != nil {
+		for _, st := range status.ResponseData.TrafficTypes {
+			m := n.mode(st.TransportMode())
+			// Several traffic types can map to the same mode.
+			if s := st.WorstSeverity(); s.WorseThan(m.Severity) {
+				m.Severity = s
+			}
+			m.Events = append(m.Events, st.Events...)
+			for _, e := range st.Events {
+				for _, designation := range e.Lines() {
+					l := m.line(designation)
+					l.Events = append(l.Events, e)
+					if e.Severity.WorseThan(l.Severity) {
+						l.Severity = e.Severity
+					}
+				}
+			}
+		}
+	}
+
+	for _, d := range devs {
+		if !published(d, now) {
+			continue
+		}
+		severity := trafficstatus.SeverityMinor
+		if d.Priority.ImportanceLevel >= alerts.SevereImportanceLevel {
+			severity = trafficstatus.SeverityMajor
+		}
+		for _, dl := range d.Scope.Lines {
+			m := n.mode(dl.TransportMode)
+			l := m.line(dl.Designation)
+			l.Deviations = append(l.Deviations, d)
+			if severity.WorseThan(l.Severity) {
+				l.Severity = severity
+			}
+			if severity.WorseThan(m.Severity) {
+				m.Severity = severity
+			}
+		}
+	}
+
+	return n
+}
+
+func published(d *deviations.DeviationsResponse, now time.Time) bool {
+	if !d.Publish.From.IsZero() && now.Before(d.Publish.From) {
+		return false
+	}
+	if !d.Publish.Upto.IsZero() && now.After(d.Publish.Upto) {
+		return false
+	}
+	return true
+}
+
+// Client fetches traffic status and deviations and combines them.
+type Client struct {
+	trafficStatus *trafficstatus.Client
+	deviations    *deviations.Client
+	clock         timeutils.Clock
+}
+
+func NewClient(trafficStatus *trafficstatus.Client, deviations *deviations.Client, opts ...Option) *Client {
+	c := &Client{
+		trafficStatus: trafficStatus,
+		deviations:    deviations,
+		clock:         timeutils.SystemClock,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+type Option func(*Client)
+
+// WithClock sets the clock deciding which deviations are active.
+func WithClock(clock timeutils.Clock) Option {
+	return func(c *Client) {
+		c.clock = clock
+	}
+}
+
+func (c *Client) NetworkStatus(ctx context.Context) (*NetworkStatus, error) {
+	status, err := c.trafficStatus.TrafficStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get traffic status: %w", err)
+	}
+	devs, err := c.deviations.Deviations(ctx, &deviations.DeviationsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deviations: %w", err)
+	}
+	return Combine(status, devs, c.clock.Now()), nil
+}
+
+// Ping checks that both the traffic status and deviations APIs respond.
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.trafficStatus.Ping(ctx); err != nil {
+		return fmt.Errorf("traffic status: %w", err)
+	}
+	if err := c.deviations.Ping(ctx); err != nil {
+		return fmt.Errorf("deviations: %w", err)
+	}
+	return nil
+}
+
+// Healthy reports whether Ping succeeds.
+func (c *Client) Healthy(ctx context.Context) bool {
+	return c.Ping(ctx) == nil
+}
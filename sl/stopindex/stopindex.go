@@ -0,0 +1,281 @@
+package stopindex
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/nobina/go-trafiklab/geo"
+	"github.com/nobina/go-trafiklab/internal/normalize"
+	"github.com/nobina/go-trafiklab/sl/transport"
+)
+
+// cellSize is the size in degrees of the grid used for nearby lookups,
+// roughly 1 km north-south around Stockholm.
+const cellSize = 0.01
+
+type Site struct {
+	ID      int
+	GID     int64
+	Name    string
+	Aliases []string
+	Lat     float64
+	Lon     float64
+}
+
+type cell struct {
+	lat int
+	lon int
+}
+
+// Index serves typeahead and nearby queries over a complete site list
+// without calling the API. It is safe for concurrent reads.
+type Index struct {
+	sites []Site
+	names [][]string
+	cells map[cell][]int
+	byGID map[int64]int
+}
+
+func New(sites []Site) *Index {
+	idx := &Index{
+		sites: sites,
+		names: make([][]string, len(sites)),
+		cells: map[cell][]int{},
+		byGID: map[int64]int{},
+	}
+	for i, s := range sites {
+		names := []string{strings.TrimSpace(normalize.Fold(s.Name))}
+		for _, a := range s.Aliases {
+			names = append(names, strings.TrimSpace(normalize.Fold(a)))
+		}
+		idx.names[i] = names
+
+		if s.GID != 0 {
+			idx.byGID[s.GID] = i
+		}
+
+		k := cellFor(s.Lat, s.Lon)
+		idx.cells[k] = append(idx.cells[k], i)
+	}
+	return idx
+}
+
+// Load downloads the full site list and builds an index from it.
+func Load(ctx context.Context, client *transport.Client) (*Index, error) {
+	sites, err := client.Sites(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sites: %w", err)
+	}
+	return FromSites(sites), nil
+}
+
+func FromSites(sites []*transport.Site) *Index {
+	s := make([]Site, 0, len(sites))
+	for _, site := range sites {
+		s = append(s, Site{
+			ID:      site.ID,
+			GID:     site.GID,
+			Name:    site.Name,
+			Aliases: site.Alias,
+			Lat:     site.Lat,
+			Lon:     site.Lon,
+		})
+	}
+	return New(s)
+}
+
+// Site returns the site with gid.
+func (idx *Index) Site(gid int64) (Site, bool) {
+	i, ok := idx.byGID[gid]
+	if !ok {
+		return Site{}, false
+	}
+	return idx.sites[i], true
+}
+
+func (idx *Index) Len() int {
+	return len(idx.sites)
+}
+
+func cellFor(lat, lon float64) cell {
+	return cell{
+		lat: int(math.Floor(lat / cellSize)),
+		lon: int(math.Floor(lon / cellSize)),
+	}
+}
+
+type Match struct {
+	Site  Site
+	Score int
+}
+
+// Search returns at most max sites matching query, best match first. Exact
+// names score 0, prefixes 1, word prefixes 2, substrings 3 and names within
+// a small edit distance of the query 4 and up.
+func (idx *Index) Search(query string, max int) []Match {
+	q := strings.TrimSpace(normalize.Fold(query))
+	if q == "" {
+		return nil
+	}
+
+	matches := []Match{}
+	for i, names := range idx.names {
+		best := -1
+		for _, name := range names {
+			score := matchScore(q, name)
+			if score >= 0 && (best < 0 || score < best) {
+				best = score
+			}
+		}
+		if best >= 0 {
+			matches = append(matches, Match{Site: idx.sites[i], Score: best})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score < matches[j].Score
+		}
+		return len(matches[i].Site.Name) < len(matches[j].Site.Name)
+	})
+	if max > 0 && len(matches) > max {
+		matches = matches[:max]
+	}
+	return matches
+}
+
+func matchScore(q, name string) int {
+	switch {
+	case name == q:
+		return 0
+	case strings.HasPrefix(name, q):
+		return 1
+	case strings.Contains(name, " "+q):
+		return 2
+	case strings.Contains(name, q):
+		return 3
+	}
+
+	// allow a typo per four characters, compared against the start of the
+	// name so partially typed queries still match
+	qr := []rune(q)
+	allowed := len(qr) / 4
+	if allowed == 0 {
+		return -1
+	}
+	nr := []rune(name)
+	best := -1
+	for l := len(qr) - allowed; l <= len(qr)+allowed && l <= len(nr); l++ {
+		if d := levenshtein(qr, nr[:l]); d <= allowed && (best < 0 || d < best) {
+			best = d
+		}
+	}
+	if best < 0 {
+		return -1
+	}
+	return 3 + best
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+type NearbySite struct {
+	Site     Site
+	Distance float64
+}
+
+// Nearby returns at most max sites within radius meters of lat/lon,
+// nearest first.
+func (idx *Index) Nearby(lat, lon, radius float64, max int) []NearbySite {
+	if radius <= 0 {
+		return nil
+	}
+	dLat := radius / 111320
+	dLon := radius / (111320 * math.Cos(lat*math.Pi/180))
+	from := cellFor(lat-dLat, lon-dLon)
+	to := cellFor(lat+dLat, lon+dLon)
+
+	nearby := []NearbySite{}
+	for cLat := from.lat; cLat <= to.lat; cLat++ {
+		for cLon := from.lon; cLon <= to.lon; cLon++ {
+			for _, i := range idx.cells[cell{lat: cLat, lon: cLon}] {
+				s := idx.sites[i]
+				d := geo.Distance(lat, lon, s.Lat, s.Lon)
+				if d <= radius {
+					nearby = append(nearby, NearbySite{Site: s, Distance: d})
+				}
+			}
+		}
+	}
+
+	sort.Slice(nearby, func(i, j int) bool {
+		return nearby[i].Distance < nearby[j].Distance
+	})
+	if max > 0 && len(nearby) > max {
+		nearby = nearby[:max]
+	}
+	return nearby
+}
+
+// StopsInBounds returns the sites within the bounding box, inclusive, in
+// the order they were indexed. It is meant for map views, where the box is
+// the visible area.
+func (idx *Index) StopsInBounds(minLat, minLng, maxLat, maxLng float64) []Site {
+	if minLat > maxLat || minLng > maxLng {
+		return nil
+	}
+	inBounds := func(s Site) bool {
+		return s.Lat >= minLat && s.Lat <= maxLat && s.Lon >= minLng && s.Lon <= maxLng
+	}
+
+	from := cellFor(minLat, minLng)
+	to := cellFor(maxLat, maxLng)
+	cells := (to.lat - from.lat + 1) * (to.lon - from.lon + 1)
+	// Zoomed out boxes cover more cells than there are sites.
+	if cells > len(idx.sites) {
+		sites := []Site{}
+		for _, s := range idx.sites {
+			if inBounds(s) {
+				sites = append(sites, s)
+			}
+		}
+		return sites
+	}
+
+	matches := []int{}
+	for cLat := from.lat; cLat <= to.lat; cLat++ {
+		for cLon := from.lon; cLon <= to.lon; cLon++ {
+			for _, i := range idx.cells[cell{lat: cLat, lon: cLon}] {
+				if inBounds(idx.sites[i]) {
+					matches = append(matches, i)
+				}
+			}
+		}
+	}
+	sort.Ints(matches)
+	sites := make([]Site, 0, len(matches))
+	for _, i := range matches {
+		sites = append(sites, idx.sites[i])
+	}
+	return sites
+}
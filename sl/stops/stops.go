@@ -2,16 +2,24 @@ package stops
 
 import (
 	"context"
-	"encoding/xml"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/nobina/go-trafiklab/cache"
+	"github.com/nobina/go-trafiklab/geo"
+	"github.com/nobina/go-trafiklab/metrics"
+	"github.com/nobina/go-trafiklab/requests"
 )
 
 type Config struct {
-	APIKey  string
-	BaseURL string
+	APIKey        string
+	BaseURL       string
+	AllowInsecure requests.AllowInsecure
 }
 
 func (cfg *Config) Valid() error {
@@ -21,53 +29,159 @@ func (cfg *Config) Valid() error {
 	if cfg.BaseURL == "" {
 		return errors.New("missing base url")
 	}
-	return nil
+	return requests.ValidateBaseURL(cfg.BaseURL, cfg.AllowInsecure)
 }
 
+type Format string
+
+const (
+	FormatXML  Format = "xml"
+	FormatJSON Format = "json"
+)
+
 type Client struct {
 	httpClient *http.Client
 	apiKey     string
 	baseURL    string
+	format     Format
+	logger     requests.Logger
 }
 
-func NewClient(cfg *Config, client *http.Client) *Client {
-	return &Client{
+func NewClient(cfg *Config, client *http.Client, opts ...Option) *Client {
+	c := &Client{
 		httpClient: client,
 		apiKey:     cfg.APIKey,
 		baseURL:    cfg.BaseURL,
+		format:     FormatXML,
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.httpClient = requests.WrapClient(c.httpClient, requests.UserAgent(""))
+
+	return c
 }
 
-func (c *Client) Query(ctx context.Context, payload *StopsQueryRequest) (*TypeaheadResponse, error) {
-	payload.key = c.apiKey
-	url := c.baseURL + "/v1/typeahead.xml"
+type Option func(*Client)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// WithMetrics reports the requests of the client to r, e.g. a
+// metrics.LatencyTracker.
+func WithMetrics(r metrics.Recorder) Option {
+	return func(c *Client) {
+		c.httpClient = requests.WrapClient(c.httpClient, metrics.Middleware(r))
 	}
-	q := payload.params()
-	req.URL.RawQuery = q.Encode()
+}
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed request: %w", err)
+// WithResponseCache caches typeahead responses in c for ttl.
+func WithResponseCache(c cache.Cache, ttl time.Duration) Option {
+	return func(cl *Client) {
+		cl.httpClient = requests.WrapClient(cl.httpClient, cache.Middleware(c, ttl, nil))
 	}
-	defer res.Body.Close()
+}
+
+// WithLogger logs failed requests to logger.
+func WithLogger(logger requests.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
 
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d, response: %v, for url: %s", res.StatusCode, res, url+req.URL.RawQuery)
+// WithKeyPool rotates between the keys in pool instead of using the
+// configured api key.
+func WithKeyPool(pool *requests.KeyPool) Option {
+	return func(c *Client) {
+		c.httpClient = requests.WrapClient(c.httpClient, requests.RotateKeys(pool, "key"))
 	}
+}
+
+func (c *Client) logf(format string, v ...any) {
+	if c.logger != nil {
+		c.logger.Printf(format, v...)
+	}
+}
+
+// WithFormat selects which typeahead response format to request.
+func WithFormat(format Format) Option {
+	return func(c *Client) {
+		c.format = format
+	}
+}
+
+// ResponseError is returned when the response envelope reports a non zero
+// status code.
+type ResponseError struct {
+	StatusCode int32
+	Message    string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("typeahead error %d: %s", e.StatusCode, e.Message)
+}
+
+func (c *Client) Query(ctx context.Context, payload *StopsQueryRequest) (*TypeaheadResponse, error) {
+	payload.key = c.apiKey
+	url := c.baseURL + "/v1/typeahead." + string(c.format)
+
+	q := payload.params()
 
-	queryResp := &TypeaheadResponse{}
-	err = xml.NewDecoder(res.Body).Decode(queryResp)
+	var queryResp *TypeaheadResponse
+	var err error
+	switch c.format {
+	case FormatJSON:
+		var jsonResp typeaheadJSONResponse
+		jsonResp, err = requests.GetJSON[typeaheadJSONResponse](ctx, c.httpClient, url, q)
+		queryResp = jsonResp.typeaheadResponse()
+	default:
+		var xmlResp TypeaheadResponse
+		xmlResp, err = requests.GetXML[TypeaheadResponse](ctx, c.httpClient, url, q)
+		queryResp = &xmlResp
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w, for url: %s", err, url+req.URL.RawQuery)
+		c.logf("typeahead request failed: %v", err)
+		return nil, err
+	}
+	if queryResp.StatusCode != 0 {
+		c.logf("typeahead error %d: %s", queryResp.StatusCode, queryResp.Message)
+		return nil, &ResponseError{
+			StatusCode: queryResp.StatusCode,
+			Message:    queryResp.Message,
+		}
+	}
+	if payload.SWEREF99TM {
+		queryResp.addSWEREF99TM()
 	}
 
 	return queryResp, nil
 }
 
+// addSWEREF99TM converts the coordinates of the stops, leaving those that
+// can't be parsed at zero.
+func (r *TypeaheadResponse) addSWEREF99TM() {
+	for i := range r.Data {
+		northing, easting, err := r.Data[i].SWEREF99TM()
+		if err != nil {
+			continue
+		}
+		r.Data[i].Northing, r.Data[i].Easting = northing, easting
+	}
+}
+
+// Ping checks that the typeahead API responds and accepts the key, asking
+// for a single stop. The API answers a rejected key with 200 and a status
+// code in the body, so the response is decoded like Query's.
+func (c *Client) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, requests.DefaultPingTimeout)
+	defer cancel()
+	_, err := c.Query(ctx, &StopsQueryRequest{SearchString: "T-Centralen", MaxResults: "1"})
+	return err
+}
+
+// Healthy reports whether Ping succeeds.
+func (c *Client) Healthy(ctx context.Context) bool {
+	return c.Ping(ctx) == nil
+}
+
 type StopsQueryRequest struct {
 	key string
 
@@ -75,6 +189,9 @@ type StopsQueryRequest struct {
 	StationsOnly bool
 	MaxResults   string
 	Type         string
+	// SWEREF99TM sets Northing and Easting on the stops. The API only
+	// returns WGS84, so they are converted locally.
+	SWEREF99TM bool
 }
 
 func (r StopsQueryRequest) params() url.Values {
@@ -112,4 +229,80 @@ type TypeaheadStop struct {
 	Type   string `json:"type"`
 	X      string `json:"x"`
 	Y      string `json:"y"`
+	// Northing and Easting are set if requested with SWEREF99TM.
+	Northing float64 `json:"northing,omitempty" xml:"-"`
+	Easting  float64 `json:"easting,omitempty" xml:"-"`
+}
+
+type typeaheadJSONResponse struct {
+	StatusCode    int32               `json:"StatusCode"`
+	Message       string              `json:"Message"`
+	ExecutionTime int64               `json:"ExecutionTime"`
+	ResponseData  []typeaheadJSONStop `json:"ResponseData"`
+}
+
+// typeaheadJSONStop accepts coordinates both as numbers and as quoted
+// numbers, which is what the API currently sends.
+type typeaheadJSONStop struct {
+	Name   string      `json:"Name"`
+	SiteID string      `json:"SiteId"`
+	Type   string      `json:"Type"`
+	X      json.Number `json:"X"`
+	Y      json.Number `json:"Y"`
+}
+
+func (r *typeaheadJSONResponse) typeaheadResponse() *TypeaheadResponse {
+	resp := &TypeaheadResponse{
+		StatusCode:    r.StatusCode,
+		Message:       r.Message,
+		ExecutionTime: r.ExecutionTime,
+	}
+	for _, s := range r.ResponseData {
+		resp.Data = append(resp.Data, TypeaheadStop{
+			Name:   s.Name,
+			SiteID: s.SiteID,
+			Type:   s.Type,
+			X:      s.X.String(),
+			Y:      s.Y.String(),
+		})
+	}
+	return resp
+}
+
+// Coordinates parses the raw X and Y values.
+func (s TypeaheadStop) Coordinates() (x, y float64, err error) {
+	x, err = strconv.ParseFloat(s.X, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse x: %w", err)
+	}
+	y, err = strconv.ParseFloat(s.Y, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse y: %w", err)
+	}
+	return x, y, nil
+}
+
+// LatLng converts the coordinates to WGS84. X and Y are longitude and
+// latitude in degrees multiplied by 1e6.
+func (s TypeaheadStop) LatLng() (lat, lng float64, err error) {
+	x, y, err := s.Coordinates()
+	if err != nil {
+		return 0, 0, err
+	}
+	lat, lng = y/1e6, x/1e6
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return 0, 0, fmt.Errorf("coordinates out of range: %s, %s", s.X, s.Y)
+	}
+	return lat, lng, nil
+}
+
+// SWEREF99TM converts the coordinates to SWEREF 99 TM, as used by Swedish
+// GIS systems.
+func (s TypeaheadStop) SWEREF99TM() (northing, easting float64, err error) {
+	lat, lng, err := s.LatLng()
+	if err != nil {
+		return 0, 0, err
+	}
+	northing, easting = geo.WGS84ToSWEREF99TM(lat, lng)
+	return northing, easting, nil
 }
@@ -0,0 +1,167 @@
+package stopsnearby
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/nobina/go-trafiklab/metrics"
+	"github.com/nobina/go-trafiklab/requests"
+)
+
+type Client struct {
+	httpClient *http.Client
+	apiKey     string
+	baseURL    string
+	isDebug    bool
+	convertID  IDConverter
+	keepFailed bool
+}
+
+func NewClient(cfg *Config, client *http.Client, opts ...Option) *Client {
+	c := &Client{
+		httpClient: client,
+		apiKey:     cfg.APIKey,
+		baseURL:    cfg.BaseURL,
+		convertID:  EFAConverter(DefaultEFAPrefix),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.httpClient = requests.WrapClient(c.httpClient, requests.UserAgent(""))
+
+	return c
+}
+
+type Option func(*Client)
+
+func WithDebug() Option {
+	return func(c *Client) {
+		c.isDebug = true
+	}
+}
+
+// WithMetrics reports the requests of the client to r, e.g. a
+// metrics.LatencyTracker.
+func WithMetrics(r metrics.Recorder) Option {
+	return func(c *Client) {
+		c.httpClient = requests.WrapClient(c.httpClient, metrics.Middleware(r))
+	}
+}
+
+// WithKeyPool rotates between the keys in pool instead of using the
+// configured api key.
+func WithKeyPool(pool *requests.KeyPool) Option {
+	return func(c *Client) {
+		c.httpClient = requests.WrapClient(c.httpClient, requests.RotateKeys(pool, "key"))
+	}
+}
+
+// Ping checks that the nearby stops API responds and accepts the key,
+// asking for a single stop.
+func (c *Client) Ping(ctx context.Context) error {
+	q := StopsNearbyRequest{OriginCoordLat: "59.331", OriginCoordLong: "18.060", MaxNo: "1"}.params()
+	q.Set("key", c.apiKey)
+	return requests.Ping(ctx, c.httpClient, c.baseURL+"/nearbystopsv2.json", q)
+}
+
+// Healthy reports whether Ping succeeds.
+func (c *Client) Healthy(ctx context.Context) bool {
+	return c.Ping(ctx) == nil
+}
+
+// Nearby queries the JSON variant of the nearby stops API.
+func (c *Client) Nearby(ctx context.Context, payload *StopsNearbyRequest) (*NearbyResponse, error) {
+	nearbyResp, err := c.fetch(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	if nearbyResp.ErrorCode != "" {
+		return nil, fmt.Errorf("api error: %s: %s", nearbyResp.ErrorCode, nearbyResp.ErrorText)
+	}
+
+	mask := payload.productMask()
+	locations := []NearbyLocations{}
+	for _, l := range nearbyResp.Locations {
+		if l.StopLocation == nil {
+			locations = append(locations, l)
+			continue
+		}
+		if mask != 0 && !l.StopLocation.ServedBy(mask) {
+			continue
+		}
+		gid, err := c.convertID(l.StopLocation.ExtID)
+		if err != nil {
+			nearbyResp.Warnings = append(nearbyResp.Warnings, ConversionWarning{
+				ExtID: l.StopLocation.ExtID,
+				Name:  l.StopLocation.Name,
+				Kept:  c.keepFailed,
+				Err:   err,
+			})
+			if !c.keepFailed {
+				continue
+			}
+			gid = l.StopLocation.ExtID
+		}
+		l.StopLocation.GID = gid
+		locations = append(locations, l)
+	}
+	nearbyResp.Locations = locations
+
+	return &nearbyResp, nil
+}
+
+// fetch validates payload and decodes the response as is, leaving error
+// codes, product filtering and id conversion to the caller.
+func (c *Client) fetch(ctx context.Context, payload *StopsNearbyRequest) (NearbyResponse, error) {
+	if err := payload.Validate(); err != nil {
+		return NearbyResponse{}, err
+	}
+	url := c.baseURL + "/nearbystopsv2.json"
+
+	q := payload.params()
+	if c.isDebug {
+		log.Printf("url: %s\n", url+"?"+q.Encode())
+	}
+	q.Set("key", c.apiKey)
+
+	return requests.GetJSON[NearbyResponse](ctx, c.httpClient, url, q,
+		requests.OnResponse(func(res *http.Response) {
+			if !c.isDebug {
+				return
+			}
+			b, err := requests.DumpResponse(res, 0)
+			if err != nil {
+				log.Printf("failed to dump response: %v", err)
+			} else {
+				log.Printf("response: %s\n", b)
+			}
+		}))
+}
+
+type NearbyResponse struct {
+	ErrorCode string            `json:"errorCode"`
+	ErrorText string            `json:"errorText"`
+	Locations []NearbyLocations `json:"stopLocationOrCoordLocation"`
+
+	// Warnings lists the stops whose ids couldn't be converted.
+	Warnings []ConversionWarning `json:"-"`
+}
+
+type NearbyLocations struct {
+	StopLocation *StopLocation `json:"StopLocation"`
+}
+
+// Stops returns the stop locations in the response, skipping coordinate
+// only locations.
+func (r *NearbyResponse) Stops() []StopLocation {
+	stops := make([]StopLocation, 0, len(r.Locations))
+	for _, l := range r.Locations {
+		if l.StopLocation != nil {
+			stops = append(stops, *l.StopLocation)
+		}
+	}
+	return stops
+}
@@ -0,0 +1,71 @@
+package stopsnearby
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nobina/go-trafiklab/slidentifiers"
+)
+
+// DefaultEFAPrefix is the EFA GID prefix for SL sites.
+const DefaultEFAPrefix = slidentifiers.PrefixSLSite
+
+// IDConverter converts the HAFAS extId of a stop into the id returned in
+// StopLocation.GID.
+type IDConverter func(extID string) (string, error)
+
+// EFAConverter converts HAFAS ids of the form 3AA1BBBBB into EFA GIDs by
+// appending the zero padded site id AABBBBB to prefix.
+func EFAConverter(prefix string) IDConverter {
+	return func(extID string) (string, error) {
+		siteID, err := slidentifiers.ConvertHAFASToSiteID(extID)
+		if err != nil {
+			return "", err
+		}
+		id, err := strconv.Atoi(siteID)
+		if err != nil {
+			return "", fmt.Errorf("unexpected site id: %q: %w", siteID, err)
+		}
+		return fmt.Sprintf("%s%07d", prefix, id), nil
+	}
+}
+
+// WithEFAPrefix converts stop ids using prefix instead of DefaultEFAPrefix,
+// e.g. for stops belonging to another authority.
+func WithEFAPrefix(prefix string) Option {
+	return func(c *Client) {
+		c.convertID = EFAConverter(prefix)
+	}
+}
+
+// WithRegion converts stop ids using the site prefix of region.
+func WithRegion(region slidentifiers.Region) Option {
+	return WithEFAPrefix(region.Prefixes[slidentifiers.EntitySite])
+}
+
+// WithIDConverter replaces the id conversion entirely.
+func WithIDConverter(fn IDConverter) Option {
+	return func(c *Client) {
+		c.convertID = fn
+	}
+}
+
+// WithKeepUnconverted keeps stops whose ids fail conversion, using the
+// original extId as GID, instead of dropping them. Failures are reported
+// in NearbyResponse.Warnings either way.
+func WithKeepUnconverted() Option {
+	return func(c *Client) {
+		c.keepFailed = true
+	}
+}
+
+type ConversionWarning struct {
+	ExtID string
+	Name  string
+	Kept  bool
+	Err   error
+}
+
+func (w ConversionWarning) String() string {
+	return fmt.Sprintf("stop %s (%s): %v", w.ExtID, w.Name, w.Err)
+}
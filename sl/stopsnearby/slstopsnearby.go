@@ -2,61 +2,112 @@ package stopsnearby
 
 import (
 	"context"
-	"encoding/xml"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
+
+	"github.com/nobina/go-trafiklab/geo"
+	"github.com/nobina/go-trafiklab/requests"
 )
 
 type Config struct {
-	APIKey  string
-	BaseURL string
+	APIKey        string
+	BaseURL       string
+	AllowInsecure requests.AllowInsecure
+}
+
+func (cfg *Config) Valid() error {
+	if cfg.APIKey == "" {
+		return errors.New("missing api key")
+	}
+	if cfg.BaseURL == "" {
+		return errors.New("missing base url")
+	}
+	return requests.ValidateBaseURL(cfg.BaseURL, cfg.AllowInsecure)
 }
 
+// StopsNearbyClient is the client of the XML variant of the API, kept for
+// compatibility. It is backed by the JSON API of Client, but keeps the old
+// contract: API errors are reported in LocationList.ErrorCode and stop ids
+// are not converted.
+//
+// Deprecated: Use Client.
 type StopsNearbyClient struct {
-	httpClient *http.Client
-	apiKey     string
-	baseURL    string
+	client *Client
 }
 
+// Deprecated: Use NewClient.
 func NewStopsNearbyClient(cfg *Config, client *http.Client) *StopsNearbyClient {
 	return &StopsNearbyClient{
-		httpClient: client,
-		apiKey:     cfg.APIKey,
-		baseURL:    cfg.BaseURL,
+		client: NewClient(cfg, client),
 	}
 }
 
 func (c *StopsNearbyClient) Nearby(ctx context.Context, body *StopsNearbyRequest) (*LocationList, error) {
-	url := c.baseURL + "/nearbystopsv2.xml"
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	resp, err := c.client.fetch(ctx, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-	q := body.params()
-	q.Add("key", c.apiKey)
-	req.URL.RawQuery = q.Encode()
+	return &LocationList{
+		ErrorCode: resp.ErrorCode,
+		Data:      filterProducts(resp.Stops(), body.productMask()),
+	}, nil
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed request: %w", err)
-	}
-	defer resp.Body.Close()
+// Ping checks that the nearby stops API responds and accepts the key,
+// asking for a single stop.
+func (c *StopsNearbyClient) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx)
+}
 
-	nearbyResp := &LocationList{}
-	err = xml.NewDecoder(resp.Body).Decode(nearbyResp)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-	return nearbyResp, nil
+// Healthy reports whether Ping succeeds.
+func (c *StopsNearbyClient) Healthy(ctx context.Context) bool {
+	return c.client.Healthy(ctx)
 }
 
+type ProductRef int32
+
+const (
+	ProductRefTrain   ProductRef = 1
+	ProductRefMetro   ProductRef = 2
+	ProductRefTram    ProductRef = 4
+	ProductRefBus     ProductRef = 8
+	ProductRefBoat    ProductRef = 96
+	ProductRefCommute ProductRef = 128
+)
+
 type StopsNearbyRequest struct {
 	OriginCoordLat  string
 	OriginCoordLong string
 	MaxNo           string
 	Radius          string
 	Type            string
+	Products        []ProductRef
+}
+
+// Validate checks that the origin is a WGS84 coordinate in Sweden, and not
+// swapped. Use geo.ValidateCoordinate with geo.BoundsStockholm for a
+// stricter check.
+func (r StopsNearbyRequest) Validate() error {
+	lat, err := strconv.ParseFloat(r.OriginCoordLat, 64)
+	if err != nil {
+		return fmt.Errorf("invalid latitude %q: %w", r.OriginCoordLat, err)
+	}
+	lng, err := strconv.ParseFloat(r.OriginCoordLong, 64)
+	if err != nil {
+		return fmt.Errorf("invalid longitude %q: %w", r.OriginCoordLong, err)
+	}
+	return geo.ValidateCoordinate(lat, lng, geo.BoundsSweden)
+}
+
+func (r StopsNearbyRequest) productMask() int {
+	mask := 0
+	for _, p := range r.Products {
+		mask |= int(p)
+	}
+	return mask
 }
 
 func (r StopsNearbyRequest) params() url.Values {
@@ -76,6 +127,9 @@ func (r StopsNearbyRequest) params() url.Values {
 	if r.Type != "" {
 		params.Set("type", r.Type)
 	}
+	if mask := r.productMask(); mask != 0 {
+		params.Set("products", strconv.Itoa(mask))
+	}
 	return params
 }
 
@@ -85,11 +139,34 @@ type LocationList struct {
 }
 
 type StopLocation struct {
-	Name          string  `xml:"name,attr"`
-	ID            string  `xml:"id,attr"`
-	ExtID         string  `xml:"extId,attr"`
-	MainMastExtID string  `xml:"mainMastExtId,attr"`
-	Lat           float64 `xml:"lat,attr"`
-	Lon           float64 `xml:"lon,attr"`
-	Distance      int     `xml:"dist,attr"`
+	Name          string  `json:"name" xml:"name,attr"`
+	ID            string  `json:"id" xml:"id,attr"`
+	ExtID         string  `json:"extId" xml:"extId,attr"`
+	MainMastExtID string  `json:"mainMastExtId" xml:"mainMastExtId,attr"`
+	Lat           float64 `json:"lat" xml:"lat,attr"`
+	Lon           float64 `json:"lon" xml:"lon,attr"`
+	Distance      int     `json:"dist" xml:"dist,attr"`
+	Products      int     `json:"products" xml:"products,attr"`
+	GID           string  `json:"-" xml:"-"`
+}
+
+// ServedBy reports whether any of the products in mask stop here. Stops
+// without product information are assumed to be served.
+func (s StopLocation) ServedBy(mask int) bool {
+	return s.Products == 0 || s.Products&mask != 0
+}
+
+// filterProducts is applied after the request as well since the API
+// doesn't honour the products parameter for all stop types.
+func filterProducts(stops []StopLocation, mask int) []StopLocation {
+	if mask == 0 {
+		return stops
+	}
+	filtered := []StopLocation{}
+	for _, s := range stops {
+		if s.ServedBy(mask) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
 }
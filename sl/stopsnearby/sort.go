@@ -0,0 +1,44 @@
+package stopsnearby
+
+import "sort"
+
+// SortByDistance sorts stops nearest first.
+func SortByDistance(stops []StopLocation) {
+	sort.SliceStable(stops, func(i, j int) bool {
+		return stops[i].Distance < stops[j].Distance
+	})
+}
+
+// DedupeSites keeps only the nearest stop point per parent site. Stops
+// without a main mast are treated as their own site.
+func DedupeSites(stops []StopLocation) []StopLocation {
+	nearest := map[string]int{}
+	deduped := []StopLocation{}
+	for _, s := range stops {
+		key := s.MainMastExtID
+		if key == "" {
+			key = s.ExtID
+		}
+		i, ok := nearest[key]
+		if !ok {
+			nearest[key] = len(deduped)
+			deduped = append(deduped, s)
+			continue
+		}
+		if s.Distance < deduped[i].Distance {
+			deduped[i] = s
+		}
+	}
+	return deduped
+}
+
+// Nearest sorts stops by distance, dedupes them per site and returns at
+// most max stops. A max of 0 or less returns all of them.
+func Nearest(stops []StopLocation, max int) []StopLocation {
+	deduped := DedupeSites(stops)
+	SortByDistance(deduped)
+	if max > 0 && len(deduped) > max {
+		deduped = deduped[:max]
+	}
+	return deduped
+}
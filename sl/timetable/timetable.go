@@ -0,0 +1,153 @@
+// Package timetable serves SL departures computed from static GTFS, for
+// use as a transport.Fallback while the SL Transport API is down.
+package timetable
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nobina/go-trafiklab/display"
+	"github.com/nobina/go-trafiklab/gtfs"
+	"github.com/nobina/go-trafiklab/sl/transport"
+	"github.com/nobina/go-trafiklab/slidentifiers"
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+// DefaultWindow is used when a request has no forecast.
+const DefaultWindow = 60 * time.Minute
+
+// shipAgency is the agency whose boats SL Transport reports as SHIP. Other
+// water transport, such as SL's own ferry lines, is FERRY.
+const shipAgency = "waxholmsbolaget"
+
+// Board computes scheduled departures of SL sites from the SL GTFS feed.
+type Board struct {
+	schedule  *gtfs.Schedule
+	stops     map[string]*gtfs.Stop
+	siteStops map[string][]string
+	// shipAgencies are the ids of the agencies matching shipAgency.
+	shipAgencies map[string]bool
+	clock        timeutils.Clock
+}
+
+type Option func(*Board)
+
+func WithClock(clock timeutils.Clock) Option {
+	return func(b *Board) {
+		b.clock = clock
+	}
+}
+
+// New indexes feed. Stops are grouped by site through h, which should
+// know the stop areas of every site, see slidentifiers.Hierarchy.
+func New(feed *gtfs.Feed, h *slidentifiers.Hierarchy, opts ...Option) *Board {
+	b := &Board{
+		schedule:     gtfs.NewSchedule(feed),
+		stops:        map[string]*gtfs.Stop{},
+		siteStops:    map[string][]string{},
+		shipAgencies: map[string]bool{},
+		clock:        timeutils.SystemClock,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	for _, a := range feed.Agencies {
+		if strings.Contains(strings.ToLower(a.Name), shipAgency) {
+			b.shipAgencies[a.ID] = true
+		}
+	}
+	for i := range feed.Stops {
+		stop := &feed.Stops[i]
+		b.stops[stop.ID] = stop
+		if stop.LocationType != gtfs.LocationStop {
+			continue
+		}
+		site, err := h.Site(stop.ID)
+		if err != nil {
+			continue
+		}
+		b.siteStops[site] = append(b.siteStops[site], stop.ID)
+	}
+	return b
+}
+
+// Departures returns the scheduled departures of the site in payload,
+// marked ScheduleOnly. Mode filters are applied by the transport client.
+func (b *Board) Departures(ctx context.Context, payload *transport.DeparturesRequest) (*transport.DepartureResponse, error) {
+	siteGID, err := slidentifiers.ConvertSiteIDToEFA(payload.SiteID, slidentifiers.EntitySite)
+	if err != nil {
+		return nil, fmt.Errorf("invalid site id: %w", err)
+	}
+	stopIDs, ok := b.siteStops[siteGID]
+	if !ok {
+		return nil, fmt.Errorf("no timetable for site %s", payload.SiteID)
+	}
+	window := DefaultWindow
+	if payload.Forecast > 0 {
+		window = time.Duration(payload.Forecast) * time.Minute
+	}
+
+	now := b.clock.Now()
+	resp := &transport.DepartureResponse{ScheduleOnly: true}
+	for _, d := range b.schedule.Departures(stopIDs, now, window) {
+		resp.Departures = append(resp.Departures, b.departure(now, d))
+	}
+	return resp, nil
+}
+
+func (b *Board) departure(now time.Time, d gtfs.ScheduledDeparture) *transport.Departure {
+	scheduled := d.Time.In(timeutils.EuropeStockholm()).Format("2006-01-02T15:04:05")
+	dep := &transport.Departure{
+		DirectionCode: d.Trip.DirectionID + 1,
+		Destination:   d.Headsign(),
+		Direction:     d.Headsign(),
+		State:         "NOTEXPECTED",
+		Scheduled:     scheduled,
+		Expected:      scheduled,
+		Display:       display.New(display.Swedish).Departure(now, d.Time),
+	}
+	if stop, ok := b.stops[d.StopTime.StopID]; ok {
+		dep.StopPoint = transport.StopPoint{
+			Name:        stop.Name,
+			Designation: stop.PlatformCode,
+		}
+		if parent, ok := b.stops[stop.ParentStation]; ok {
+			dep.StopArea = transport.StopArea{Name: parent.Name}
+		}
+	}
+	if d.Route != nil {
+		dep.Line = transport.Line{
+			Designation:   d.Route.ShortName,
+			TransportMode: b.transportMode(d.Route),
+			GroupOfLines:  d.Route.LongName,
+		}
+	}
+	return dep
+}
+
+// transportMode maps basic and extended GTFS route types to SL transport
+// modes. Water transport is SHIP for Waxholmsbolaget and FERRY otherwise,
+// as SL Transport reports it.
+func (b *Board) transportMode(route *gtfs.Route) string {
+	routeType := route.Type
+	switch {
+	case routeType == 0, routeType >= 900 && routeType < 1000:
+		return transport.TransportModeTram
+	case routeType == 1, routeType >= 400 && routeType < 500:
+		return transport.TransportModeMetro
+	case routeType == 2, routeType >= 100 && routeType < 200:
+		return transport.TransportModeTrain
+	case routeType == 3, routeType >= 700 && routeType < 800:
+		return transport.TransportModeBus
+	case routeType == 4, routeType >= 1000 && routeType < 1100:
+		if b.shipAgencies[route.AgencyID] {
+			return transport.TransportModeShip
+		}
+		return transport.TransportModeFerry
+	case routeType >= 1500 && routeType < 1600:
+		return transport.TransportModeTaxi
+	}
+	return ""
+}
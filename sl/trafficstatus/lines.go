@@ -0,0 +1,67 @@
+package trafficstatus
+
+import (
+	"strings"
+
+	"github.com/nobina/go-trafiklab/sl/transport"
+)
+
+// Lines parses LineNumbers, e.g. "17, 18, 19", into line designations.
+func (e Event) Lines() []string {
+	return strings.FieldsFunc(e.LineNumbers, func(r rune) bool {
+		return r == ',' || r == ' ' || r == ';'
+	})
+}
+
+// Mentions reports whether the event affects the line with designation.
+func (e Event) Mentions(designation string) bool {
+	for _, l := range e.Lines() {
+		if strings.EqualFold(l, designation) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForLine returns the events across all traffic types that affect the line
+// with designation.
+func (r *TrafficStatusResponse) ForLine(designation string) []Event {
+	events := []Event{}
+	for _, st := range r.ResponseData.TrafficTypes {
+		for _, e := range st.Events {
+			if e.Mentions(designation) {
+				events = append(events, e)
+			}
+		}
+	}
+	return events
+}
+
+// ForMode returns the status of the traffic type mode, e.g. "metro".
+func (r *TrafficStatusResponse) ForMode(mode string) (*Status, bool) {
+	for i, st := range r.ResponseData.TrafficTypes {
+		if strings.EqualFold(st.Type, mode) {
+			return &r.ResponseData.TrafficTypes[i], true
+		}
+	}
+	return nil, false
+}
+
+// trafficTypeModes maps traffic status types to transport modes.
+var trafficTypeModes = map[string]string{
+	"metro": transport.TransportModeMetro,
+	"train": transport.TransportModeTrain,
+	"local": transport.TransportModeTram,
+	"tram":  transport.TransportModeTram,
+	"bus":   transport.TransportModeBus,
+	"fer":   transport.TransportModeFerry,
+}
+
+// TransportMode returns the transport mode of the traffic type, e.g.
+// transport.TransportModeMetro for "metro".
+func (s Status) TransportMode() string {
+	if mode, ok := trafficTypeModes[s.Type]; ok {
+		return mode
+	}
+	return strings.ToUpper(s.Type)
+}
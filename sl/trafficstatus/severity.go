@@ -0,0 +1,85 @@
+package trafficstatus
+
+// Severity is the traffic situation sent as StatusIcon, ordered from best
+// to worst so severities can be compared directly.
+type Severity int
+
+const (
+	SeverityUnknown Severity = iota
+	SeverityGood
+	SeverityPlanned
+	SeverityMinor
+	SeverityMajor
+)
+
+var severityIcons = map[Severity]string{
+	SeverityGood:    "EventGood",
+	SeverityPlanned: "EventPlanned",
+	SeverityMinor:   "EventMinor",
+	SeverityMajor:   "EventMajor",
+}
+
+func ParseSeverity(icon string) Severity {
+	for s, i := range severityIcons {
+		if i == icon {
+			return s
+		}
+	}
+	return SeverityUnknown
+}
+
+func (s Severity) String() string {
+	if icon, ok := severityIcons[s]; ok {
+		return icon
+	}
+	return ""
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText handles both the JSON and XML representations. Unknown
+// icons decode to SeverityUnknown rather than failing the response.
+func (s *Severity) UnmarshalText(b []byte) error {
+	*s = ParseSeverity(string(b))
+	return nil
+}
+
+// WorseThan reports whether s is more severe than other.
+func (s Severity) WorseThan(other Severity) bool {
+	return s > other
+}
+
+// AtLeast reports whether s is as severe as other or worse.
+func (s Severity) AtLeast(other Severity) bool {
+	return s >= other
+}
+
+// Disrupted reports whether s indicates an ongoing disruption.
+func (s Severity) Disrupted() bool {
+	return s >= SeverityMinor
+}
+
+// WorstSeverity returns the most severe of the traffic type itself and its
+// events.
+func (st Status) WorstSeverity() Severity {
+	worst := st.Severity
+	for _, e := range st.Events {
+		if e.Severity.WorseThan(worst) {
+			worst = e.Severity
+		}
+	}
+	return worst
+}
+
+// WorstSeverity returns the most severe status across all traffic types.
+func (r *TrafficStatusResponse) WorstSeverity() Severity {
+	worst := SeverityUnknown
+	for _, st := range r.ResponseData.TrafficTypes {
+		if s := st.WorstSeverity(); s.WorseThan(worst) {
+			worst = s
+		}
+	}
+	return worst
+}
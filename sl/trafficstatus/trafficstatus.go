@@ -0,0 +1,195 @@
+package trafficstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/nobina/go-trafiklab/cache"
+	"github.com/nobina/go-trafiklab/metrics"
+	"github.com/nobina/go-trafiklab/requests"
+)
+
+const trafficSituationPath = "/api2/trafficsituation.json"
+
+type Config struct {
+	APIKey        string
+	BaseURL       string
+	AllowInsecure requests.AllowInsecure
+}
+
+func (cfg *Config) Valid() error {
+	if cfg.APIKey == "" {
+		return errors.New("missing api key")
+	}
+	if cfg.BaseURL == "" {
+		return errors.New("missing base url")
+	}
+	return requests.ValidateBaseURL(cfg.BaseURL, cfg.AllowInsecure)
+}
+
+type Client struct {
+	httpClient *http.Client
+	apiKey     string
+	baseURL    string
+	isDebug    bool
+	logger     requests.Logger
+}
+
+func NewClient(cfg *Config, client *http.Client, opts ...Option) *Client {
+	c := &Client{
+		httpClient: client,
+		apiKey:     cfg.APIKey,
+		baseURL:    cfg.BaseURL,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.httpClient = requests.WrapClient(c.httpClient, requests.UserAgent(""))
+
+	return c
+}
+
+type Option func(*Client)
+
+func WithDebug() Option {
+	return func(c *Client) {
+		c.isDebug = true
+	}
+}
+
+// WithMetrics reports the requests of the client to r, e.g. a
+// metrics.LatencyTracker.
+func WithMetrics(r metrics.Recorder) Option {
+	return func(c *Client) {
+		c.httpClient = requests.WrapClient(c.httpClient, metrics.Middleware(r))
+	}
+}
+
+// WithResponseCache caches traffic status responses in c for ttl.
+func WithResponseCache(c cache.Cache, ttl time.Duration) Option {
+	return func(cl *Client) {
+		cl.httpClient = requests.WrapClient(cl.httpClient, cache.Middleware(c, ttl, nil))
+	}
+}
+
+// WithLogger logs failed requests, and debug output if enabled, to logger.
+func WithLogger(logger requests.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithKeyPool rotates between the keys in pool instead of using the
+// configured api key.
+func WithKeyPool(pool *requests.KeyPool) Option {
+	return func(c *Client) {
+		c.httpClient = requests.WrapClient(c.httpClient, requests.RotateKeys(pool, "key"))
+	}
+}
+
+func (c *Client) logf(format string, v ...any) {
+	if c.logger != nil {
+		c.logger.Printf(format, v...)
+	}
+}
+
+// ResponseError is returned when the response envelope reports a non zero
+// status code.
+type ResponseError struct {
+	StatusCode int32
+	Message    string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("traffic status error %d: %s", e.StatusCode, e.Message)
+}
+
+func (c *Client) TrafficStatus(ctx context.Context) (*TrafficStatusResponse, error) {
+	endpoint := c.baseURL + trafficSituationPath
+
+	if c.isDebug {
+		c.logf("url: %s\n", endpoint)
+	}
+
+	statusResp, err := requests.GetJSON[TrafficStatusResponse](ctx, c.httpClient, endpoint, url.Values{"key": {c.apiKey}},
+		requests.OnResponse(func(res *http.Response) {
+			if c.isDebug {
+				c.dump(res)
+			}
+		}))
+	if err != nil {
+		c.logf("traffic status request failed: %v", err)
+		return nil, err
+	}
+	if statusResp.StatusCode != 0 {
+		return nil, &ResponseError{
+			StatusCode: statusResp.StatusCode,
+			Message:    statusResp.Message,
+		}
+	}
+
+	return &statusResp, nil
+}
+
+// Ping checks that the traffic status API responds and accepts the key.
+// The API answers a rejected key with 200 and a status code in the body,
+// so the response is decoded like TrafficStatus's.
+func (c *Client) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, requests.DefaultPingTimeout)
+	defer cancel()
+	_, err := c.TrafficStatus(ctx)
+	return err
+}
+
+// Healthy reports whether Ping succeeds.
+func (c *Client) Healthy(ctx context.Context) bool {
+	return c.Ping(ctx) == nil
+}
+
+func (c *Client) dump(res *http.Response) {
+	b, err := requests.DumpResponse(res, 0)
+	if err != nil {
+		c.logf("failed to dump response: %v", err)
+		return
+	}
+	c.logf("response: %s\n", b)
+}
+
+type TrafficStatusResponse struct {
+	StatusCode    int32        `json:"StatusCode"`
+	Message       string       `json:"Message"`
+	ExecutionTime int64        `json:"ExecutionTime"`
+	ResponseData  ResponseData `json:"ResponseData"`
+}
+
+type ResponseData struct {
+	TrafficTypes []Status `json:"TrafficTypes"`
+}
+
+// Status is the current situation for one traffic type, e.g. metro.
+type Status struct {
+	ID              int      `json:"Id"`
+	Name            string   `json:"Name"`
+	Type            string   `json:"Type"`
+	Severity        Severity `json:"StatusIcon"`
+	Expanded        bool     `json:"Expanded"`
+	HasPlannedEvent bool     `json:"HasPlannedEvent"`
+	Events          []Event  `json:"Events"`
+}
+
+type Event struct {
+	EventID      int      `json:"EventId"`
+	Message      string   `json:"Message"`
+	Expanded     bool     `json:"Expanded"`
+	Planned      bool     `json:"Planned"`
+	SortIndex    int      `json:"SortIndex"`
+	Severity     Severity `json:"StatusIcon"`
+	LineNumbers  string   `json:"LineNumbers"`
+	EventInfoURL string   `json:"EventInfoUrl"`
+	TrafficLine  string   `json:"TrafficLine"`
+}
@@ -0,0 +1,75 @@
+package trafficstatus
+
+// severityNames are the stable names of severities in views, independent
+// of the icon names the API uses.
+var severityNames = map[Severity]string{
+	SeverityUnknown: "unknown",
+	SeverityGood:    "good",
+	SeverityPlanned: "planned",
+	SeverityMinor:   "minor",
+	SeverityMajor:   "major",
+}
+
+// View is the traffic status with stable JSON field names, for services
+// that re-expose it. The response types follow the API's field names and
+// are meant for decoding only.
+type View struct {
+	Severity string     `json:"severity"`
+	Modes    []ModeView `json:"modes"`
+}
+
+type ModeView struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// TransportMode is a transport.TransportMode constant.
+	TransportMode    string      `json:"transport_mode"`
+	Severity         string      `json:"severity"`
+	HasPlannedEvents bool        `json:"has_planned_events"`
+	Events           []EventView `json:"events"`
+}
+
+type EventView struct {
+	ID       int      `json:"id"`
+	Message  string   `json:"message"`
+	Severity string   `json:"severity"`
+	Planned  bool     `json:"planned"`
+	Lines    []string `json:"lines"`
+	URL      string   `json:"url,omitempty"`
+}
+
+func (r *TrafficStatusResponse) View() View {
+	v := View{
+		Severity: severityNames[r.WorstSeverity()],
+		Modes:    make([]ModeView, 0, len(r.ResponseData.TrafficTypes)),
+	}
+	for _, st := range r.ResponseData.TrafficTypes {
+		v.Modes = append(v.Modes, st.View())
+	}
+	return v
+}
+
+func (st Status) View() ModeView {
+	v := ModeView{
+		ID:               st.ID,
+		Name:             st.Name,
+		TransportMode:    st.TransportMode(),
+		Severity:         severityNames[st.Severity],
+		HasPlannedEvents: st.HasPlannedEvent,
+		Events:           make([]EventView, 0, len(st.Events)),
+	}
+	for _, e := range st.Events {
+		v.Events = append(v.Events, e.View())
+	}
+	return v
+}
+
+func (e Event) View() EventView {
+	return EventView{
+		ID:       e.EventID,
+		Message:  e.Message,
+		Severity: severityNames[e.Severity],
+		Planned:  e.Planned,
+		Lines:    append([]string{}, e.Lines()...),
+		URL:      e.EventInfoURL,
+	}
+}
@@ -0,0 +1,162 @@
+package trafficstatus
+
+import (
+	"context"
+	"time"
+
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+type ChangeKind int
+
+const (
+	ChangeSeverity ChangeKind = iota + 1
+	ChangeEventAdded
+	ChangeEventRemoved
+)
+
+// Change describes a difference between two consecutive traffic status
+// responses. Event is set for ChangeEventAdded and ChangeEventRemoved.
+type Change struct {
+	Kind        ChangeKind
+	TrafficType string
+	Previous    Severity
+	Current     Severity
+	Event       *Event
+}
+
+// Diff returns the changes between prev and curr. Traffic types are
+// matched on Type and events on EventID.
+func Diff(prev, curr *TrafficStatusResponse) []Change {
+	changes := []Change{}
+	prevTypes := map[string]Status{}
+	if prev != nil {
+		for _, st := range prev.ResponseData.TrafficTypes {
+			prevTypes[st.Type] = st
+		}
+	}
+
+	for _, st := range curr.ResponseData.TrafficTypes {
+		p, ok := prevTypes[st.Type]
+		if ok && p.Severity != st.Severity {
+			changes = append(changes, Change{
+				Kind:        ChangeSeverity,
+				TrafficType: st.Type,
+				Previous:    p.Severity,
+				Current:     st.Severity,
+			})
+		}
+
+		prevEvents := map[int]bool{}
+		for _, e := range p.Events {
+			prevEvents[e.EventID] = true
+		}
+		currEvents := map[int]bool{}
+		for i, e := range st.Events {
+			currEvents[e.EventID] = true
+			if !prevEvents[e.EventID] {
+				changes = append(changes, Change{
+					Kind:        ChangeEventAdded,
+					TrafficType: st.Type,
+					Previous:    p.Severity,
+					Current:     st.Severity,
+					Event:       &st.Events[i],
+				})
+			}
+		}
+		for i, e := range p.Events {
+			if !currEvents[e.EventID] {
+				changes = append(changes, Change{
+					Kind:        ChangeEventRemoved,
+					TrafficType: st.Type,
+					Previous:    p.Severity,
+					Current:     st.Severity,
+					Event:       &p.Events[i],
+				})
+			}
+		}
+	}
+
+	return changes
+}
+
+// Watcher polls the traffic status and emits the changes between polls.
+type Watcher struct {
+	client   *Client
+	interval time.Duration
+	onError  func(error)
+	clock    timeutils.Clock
+	prev     *TrafficStatusResponse
+}
+
+type WatcherOption func(*Watcher)
+
+// WithErrorHandler is called when a poll fails. The watcher keeps polling
+// and compares the next successful response against the last one.
+func WithErrorHandler(fn func(error)) WatcherOption {
+	return func(w *Watcher) {
+		w.onError = fn
+	}
+}
+
+// WithWatcherClock sets the clock that paces polls.
+func WithWatcherClock(clock timeutils.Clock) WatcherOption {
+	return func(w *Watcher) {
+		w.clock = clock
+	}
+}
+
+func NewWatcher(client *Client, interval time.Duration, opts ...WatcherOption) *Watcher {
+	w := &Watcher{
+		client:   client,
+		interval: interval,
+		onError:  func(error) {},
+		clock:    timeutils.SystemClock,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// Run polls until ctx is done, sending changes on changes. The first
+// successful poll sets the baseline and emits nothing.
+func (w *Watcher) Run(ctx context.Context, changes chan<- Change) error {
+	for {
+		if err := w.poll(ctx, changes); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			w.onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.clock.After(w.interval):
+		}
+	}
+}
+
+func (w *Watcher) poll(ctx context.Context, changes chan<- Change) error {
+	curr, err := w.client.TrafficStatus(ctx)
+	if err != nil {
+		return err
+	}
+	prev := w.prev
+	w.prev = curr
+	if prev == nil {
+		return nil
+	}
+
+	for _, c := range Diff(prev, curr) {
+		select {
+		case changes <- c:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
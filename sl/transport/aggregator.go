@@ -0,0 +1,257 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+// BoardFilter keeps departures matching all of its non-empty fields.
+type BoardFilter struct {
+	// Lines are line designations, e.g. "14" or "55".
+	Lines []string
+	// DirectionCodes are 1 or 2.
+	DirectionCodes []int
+	// TransportModes are TransportMode constants.
+	TransportModes []string
+}
+
+func (f BoardFilter) match(d *Departure) bool {
+	if len(f.Lines) > 0 && !slices.Contains(f.Lines, d.Line.Designation) {
+		return false
+	}
+	if len(f.DirectionCodes) > 0 && !slices.Contains(f.DirectionCodes, d.DirectionCode) {
+		return false
+	}
+	if len(f.TransportModes) > 0 && !slices.Contains(f.TransportModes, d.Line.TransportMode) {
+		return false
+	}
+	return true
+}
+
+// BoardDeparture is a departure on an aggregated board.
+type BoardDeparture struct {
+	SiteID    string
+	Departure *Departure
+	// Expected is the parsed expected time, or the scheduled time if the
+	// departure has no prognosis.
+	Expected time.Time
+}
+
+// Aggregator keeps a merged departure board for a set of sites, such as
+// all stops near an office.
+type Aggregator struct {
+	client   *Client
+	interval time.Duration
+	forecast int
+	filter   BoardFilter
+	clock    timeutils.Clock
+	onError  func(error)
+
+	mu     sync.RWMutex
+	sites  []string
+	boards map[string][]BoardDeparture
+	merged []BoardDeparture
+}
+
+type AggregatorOption func(*Aggregator)
+
+// WithBoardFilter only keeps departures matching f.
+func WithBoardFilter(f BoardFilter) AggregatorOption {
+	return func(a *Aggregator) {
+		a.filter = f
+	}
+}
+
+// WithForecast sets the time window in minutes requested for each site.
+func WithForecast(minutes int) AggregatorOption {
+	return func(a *Aggregator) {
+		a.forecast = minutes
+	}
+}
+
+// WithAggregatorClock sets the clock that paces polls.
+func WithAggregatorClock(clock timeutils.Clock) AggregatorOption {
+	return func(a *Aggregator) {
+		a.clock = clock
+	}
+}
+
+// WithAggregatorErrorHandler is called when a site fails to refresh. The
+// last board of the site is kept.
+func WithAggregatorErrorHandler(fn func(error)) AggregatorOption {
+	return func(a *Aggregator) {
+		a.onError = fn
+	}
+}
+
+func NewAggregator(client *Client, siteIDs []string, interval time.Duration, opts ...AggregatorOption) *Aggregator {
+	a := &Aggregator{
+		client:   client,
+		interval: interval,
+		clock:    timeutils.SystemClock,
+		onError:  func(error) {},
+		sites:    append([]string(nil), siteIDs...),
+		boards:   map[string][]BoardDeparture{},
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// SetSites replaces the set of sites from the next refresh on.
+func (a *Aggregator) SetSites(siteIDs []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sites = append([]string(nil), siteIDs...)
+	for id := range a.boards {
+		if !slices.Contains(a.sites, id) {
+			delete(a.boards, id)
+		}
+	}
+}
+
+// Snapshot returns the merged board, ordered by expected time.
+func (a *Aggregator) Snapshot() []BoardDeparture {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return append([]BoardDeparture(nil), a.merged...)
+}
+
+// Refresh fetches the boards of all sites concurrently and reports
+// whether the merged board changed. Departures that have left are dropped.
+func (a *Aggregator) Refresh(ctx context.Context) (bool, error) {
+	a.mu.RLock()
+	sites := append([]string(nil), a.sites...)
+	a.mu.RUnlock()
+
+	type result struct {
+		site  string
+		board []BoardDeparture
+		err   error
+	}
+	results := make(chan result, len(sites))
+	for _, site := range sites {
+		go func(site string) {
+			board, err := a.fetch(ctx, site)
+			results <- result{site: site, board: board, err: err}
+		}(site)
+	}
+
+	var errs []error
+	boards := map[string][]BoardDeparture{}
+	for range sites {
+		r := <-results
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+		boards[r.site] = r.board
+	}
+
+	// Snapshot and SetSites aren't blocked while fetching. Sites
+	// removed meanwhile are skipped.
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for site, board := range boards {
+		if slices.Contains(a.sites, site) {
+			a.boards[site] = board
+		}
+	}
+
+	now := a.clock.Now()
+	var merged []BoardDeparture
+	for _, board := range a.boards {
+		for _, d := range board {
+			if !d.Expected.Before(now) {
+				merged = append(merged, d)
+			}
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Expected.Equal(merged[j].Expected) {
+			return merged[i].SiteID < merged[j].SiteID
+		}
+		return merged[i].Expected.Before(merged[j].Expected)
+	})
+	changed := !sameBoard(a.merged, merged)
+	a.merged = merged
+	return changed, errors.Join(errs...)
+}
+
+func (a *Aggregator) fetch(ctx context.Context, site string) ([]BoardDeparture, error) {
+	resp, err := a.client.Departures(ctx, &DeparturesRequest{
+		SiteID:   site,
+		Forecast: a.forecast,
+		Bus:      true,
+		Metro:    true,
+		Train:    true,
+		Tram:     true,
+		Ship:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var board []BoardDeparture
+	for _, d := range resp.Departures {
+		if !a.filter.match(d) {
+			continue
+		}
+		expected, err := d.ExpectedTime()
+		if err != nil {
+			continue
+		}
+		board = append(board, BoardDeparture{SiteID: site, Departure: d, Expected: expected})
+	}
+	return board, nil
+}
+
+func sameBoard(a, b []BoardDeparture) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].SiteID != b[i].SiteID ||
+			a[i].Departure.Journey.ID != b[i].Departure.Journey.ID ||
+			!a[i].Expected.Equal(b[i].Expected) ||
+			a[i].Departure.State != b[i].Departure.State {
+			return false
+		}
+	}
+	return true
+}
+
+// Run refreshes until ctx is done, sending the merged board on updates
+// whenever it changes.
+func (a *Aggregator) Run(ctx context.Context, updates chan<- []BoardDeparture) error {
+	for {
+		changed, err := a.Refresh(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			a.onError(err)
+		}
+		if changed {
+			select {
+			case updates <- a.Snapshot():
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-a.clock.After(a.interval):
+		}
+	}
+}
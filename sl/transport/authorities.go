@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nobina/go-trafiklab/requests"
+)
+
+// TransportAuthoritySL is the transport authority id of SL. Other
+// authorities, such as Waxholmsbolaget, are best looked up by name with
+// FindTransportAuthority.
+const TransportAuthoritySL = 1
+
+type TransportAuthority struct {
+	ID         int    `json:"id"`
+	GID        int64  `json:"gid"`
+	Name       string `json:"name"`
+	FormalName string `json:"formal_name"`
+	Code       string `json:"code"`
+	Street     string `json:"street"`
+	PostalCode int    `json:"postal_code"`
+	City       string `json:"city"`
+	Country    string `json:"country"`
+}
+
+// TransportAuthorities lists the transport authorities whose stops and
+// lines are served by the API.
+func (c *Client) TransportAuthorities(ctx context.Context) ([]*TransportAuthority, error) {
+	return requests.GetJSON[[]*TransportAuthority](ctx, c.httpClient, c.baseURL+"/v1/transport-authorities", nil, c.debugResponse())
+}
+
+// FindTransportAuthority returns the authority whose name, formal name or
+// code contains name, ignoring case, e.g. "waxholm".
+func (c *Client) FindTransportAuthority(ctx context.Context, name string) (*TransportAuthority, error) {
+	authorities, err := c.TransportAuthorities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.ToLower(name)
+	for _, a := range authorities {
+		if strings.Contains(strings.ToLower(a.Name), name) ||
+			strings.Contains(strings.ToLower(a.FormalName), name) ||
+			strings.EqualFold(a.Code, name) {
+			return a, nil
+		}
+	}
+	return nil, fmt.Errorf("no transport authority matching %q", name)
+}
@@ -0,0 +1,44 @@
+package transport_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/nobina/go-trafiklab/sl/transport"
+)
+
+// Waxholmsbolaget's boats are departures with the SHIP mode. Filtering on
+// the authority leaves out SL's own ferries at shared piers.
+func ExampleClient_Departures_waxholmsbolaget() {
+	ctx := context.Background()
+	client := transport.NewClient(&transport.Config{BaseURL: "https://transport.integration.sl.se"}, http.DefaultClient)
+
+	authority, err := client.FindTransportAuthority(ctx, "waxholm")
+	if err != nil {
+		log.Fatal(err)
+	}
+	sites, err := client.Sites(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	siteID := ""
+	for _, s := range sites {
+		if s.Name == "Strömkajen" {
+			siteID = strconv.Itoa(s.ID)
+		}
+	}
+	res, err := client.Departures(ctx, &transport.DeparturesRequest{
+		SiteID:             siteID,
+		Ship:               true,
+		TransportAuthority: authority.ID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, d := range res.Departures {
+		fmt.Println(d.Line.Designation, d.Destination, d.Display)
+	}
+}
@@ -0,0 +1,47 @@
+package transport
+
+import (
+	"context"
+	"log"
+	"net/url"
+
+	"github.com/nobina/go-trafiklab/requests"
+)
+
+// Sites fetches the complete list of SL sites.
+func (c *Client) Sites(ctx context.Context) ([]*Site, error) {
+	endpoint := c.baseURL + "/v1/sites"
+
+	q := url.Values{"expand": {"false"}}
+
+	if c.isDebug {
+		log.Printf("url: %s\n", endpoint+"?"+q.Encode())
+	}
+
+	return requests.GetJSON[[]*Site](ctx, c.httpClient, endpoint, q)
+}
+
+// EachSite streams the site list, calling fn with each site as it is
+// decoded instead of holding the full list in memory. Return
+// requests.ErrStop from fn to stop early.
+func (c *Client) EachSite(ctx context.Context, fn func(*Site) error) error {
+	endpoint := c.baseURL + "/v1/sites"
+	return requests.StreamJSON(ctx, c.httpClient, endpoint, url.Values{"expand": {"false"}}, fn)
+}
+
+type Site struct {
+	ID           int       `json:"id"`
+	GID          int64     `json:"gid"`
+	Name         string    `json:"name"`
+	Abbreviation string    `json:"abbreviation"`
+	Alias        []string  `json:"alias"`
+	Lat          float64   `json:"lat"`
+	Lon          float64   `json:"lon"`
+	StopAreas    []int     `json:"stop_areas"`
+	Valid        SiteValid `json:"valid"`
+}
+
+type SiteValid struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
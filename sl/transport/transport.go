@@ -2,17 +2,22 @@ package transport
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"strconv"
+	"time"
 
+	"github.com/nobina/go-trafiklab/cache"
+	"github.com/nobina/go-trafiklab/metrics"
 	"github.com/nobina/go-trafiklab/requests"
+	"github.com/nobina/go-trafiklab/timeutils"
 )
 
+// SHIP is used by Waxholmsbolaget's archipelago boats, FERRY by SL's own
+// ferry lines such as Djurgårdsfärjan.
 const (
 	TransportModeBus   = "BUS"
 	TransportModeTram  = "TRAM"
@@ -24,20 +29,22 @@ const (
 )
 
 type Config struct {
-	BaseURL string
+	BaseURL       string
+	AllowInsecure requests.AllowInsecure
 }
 
 func (cfg *Config) Valid() error {
 	if cfg.BaseURL == "" {
 		return fmt.Errorf("missing base url")
 	}
-	return nil
+	return requests.ValidateBaseURL(cfg.BaseURL, cfg.AllowInsecure)
 }
 
 type Client struct {
 	httpClient *http.Client
 	baseURL    string
 	isDebug    bool
+	fallback   Fallback
 }
 
 func NewClient(cfg *Config, client *http.Client, options ...Option) *Client {
@@ -49,6 +56,7 @@ func NewClient(cfg *Config, client *http.Client, options ...Option) *Client {
 	for _, opt := range options {
 		opt(c)
 	}
+	c.httpClient = requests.WrapClient(c.httpClient, requests.UserAgent(""))
 	return c
 }
 
@@ -60,70 +68,112 @@ func WithDebug() Option {
 	}
 }
 
+// WithMetrics reports the requests of the client to r, e.g. a
+// metrics.LatencyTracker.
+func WithMetrics(r metrics.Recorder) Option {
+	return func(c *Client) {
+		c.httpClient = requests.WrapClient(c.httpClient, metrics.Middleware(r))
+	}
+}
+
+// Fallback serves departures when the API fails, e.g. from a local
+// timetable.
+type Fallback interface {
+	Departures(ctx context.Context, payload *DeparturesRequest) (*DepartureResponse, error)
+}
+
+// WithFallback serves departures from f when the API request fails or
+// responds with a server error. Fallback responses should set
+// ScheduleOnly.
+func WithFallback(f Fallback) Option {
+	return func(c *Client) {
+		c.fallback = f
+	}
+}
+
+// WithSitesCache caches the site list in c for ttl. Departures are never
+// cached.
+func WithSitesCache(c cache.Cache, ttl time.Duration) Option {
+	return func(cl *Client) {
+		cl.httpClient = requests.WrapClient(cl.httpClient, cache.Middleware(c, ttl, cache.PathMatcher("/v1/sites")))
+	}
+}
+
 func (c *Client) Departures(ctx context.Context, payload *DeparturesRequest) (*DepartureResponse, error) {
 	url := fmt.Sprintf("%s/v1/sites/%s/departures", c.baseURL, payload.SiteID)
 
-	req, err := requests.JSON(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
-	}
 	q := payload.params()
-	req.URL.RawQuery = q.Encode()
 
 	if c.isDebug {
-		log.Printf("url: %s\n", url+req.URL.RawQuery)
+		log.Printf("url: %s\n", url+"?"+q.Encode())
 	}
 
-	resp, err := c.httpClient.Do(req)
+	departuresResp, err := requests.GetJSON[DepartureResponse](ctx, c.httpClient, url, q, c.debugResponse())
 	if err != nil {
-		return nil, fmt.Errorf("failed request: %w", err)
+		if c.fallback == nil || ctx.Err() != nil || !fallbackOn(err) {
+			return nil, err
+		}
+		if c.isDebug {
+			log.Printf("departures failed, using fallback: %v\n", err)
+		}
+		fallbackResp, fallbackErr := c.fallback.Departures(ctx, payload)
+		if fallbackErr != nil {
+			return nil, fmt.Errorf("%w (fallback: %v)", err, fallbackErr)
+		}
+		return filterTransportTypes(fallbackResp, payload), nil
 	}
-	defer resp.Body.Close()
 
-	if c.isDebug {
-		res, err := httputil.DumpResponse(resp, true)
+	return filterTransportTypes(&departuresResp, payload), nil
+}
+
+// fallbackOn reports whether err is an outage the fallback should cover:
+// a failed request or a server error. Client errors, such as a bad key or
+// an unknown site, are the caller's to fix.
+func fallbackOn(err error) bool {
+	var apiErr *requests.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	return true
+}
+
+func (c *Client) debugResponse() requests.GetOption {
+	return requests.OnResponse(func(resp *http.Response) {
+		if !c.isDebug {
+			return
+		}
+		res, err := requests.DumpResponse(resp, 0)
 		if err != nil {
 			log.Printf("failed to dump response: %v", err)
 		} else {
 			log.Printf("response: %s\n", res)
 		}
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d, response: %v, for url: %s", resp.StatusCode, resp, url+req.URL.RawQuery)
-	}
+	})
+}
 
-	departuresResp := &DepartureResponse{}
-	err = json.NewDecoder(resp.Body).Decode(departuresResp)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w, for url: %s", err, url+req.URL.RawQuery)
-	}
+// Ping checks that the transport API responds, using the small list of
+// transport authorities.
+func (c *Client) Ping(ctx context.Context) error {
+	return requests.Ping(ctx, c.httpClient, c.baseURL+"/v1/transport-authorities", nil)
+}
 
-	departuresResp = filterTransportTypes(departuresResp, payload.Bus, payload.Metro, payload.Train, payload.Tram, payload.Ship)
-	return departuresResp, nil
+// Healthy reports whether Ping succeeds.
+func (c *Client) Healthy(ctx context.Context) bool {
+	return c.Ping(ctx) == nil
 }
 
 // The new API for SL doesn't support multiple filters so we will have to do it ourselves...
-func filterTransportTypes(res *DepartureResponse, bus, metro, train, tram, ship bool) *DepartureResponse {
-	if bus && metro && train && tram && ship {
+func filterTransportTypes(res *DepartureResponse, r *DeparturesRequest) *DepartureResponse {
+	allModes := r.Bus && r.Metro && r.Train && r.Tram && r.Ship
+	if allModes && r.TransportAuthority == 0 {
 		return res
 	}
 	var departures []*Departure
 	for _, departure := range res.Departures {
-		transportMode := departure.Line.TransportMode
-		if bus && transportMode == TransportModeBus {
-			departures = append(departures, departure)
-		}
-		if metro && transportMode == TransportModeMetro {
-			departures = append(departures, departure)
-		}
-		if train && transportMode == TransportModeTrain {
-			departures = append(departures, departure)
-		}
-		if tram && transportMode == TransportModeTram {
-			departures = append(departures, departure)
+		if r.TransportAuthority != 0 && departure.Line.TransportAuthority != 0 && departure.Line.TransportAuthority != r.TransportAuthority {
+			continue
 		}
-		if ship && transportMode == TransportModeShip {
+		if allModes || r.keepsMode(departure.Line.TransportMode) {
 			departures = append(departures, departure)
 		}
 	}
@@ -132,6 +182,24 @@ func filterTransportTypes(res *DepartureResponse, bus, metro, train, tram, ship
 	return res
 }
 
+func (r *DeparturesRequest) keepsMode(transportMode string) bool {
+	switch transportMode {
+	case TransportModeBus:
+		return r.Bus
+	case TransportModeMetro:
+		return r.Metro
+	case TransportModeTrain:
+		return r.Train
+	case TransportModeTram:
+		return r.Tram
+	case TransportModeShip:
+		return r.Ship
+	case TransportModeFerry:
+		return r.Ferry
+	}
+	return false
+}
+
 type DeparturesRequest struct {
 	SiteID   string `json:"site_id"`
 	Forecast int    `json:"time_window"`
@@ -140,6 +208,12 @@ type DeparturesRequest struct {
 	Train    bool   `json:"train"`
 	Tram     bool   `json:"tram"`
 	Ship     bool   `json:"ship"`
+	// Ferry keeps SL's own ferries when filtering by mode. Departures are
+	// only filtered if one of Bus, Metro, Train, Tram or Ship is false.
+	Ferry bool `json:"ferry"`
+	// TransportAuthority keeps only lines of one authority, e.g.
+	// Waxholmsbolaget, whether or not departures are filtered by mode.
+	TransportAuthority int `json:"transport_authority"`
 }
 
 func (r DeparturesRequest) params() url.Values {
@@ -153,6 +227,8 @@ func (r DeparturesRequest) params() url.Values {
 type DepartureResponse struct {
 	Departures     []*Departure      `json:"departures"`
 	StopDeviations []*StopDeviations `json:"stop_deviations"`
+	// ScheduleOnly is set on fallback responses without realtime data.
+	ScheduleOnly bool `json:"schedule_only,omitempty"`
 }
 type Journey struct {
 	ID              int64  `json:"id"`
@@ -172,10 +248,11 @@ type StopPoint struct {
 	Designation string `json:"designation"`
 }
 type Line struct {
-	ID            int    `json:"id"`
-	Designation   string `json:"designation"`
-	TransportMode string `json:"transport_mode"`
-	GroupOfLines  string `json:"group_of_lines"`
+	ID                 int    `json:"id"`
+	Designation        string `json:"designation"`
+	TransportAuthority int    `json:"transport_authority_id"`
+	TransportMode      string `json:"transport_mode"`
+	GroupOfLines       string `json:"group_of_lines"`
 }
 type Departure struct {
 	Direction     string               `json:"direction"`
@@ -192,6 +269,20 @@ type Departure struct {
 	Line          Line                 `json:"line"`
 	Deviations    []DepartureDeviation `json:"deviations"`
 }
+
+// ScheduledTime parses Scheduled, which is local time without offset.
+func (d *Departure) ScheduledTime() (time.Time, error) {
+	return timeutils.Parse(d.Scheduled)
+}
+
+// ExpectedTime parses Expected, falling back to the scheduled time.
+func (d *Departure) ExpectedTime() (time.Time, error) {
+	if d.Expected == "" {
+		return d.ScheduledTime()
+	}
+	return timeutils.Parse(d.Expected)
+}
+
 type StopDeviations struct {
 	Importance  int    `json:"importance"`
 	Consequence string `json:"consequence"`
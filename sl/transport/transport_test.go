@@ -0,0 +1,125 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nobina/go-trafiklab/requests"
+	"github.com/nobina/go-trafiklab/sl/transport"
+	"github.com/nobina/go-trafiklab/timeutils"
+	"github.com/nobina/go-trafiklab/vcr"
+)
+
+// waxholmsbolaget is the transport authority id of Waxholmsbolaget in the
+// test responses.
+const waxholmsbolaget = 2
+
+const departuresJSON = `{"departures": [
+	{"destination": "Slussen", "line": {"id": 2, "transport_authority_id": 1, "transport_mode": "BUS"}},
+	{"destination": "Djurgården", "line": {"id": 82, "transport_authority_id": 1, "transport_mode": "FERRY"}},
+	{"destination": "Vaxholm", "line": {"id": 9999, "transport_authority_id": 2, "transport_mode": "SHIP"}}
+]}`
+
+func departuresServer(t *testing.T) *transport.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(departuresJSON))
+	}))
+	t.Cleanup(srv.Close)
+	return transport.NewClient(&transport.Config{BaseURL: srv.URL, AllowInsecure: true}, srv.Client())
+}
+
+func destinations(res *transport.DepartureResponse) []string {
+	d := []string{}
+	for _, departure := range res.Departures {
+		d = append(d, departure.Destination)
+	}
+	return d
+}
+
+func TestDeparturesFilter(t *testing.T) {
+	client := departuresServer(t)
+	for _, tc := range []struct {
+		name string
+		req  transport.DeparturesRequest
+		want []string
+	}{
+		{
+			name: "all modes",
+			req:  transport.DeparturesRequest{Bus: true, Metro: true, Train: true, Tram: true, Ship: true},
+			want: []string{"Slussen", "Djurgården", "Vaxholm"},
+		},
+		{
+			name: "all modes of one authority",
+			req:  transport.DeparturesRequest{Bus: true, Metro: true, Train: true, Tram: true, Ship: true, TransportAuthority: waxholmsbolaget},
+			want: []string{"Vaxholm"},
+		},
+		{
+			name: "ship of one authority",
+			req:  transport.DeparturesRequest{Ship: true, TransportAuthority: waxholmsbolaget},
+			want: []string{"Vaxholm"},
+		},
+		{
+			name: "ferry and bus of SL",
+			req:  transport.DeparturesRequest{Bus: true, Ferry: true, TransportAuthority: transport.TransportAuthoritySL},
+			want: []string{"Slussen", "Djurgården"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.req.SiteID = "9220"
+			res, err := client.Departures(context.Background(), &tc.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := destinations(res)
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("got %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeparturesReplay(t *testing.T) {
+	client := transport.NewClient(&transport.Config{BaseURL: "https://transport.integration.sl.se"},
+		vcr.Golden(t, "testdata/departures.json", http.DefaultClient))
+
+	res, err := client.Departures(context.Background(), &transport.DeparturesRequest{
+		SiteID: "9001", Forecast: 60, Bus: true, Metro: true, Train: true, Tram: true, Ship: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Departures) != 2 || len(res.StopDeviations) != 1 {
+		t.Fatalf("got %d departures and %d stop deviations, want 2 and 1", len(res.Departures), len(res.StopDeviations))
+	}
+	metro := res.Departures[0]
+	if metro.Line.Designation != "17" || metro.Line.TransportMode != transport.TransportModeMetro || metro.StopPoint.Designation != "3" {
+		t.Fatalf("unexpected first departure: %+v", metro)
+	}
+	expected, err := metro.ExpectedTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 5, 6, 8, 3, 30, 0, timeutils.EuropeStockholm()); !expected.Equal(want) {
+		t.Fatalf("expected time = %s, want %s", expected, want)
+	}
+	if d := res.Departures[1].Deviations; len(d) != 1 || d[0].ImportanceLevel != 2 {
+		t.Fatalf("unexpected deviations of the bus: %+v", d)
+	}
+
+	_, err = client.Departures(context.Background(), &transport.DeparturesRequest{SiteID: "1", Forecast: 60})
+	var apiErr *requests.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("departures of an unknown site = %v, want a 404 APIError", err)
+	}
+}
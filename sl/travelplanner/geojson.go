@@ -0,0 +1,139 @@
+package travelplanner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nobina/go-trafiklab/geo"
+)
+
+// ToGeoJSON returns the trips as a feature collection with a LineString
+// per leg and a Point per stop. Legs use their polyline if requested,
+// otherwise the stops they pass.
+func (d *TripsResp) ToGeoJSON() (*geo.FeatureCollection, error) {
+	fc := geo.NewFeatureCollection()
+	for ti := range d.Trips {
+		if err := d.Trips[ti].addGeoJSON(fc, ti); err != nil {
+			return nil, fmt.Errorf("trip %d: %w", ti, err)
+		}
+	}
+	return fc, nil
+}
+
+// ToGeoJSON returns the trip as a feature collection, see
+// TripsResp.ToGeoJSON.
+func (trip *Trip) ToGeoJSON() (*geo.FeatureCollection, error) {
+	fc := geo.NewFeatureCollection()
+	if err := trip.addGeoJSON(fc, 0); err != nil {
+		return nil, err
+	}
+	return fc, nil
+}
+
+func (trip *Trip) addGeoJSON(fc *geo.FeatureCollection, tripIdx int) error {
+	for li := range trip.Legs {
+		leg := &trip.Legs[li]
+		_, dep, err := leg.Origin.ParseTime()
+		if err != nil {
+			return fmt.Errorf("failed to parse departure of leg %d: %w", li, err)
+		}
+		_, arr, err := leg.Destination.ParseTime()
+		if err != nil {
+			return fmt.Errorf("failed to parse arrival of leg %d: %w", li, err)
+		}
+
+		props := map[string]any{
+			"trip":      tripIdx,
+			"leg":       li,
+			"type":      leg.Type,
+			"mode":      leg.mode(),
+			"departure": formatGeoJSONTime(dep),
+			"arrival":   formatGeoJSONTime(arr),
+		}
+		if leg.Type == "JNY" {
+			props["line"] = leg.line()
+			props["direction"] = leg.Direction
+		}
+		fc.Features = append(fc.Features, geo.LineString(leg.path(), props))
+		fc.Features = append(fc.Features, leg.stopFeatures(tripIdx, li)...)
+	}
+	return nil
+}
+
+func (leg *Leg) mode() string {
+	if leg.Type != "JNY" {
+		return "walk"
+	}
+	if leg.Product != nil && leg.Product.CategoryOut != "" {
+		return leg.Product.CategoryOut
+	}
+	return leg.Category
+}
+
+func (leg *Leg) line() string {
+	if leg.Product != nil && leg.Product.Line != "" {
+		return leg.Product.Line
+	}
+	return leg.Name
+}
+
+func (leg *Leg) path() []geo.LatLng {
+	if leg.Polyline != nil && len(leg.Polyline.Crd) >= 4 {
+		return leg.Polyline.Path()
+	}
+	if len(leg.Stops) >= 2 {
+		path := make([]geo.LatLng, 0, len(leg.Stops))
+		for _, s := range leg.Stops {
+			path = append(path, geo.LatLng{Lat: s.Lat, Lng: s.Lon})
+		}
+		return path
+	}
+	return []geo.LatLng{
+		{Lat: leg.Origin.Lat, Lng: leg.Origin.Lon},
+		{Lat: leg.Destination.Lat, Lng: leg.Destination.Lon},
+	}
+}
+
+// stopFeatures returns the stops of the leg, or its origin and destination
+// if the stops weren't requested. Unparseable times are left out.
+func (leg *Leg) stopFeatures(tripIdx, legIdx int) []geo.Feature {
+	point := func(name, id string, lat, lon float64, arr, dep time.Time) geo.Feature {
+		props := map[string]any{
+			"trip": tripIdx,
+			"leg":  legIdx,
+			"name": name,
+			"id":   id,
+			"mode": leg.mode(),
+		}
+		if !arr.IsZero() {
+			props["arrival"] = formatGeoJSONTime(arr)
+		}
+		if !dep.IsZero() {
+			props["departure"] = formatGeoJSONTime(dep)
+		}
+		return geo.Point(geo.LatLng{Lat: lat, Lng: lon}, props)
+	}
+
+	if len(leg.Stops) == 0 {
+		_, dep, _ := leg.Origin.ParseTime()
+		_, arr, _ := leg.Destination.ParseTime()
+		return []geo.Feature{
+			point(leg.Origin.Name, leg.Origin.ExtID, leg.Origin.Lat, leg.Origin.Lon, time.Time{}, dep),
+			point(leg.Destination.Name, leg.Destination.ExtID, leg.Destination.Lat, leg.Destination.Lon, arr, time.Time{}),
+		}
+	}
+	features := make([]geo.Feature, 0, len(leg.Stops))
+	for _, s := range leg.Stops {
+		_, arr, _ := s.ParseArrival()
+		_, dep, _ := s.ParseDeparture()
+		features = append(features, point(s.Name, s.ExtId, s.Lat, s.Lon, arr, dep))
+	}
+	return features
+}
+
+func formatGeoJSONTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,119 @@
+package travelplanner
+
+import (
+	"strings"
+
+	"github.com/nobina/go-trafiklab/alerts"
+	"github.com/nobina/go-trafiklab/internal/normalize"
+)
+
+type HintKind int
+
+const (
+	HintInfo HintKind = iota
+	HintCancellation
+	HintTrackChange
+	HintDisruption
+	HintCrowding
+)
+
+func (k HintKind) String() string {
+	switch k {
+	case HintCancellation:
+		return "cancellation"
+	case HintTrackChange:
+		return "track change"
+	case HintDisruption:
+		return "disruption"
+	case HintCrowding:
+		return "crowding"
+	}
+	return "info"
+}
+
+// Hint is a classified message or note of a leg.
+type Hint struct {
+	Kind     HintKind
+	Severity alerts.Severity
+	Header   string
+	Text     string
+	// Leg is the index of the leg in the trip.
+	Leg int
+}
+
+// hintKeywords are matched against the folded text, in order, so that a
+// cancellation mentioning a delay is still a cancellation. Swedish and
+// English texts are both covered as the language follows the request.
+var hintKeywords = []struct {
+	kind     HintKind
+	keywords []string
+}{
+	{HintCancellation, []string{"installd", "installt", "stalls in", "cancelled", "canceled", "cancellation"}},
+	{HintTrackChange, []string{"sparandring", "nytt spar", "andrat spar", "andrat lage", "track change", "changed track", "platform change", "new platform"}},
+	{HintDisruption, []string{"forsen", "storning", "ersattningsbuss", "omled", "delay", "disruption", "replacement bus", "diverted"}},
+	{HintCrowding, []string{"trangt", "fullsatt", "manga resenarer", "hog belastning", "crowded", "many passengers"}},
+}
+
+func classify(header, text string) HintKind {
+	folded := " " + strings.Join(strings.Fields(normalize.Fold(header+" "+text)), " ") + " "
+	for _, k := range hintKeywords {
+		for _, kw := range k.keywords {
+			if strings.Contains(folded, " "+kw) {
+				return k.kind
+			}
+		}
+	}
+	return HintInfo
+}
+
+func hintSeverity(kind HintKind) alerts.Severity {
+	switch kind {
+	case HintCancellation:
+		return alerts.SeveritySevere
+	case HintTrackChange, HintDisruption:
+		return alerts.SeverityWarning
+	}
+	return alerts.SeverityInfo
+}
+
+// Classify returns the kind and severity of the message from its text.
+func (m Message) Classify() Hint {
+	kind := classify(m.Head, m.Text)
+	return Hint{Kind: kind, Severity: hintSeverity(kind), Header: m.Head, Text: m.Text}
+}
+
+// Classify returns the kind and severity of the note from its text.
+func (n Note) Classify() Hint {
+	kind := classify("", n.Text)
+	return Hint{Kind: kind, Severity: hintSeverity(kind), Text: n.Text}
+}
+
+// Hints classifies the messages and notes of every leg of the trip.
+func (trip *Trip) Hints() []Hint {
+	hints := []Hint{}
+	for i, leg := range trip.Legs {
+		for _, m := range leg.Messages {
+			h := m.Classify()
+			h.Leg = i
+			hints = append(hints, h)
+		}
+		for _, n := range leg.Notes {
+			h := n.Classify()
+			h.Leg = i
+			hints = append(hints, h)
+		}
+	}
+	return hints
+}
+
+// CriticalHints returns the hints of the trip that are severe, such as
+// cancellations.
+func (trip *Trip) CriticalHints() []Hint {
+	critical := []Hint{}
+	for _, h := range trip.Hints() {
+		if h.Severity == alerts.SeveritySevere {
+			critical = append(critical, h)
+		}
+	}
+	return critical
+}
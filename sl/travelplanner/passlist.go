@@ -0,0 +1,57 @@
+package travelplanner
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoPasslist is returned for journey legs without stops, which are
+// only included when the trip is requested with Passlist.
+var ErrNoPasslist = errors.New("leg has no stops, request the trip with passlist")
+
+// PassedStop is a stop of a leg with its parsed times. Arrival is zero at
+// the first stop and Departure at the last. Delays are 0 without realtime
+// data.
+type PassedStop struct {
+	Stop           Stop
+	Arrival        time.Time
+	Departure      time.Time
+	ArrivalDelay   time.Duration
+	DepartureDelay time.Duration
+}
+
+// PassedStops returns the stops of the leg from origin to destination.
+func (leg *Leg) PassedStops() ([]PassedStop, error) {
+	if len(leg.Stops) == 0 {
+		return nil, ErrNoPasslist
+	}
+	stops := make([]PassedStop, 0, len(leg.Stops))
+	for _, s := range leg.Stops {
+		ps := PassedStop{Stop: s}
+		st, rt, err := s.ParseArrival()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse arrival at %q: %w", s.Name, err)
+		}
+		ps.Arrival, ps.ArrivalDelay = rt, rt.Sub(st)
+		st, rt, err = s.ParseDeparture()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse departure at %q: %w", s.Name, err)
+		}
+		ps.Departure, ps.DepartureDelay = rt, rt.Sub(st)
+		stops = append(stops, ps)
+	}
+	return stops, nil
+}
+
+// IntermediateStops returns the stops between origin and destination.
+func (leg *Leg) IntermediateStops() ([]PassedStop, error) {
+	stops, err := leg.PassedStops()
+	if err != nil {
+		return nil, err
+	}
+	if len(stops) <= 2 {
+		return []PassedStop{}, nil
+	}
+	return stops[1 : len(stops)-1], nil
+}
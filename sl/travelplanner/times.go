@@ -0,0 +1,111 @@
+package travelplanner
+
+import (
+	"fmt"
+	"time"
+)
+
+// Effective returns the realtime time of the location if present, else
+// the planned one.
+func (l Location) Effective() (time.Time, error) {
+	_, rt, err := l.ParseTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if rt.IsZero() {
+		return time.Time{}, fmt.Errorf("location %q has no time", l.Name)
+	}
+	return rt, nil
+}
+
+// Delay is the difference between the realtime and planned time, 0
+// without realtime data.
+func (l Location) Delay() (time.Duration, error) {
+	st, rt, err := l.ParseTime()
+	if err != nil {
+		return 0, err
+	}
+	return rt.Sub(st), nil
+}
+
+// EffectiveDeparture returns the realtime departure if present, else the
+// planned one. The last stop of a journey has no departure, so its
+// arrival is used instead.
+func (s Stop) EffectiveDeparture() (time.Time, error) {
+	_, rt, err := s.ParseDeparture()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !rt.IsZero() {
+		return rt, nil
+	}
+	_, rt, err = s.ParseArrival()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if rt.IsZero() {
+		return time.Time{}, fmt.Errorf("stop %q has no time", s.Name)
+	}
+	return rt, nil
+}
+
+// EffectiveArrival returns the realtime arrival if present, else the
+// planned one. The first stop of a journey has no arrival, so its
+// departure is used instead.
+func (s Stop) EffectiveArrival() (time.Time, error) {
+	_, rt, err := s.ParseArrival()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !rt.IsZero() {
+		return rt, nil
+	}
+	_, rt, err = s.ParseDeparture()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if rt.IsZero() {
+		return time.Time{}, fmt.Errorf("stop %q has no time", s.Name)
+	}
+	return rt, nil
+}
+
+// Delay is the difference between the realtime and planned departure, or
+// arrival at the last stop, 0 without realtime data.
+func (s Stop) Delay() (time.Duration, error) {
+	st, rt, err := s.ParseDeparture()
+	if err != nil {
+		return 0, err
+	}
+	if st.IsZero() {
+		st, rt, err = s.ParseArrival()
+		if err != nil {
+			return 0, err
+		}
+	}
+	return rt.Sub(st), nil
+}
+
+// Duration returns the time from departure to arrival of the leg, using
+// realtime times where available.
+func (leg *Leg) Duration() (time.Duration, error) {
+	dep, err := leg.Origin.Effective()
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse departure: %w", err)
+	}
+	arr, err := leg.Destination.Effective()
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse arrival: %w", err)
+	}
+	return arr.Sub(dep), nil
+}
+
+// RealtimeDuration returns the time from departure to arrival of the trip,
+// using realtime times where available, unlike Duration which is planned.
+func (trip *Trip) RealtimeDuration() (time.Duration, error) {
+	dep, arr, err := trip.Times()
+	if err != nil {
+		return 0, err
+	}
+	return arr.Sub(dep), nil
+}
@@ -2,15 +2,21 @@ package travelplanner
 
 import (
 	"context"
+	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/nobina/go-trafiklab/geo"
+	"github.com/nobina/go-trafiklab/metrics"
+	"github.com/nobina/go-trafiklab/requests"
+	"github.com/nobina/go-trafiklab/slidentifiers"
 	"github.com/nobina/go-trafiklab/timeutils"
 )
 
@@ -24,8 +30,9 @@ var (
 )
 
 type TravelPlannerConfig struct {
-	APIKey  string
-	BaseURL string
+	APIKey        string
+	BaseURL       string
+	AllowInsecure requests.AllowInsecure
 }
 
 type TravelPlannerClient struct {
@@ -33,6 +40,7 @@ type TravelPlannerClient struct {
 	apiKey     string
 	baseURL    string
 	isDebug    bool
+	clock      timeutils.Clock
 }
 
 func (tc *TravelPlannerConfig) Valid() error {
@@ -42,17 +50,42 @@ func (tc *TravelPlannerConfig) Valid() error {
 	if tc.BaseURL == "" {
 		return ErrMissingBaseURL
 	}
-	return nil
+	return requests.ValidateBaseURL(tc.BaseURL, tc.AllowInsecure)
 }
 
 type Option func(*TravelPlannerClient)
 
+// WithDebug logs the url of each request, with the key redacted.
 func WithDebug() Option {
 	return func(tc *TravelPlannerClient) {
 		tc.isDebug = true
 	}
 }
 
+// WithMetrics reports the requests of the client to r, e.g. a
+// metrics.LatencyTracker.
+func WithMetrics(r metrics.Recorder) Option {
+	return func(tc *TravelPlannerClient) {
+		tc.httpClient = requests.WrapClient(tc.httpClient, metrics.Middleware(r))
+	}
+}
+
+// WithClock makes trip searches without a time search from the time of
+// clock rather than the current time of the API.
+func WithClock(clock timeutils.Clock) Option {
+	return func(tc *TravelPlannerClient) {
+		tc.clock = clock
+	}
+}
+
+// WithKeyPool rotates between the keys in pool instead of using the
+// configured api key.
+func WithKeyPool(pool *requests.KeyPool) Option {
+	return func(tc *TravelPlannerClient) {
+		tc.httpClient = requests.WrapClient(tc.httpClient, requests.RotateKeys(pool, "key"))
+	}
+}
+
 func NewTravelplannerClient(cfg *TravelPlannerConfig, client *http.Client, travelPlannerOpts ...Option) *TravelPlannerClient {
 	tc := &TravelPlannerClient{
 		httpClient: client,
@@ -63,6 +96,7 @@ func NewTravelplannerClient(cfg *TravelPlannerConfig, client *http.Client, trave
 	for _, opt := range travelPlannerOpts {
 		opt(tc)
 	}
+	tc.httpClient = requests.WrapClient(tc.httpClient, requests.UserAgent(""))
 
 	return tc
 }
@@ -99,28 +133,36 @@ func (r JourneyDetailRequest) params() url.Values {
 	return params
 }
 
-func (c *TravelPlannerClient) JourneyDetail(ctx context.Context, payload *JourneyDetailRequest) (*Leg, error) {
-	payload.key = c.apiKey
-	url := c.baseURL + travelPlannerPath + "/journeydetail.xml"
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// endpointURL returns the url of endpoint below the travel planner path of
+// the base url, keeping any query parameters already in the base url.
+func (c *TravelPlannerClient) endpointURL(endpoint string) (string, error) {
+	base, err := url.Parse(c.baseURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("invalid base url: %w", err)
 	}
-	q := payload.params()
-	req.URL.RawQuery = q.Encode()
+	return base.JoinPath(travelPlannerPath, endpoint).String(), nil
+}
+
+func (c *TravelPlannerClient) inspect() requests.GetOption {
+	return requests.OnResponse(func(resp *http.Response) {
+		if c.isDebug {
+			log.Printf("url: %s\n", requests.RedactURL(resp.Request.URL, "key"))
+		}
+	})
+}
 
-	resp, err := c.httpClient.Do(req)
+func (c *TravelPlannerClient) JourneyDetail(ctx context.Context, payload *JourneyDetailRequest) (*Leg, error) {
+	payload.key = c.apiKey
+	endpoint, err := c.endpointURL("journeydetail.xml")
 	if err != nil {
-		return nil, fmt.Errorf("failed request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	legResp := &Leg{}
-	err = xml.NewDecoder(resp.Body).Decode(legResp)
+	legResp, err := requests.GetXML[Leg](ctx, c.httpClient, endpoint, payload.params(), c.inspect())
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, err
 	}
-	return legResp, nil
+	return &legResp, nil
 }
 
 func (c *TravelPlannerClient) Reconstruction(ctx context.Context, reconstruction string) (*TripResp, error) {
@@ -128,78 +170,88 @@ func (c *TravelPlannerClient) Reconstruction(ctx context.Context, reconstruction
 		"key": {c.apiKey},
 		"ctx": {reconstruction},
 	}
-	query := queryValues.Encode()
 
-	url := c.baseURL + travelPlannerPath + "/Reconstruction.xml"
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	endpoint, err := c.endpointURL("Reconstruction.xml")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-	req.URL.RawQuery = query
 
-	resp, err := c.httpClient.Do(req)
+	tripResp, err := requests.GetXML[TripResp](ctx, c.httpClient, endpoint, queryValues, c.inspect())
 	if err != nil {
-		return nil, fmt.Errorf("failed request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	tripResp := &TripResp{}
+	return &tripResp, nil
+}
 
-	err = xml.NewDecoder(resp.Body).Decode(tripResp)
+// tripParams sets the key of payload and builds its query, searching from
+// the time of the configured clock if payload has no time.
+func (c *TravelPlannerClient) tripParams(payload *TripsRequest) (url.Values, error) {
+	payload.key = c.apiKey
+	p, err := payload.params()
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to create query: %w", err)
 	}
-
-	return tripResp, nil
+	if payload.Time.IsZero() && c.clock != nil {
+		now := c.clock.Now().In(timeutils.EuropeStockholm())
+		p.Set("date", now.Format("2006-01-02"))
+		p.Set("time", now.Format("15:04"))
+	}
+	return p, nil
 }
 
 func (c *TravelPlannerClient) Trips(ctx context.Context, payload *TripsRequest) (*TripsResp, error) {
-	payload.key = c.apiKey
-
-	url := c.baseURL + travelPlannerPath + "/trip.xml"
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	p, err := c.tripParams(payload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-	p, err := payload.params()
+	endpoint, err := c.endpointURL("trip.xml")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create query: %w", err)
+		return nil, err
 	}
-	req.URL.RawQuery = p.Encode()
 
-	resp, err := c.httpClient.Do(req)
+	tripsResp, err := requests.GetXML[TripsResp](ctx, c.httpClient, endpoint, p, c.inspect())
 	if err != nil {
-		return nil, fmt.Errorf("failed request: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		url := req.URL.String()
-		query := req.URL.Query().Encode()
-		return nil, fmt.Errorf("failed request: %w, status: %s, code: %d, tried: %s", err, resp.Status, resp.StatusCode, url+"?"+query)
+		return nil, err
 	}
 
-	tripsResp := &TripsResp{}
+	return &tripsResp, nil
+}
 
-	err = xml.NewDecoder(resp.Body).Decode(tripsResp)
+// Ping checks that the travel planner responds and accepts the key, with a
+// location lookup limited to one result rather than a trip search.
+func (c *TravelPlannerClient) Ping(ctx context.Context) error {
+	endpoint, err := c.endpointURL("location.name.xml")
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return err
 	}
-
-	if c.isDebug {
-		url := removeKeyFromQuery(*req.URL)
-		fmt.Printf("Trips: %+v\n", tripsResp)
-		fmt.Printf("URL: %s\n", url.String())
-		fmt.Printf("Query: %+v\n", url.Query())
+	q := url.Values{
+		"key":   {c.apiKey},
+		"input": {"T-Centralen"},
+		"maxNo": {"1"},
 	}
+	return requests.Ping(ctx, c.httpClient, endpoint, q, c.inspect())
+}
 
-	return tripsResp, nil
+// Healthy reports whether Ping succeeds.
+func (c *TravelPlannerClient) Healthy(ctx context.Context) bool {
+	return c.Ping(ctx) == nil
 }
 
-func removeKeyFromQuery(u url.URL) url.URL {
-	q := u.Query()
-	q.Del("key")
-	u.RawQuery = q.Encode()
-	return u
+// EachTrip streams the trips of a search, calling fn with each trip as it
+// is decoded. Useful for large responses, e.g. with gen_c or polylines.
+// Return requests.ErrStop from fn to stop early.
+func (c *TravelPlannerClient) EachTrip(ctx context.Context, payload *TripsRequest, fn func(*Trip) error) error {
+	p, err := c.tripParams(payload)
+	if err != nil {
+		return err
+	}
+	endpoint, err := c.endpointURL("trip.xml")
+	if err != nil {
+		return err
+	}
+
+	return requests.StreamXML(ctx, c.httpClient, endpoint, p, "Trip", fn, c.inspect())
 }
 
 type LegContextualFunc func(leg, prevLeg, prevTransportLeg, nextLeg, nextTransportLeg *Leg, i int) error
@@ -255,27 +307,35 @@ type TripsRequest struct {
 	Lines             []string `json:"lines"`
 	Context           string   `json:"context"`
 	Poly              bool     `json:"poly"`
-	Passlist          bool     `json:"passlist"`
-	OriginWalk        Walk     `json:"origin_walk"`
-	DestWalk          Walk     `json:"dest_walk"`
+	// Passlist includes the stops of each leg, see Leg.PassedStops. Leave
+	// it off to cut the response size when they aren't needed.
+	Passlist   bool `json:"passlist"`
+	OriginWalk Walk `json:"origin_walk"`
+	DestWalk   Walk `json:"dest_walk"`
 }
 
 // When SL updated their domain they broke their id system.
 // We now have to have this flaky conversion function until we've updated
 // their other major breaking changes.
 func convertIDToHafas(sid string) (string, error) {
-	if len(sid) > 7 {
+	switch slidentifiers.DetectKind(sid) {
+	case slidentifiers.KindSiteID:
+	case slidentifiers.KindUnknown:
+		// A 16 digit id is meant as a GID, and is rejected by the API
+		// with an opaque error if its prefix isn't one we know.
+		if slidentifiers.HasGIDFormat(sid) {
+			return "", fmt.Errorf("GID prefix not registered: %s", sid)
+		}
+		return sid, nil
+	default:
+		// HAFAS ids and EFA GIDs are passed on unchanged.
 		return sid, nil
 	}
-	id, err := strconv.Atoi(sid)
+	hafasID, err := slidentifiers.Normalize(sid, slidentifiers.KindHAFAS)
 	if err != nil {
 		return "", fmt.Errorf("failed to convert id to hafas: %w", err)
 	}
-
-	// Extract the first two digits and the last five digits of the ID
-	firstTwoDigits := id / 100000
-	lastFiveDigits := id % 100000
-	return fmt.Sprintf("3%02d1%05d", firstTwoDigits, lastFiveDigits), nil
+	return hafasID, nil
 }
 
 func (r TripsRequest) params() (url.Values, error) {
@@ -475,6 +535,17 @@ type LegResp struct {
 	ScrF       string `json:"scr_f" xml:"scrF,attr"`
 }
 
+// DropInvalid removes the trips that are not Valid.
+func (d *TripsResp) DropInvalid() {
+	trips := d.Trips[:0]
+	for _, t := range d.Trips {
+		if t.Valid {
+			trips = append(trips, t)
+		}
+	}
+	d.Trips = trips
+}
+
 func (d *TripsResp) CombineWalks() {
 	for ti := range d.Trips {
 		d.Trips[ti].CombineWalks()
@@ -482,17 +553,72 @@ func (d *TripsResp) CombineWalks() {
 }
 
 type Trip struct {
-	Idx         string        `json:"idx" xml:"idx,attr"`
-	CtxRecon    string        `json:"ctx_recon" xml:"ctxRecon,attr"`
-	Checksum    string        `json:"checksum" xml:"checksum,attr"`
-	TripID      string        `json:"trip_id" xml:"tripId,attr"`
-	Valid       string        `json:"valid,string" xml:"valid,attr"`
+	Idx      string `json:"idx" xml:"idx,attr"`
+	CtxRecon string `json:"ctx_recon" xml:"ctxRecon,attr"`
+	// Checksum is a hash HAFAS computes over the trip. Its algorithm is not
+	// published, so it can only be compared, not verified.
+	Checksum string `json:"checksum" xml:"checksum,attr"`
+	TripID   string `json:"trip_id" xml:"tripId,attr"`
+	// Valid is false for trips HAFAS marks as not feasible, e.g. because
+	// of a missed change. Trips without the attribute are valid.
+	Valid       bool          `json:"valid" xml:"valid,attr"`
 	Duration    string        `json:"duration" xml:"duration,attr"`
 	ServiceDays []ServiceDay  `json:"service_days"`
 	Legs        []Leg         `json:"legs" xml:"LegList>Leg"`
 	Tariff      []FareSetItem `json:"tariff,omitempty" xml:"TariffResult>fareSetItem"`
 }
 
+// UnmarshalXML defaults Valid to true, as the attribute is not always
+// sent.
+func (trip *Trip) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain Trip
+	p := plain{Valid: true}
+	if err := d.DecodeElement(&p, &start); err != nil {
+		return err
+	}
+	*trip = Trip(p)
+	return nil
+}
+
+// UnmarshalJSON defaults Valid to true, like UnmarshalXML.
+func (trip *Trip) UnmarshalJSON(b []byte) error {
+	type plain Trip
+	p := plain{Valid: true}
+	if err := json.Unmarshal(b, &p); err != nil {
+		return err
+	}
+	*trip = Trip(p)
+	return nil
+}
+
+// Times returns the departure of the first leg and the arrival of the
+// last, using realtime times where available.
+func (trip *Trip) Times() (dep, arr time.Time, err error) {
+	if len(trip.Legs) == 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("trip has no legs")
+	}
+	_, dep, err = trip.Legs[0].Origin.ParseTime()
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to parse departure: %w", err)
+	}
+	_, arr, err = trip.Legs[len(trip.Legs)-1].Destination.ParseTime()
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to parse arrival: %w", err)
+	}
+	return dep, arr, nil
+}
+
+// Changes returns the number of changes between vehicles.
+func (trip *Trip) Changes() int {
+	rides := 0
+	for _, leg := range trip.Legs {
+		if leg.Type == "JNY" {
+			rides++
+		}
+	}
+	return max(rides-1, 0)
+}
+
 func (trip *Trip) EachLegContextual(fn LegContextualFunc) error {
 	if len(trip.Legs) == 0 {
 		return nil
@@ -577,13 +703,75 @@ func (trip *Trip) CombineWalks() {
 }
 
 type ServiceDay struct {
-	SDaysR              string `json:"s_days_r" xml:"sDaysR,attr"`
-	SDaysI              string `json:"s_days_i" xml:"sDaysI,attr"`
-	SDaysB              string `json:"s_days_b" xml:"sDaysB,attr"`
+	SDaysR string `json:"s_days_r" xml:"sDaysR,attr"`
+	SDaysI string `json:"s_days_i" xml:"sDaysI,attr"`
+	SDaysB string `json:"s_days_b" xml:"sDaysB,attr"`
+	// PlanningPeriodBegin is sent misspelled as planningPeriodBeing. The
+	// correct spelling is accepted too, see UnmarshalXML.
 	PlanningPeriodBegin string `json:"planning_period_being" xml:"planningPeriodBeing,attr"`
 	PlanningPeriodEnd   string `json:"planning_period_end" xml:"planningPeriodEnd,attr"`
 }
 
+// UnmarshalXML falls back to planningPeriodBegin, in case the API fixes
+// the spelling.
+func (s *ServiceDay) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain ServiceDay
+	if err := d.DecodeElement((*plain)(s), &start); err != nil {
+		return err
+	}
+	if s.PlanningPeriodBegin != "" {
+		return nil
+	}
+	for _, a := range start.Attr {
+		if a.Name.Local == "planningPeriodBegin" {
+			s.PlanningPeriodBegin = a.Value
+		}
+	}
+	return nil
+}
+
+// Period returns the first and last service day of the planning period.
+func (s ServiceDay) Period() (begin, end time.Time, err error) {
+	begin, err = timeutils.ParseServiceDate(s.PlanningPeriodBegin)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err = timeutils.ParseServiceDate(s.PlanningPeriodEnd)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return begin, end, nil
+}
+
+// Days decodes the sDaysB bitmask over the planning period.
+func (s ServiceDay) Days() (timeutils.DayBitmask, error) {
+	begin, end, err := s.Period()
+	if err != nil {
+		return timeutils.DayBitmask{}, err
+	}
+	return timeutils.ParseDayBitmask(s.SDaysB, begin, end)
+}
+
+// Dates returns the operating days within the planning period, as
+// midnight in Stockholm.
+func (s ServiceDay) Dates() ([]time.Time, error) {
+	days, err := s.Days()
+	if err != nil {
+		return nil, err
+	}
+	return days.Dates(), nil
+}
+
+// RunsOn reports whether the journey operates on the day of date. It is
+// false if the bitmask can't be decoded.
+func (s ServiceDay) RunsOn(date time.Time) bool {
+	days, err := s.Days()
+	if err != nil {
+		return false
+	}
+	return days.RunsOn(date)
+}
+
 type Leg struct {
 	Distance      int           `json:"distance" xml:"dist,attr"`
 	Type          string        `json:"type" xml:"type,attr"`
@@ -625,14 +813,14 @@ type Location struct {
 
 func (l Location) ParseTime() (st time.Time, rt time.Time, err error) {
 	if l.Date != "" && l.Time != "" {
-		st, err = time.ParseInLocation("2006-01-02 15:04:05", l.Date+" "+l.Time, timeutils.EuropeStockholm())
+		st, err = timeutils.Parse(l.Date + " " + l.Time)
 		if err != nil {
 			return
 		}
 	}
 
 	if l.RtDate != "" && l.RtTime != "" {
-		rt, err = time.ParseInLocation("2006-01-02 15:04:05", l.RtDate+" "+l.RtTime, timeutils.EuropeStockholm())
+		rt, err = timeutils.Parse(l.RtDate + " " + l.RtTime)
 		if err != nil {
 			return
 		}
@@ -709,6 +897,22 @@ func (p Polyline) LatLng() [][]float64 {
 	return path
 }
 
+// Path returns the decoded coordinates as points.
+func (p Polyline) Path() []geo.LatLng {
+	coords := p.LatLng()
+	path := make([]geo.LatLng, 0, len(coords))
+	for _, c := range coords {
+		path = append(path, geo.LatLng{Lat: c[0], Lng: c[1]})
+	}
+	return path
+}
+
+// Simplified returns the path reduced to points deviating more than
+// tolerance meters, e.g. for overview maps.
+func (p Polyline) Simplified(tolerance float64) []geo.LatLng {
+	return geo.Simplify(p.Path(), tolerance)
+}
+
 type Stop struct {
 	DepartureDate   string  `json:"departure_date" xml:"depDate,attr"`
 	RtDepartureDate string  `json:"rt_departure_date" xml:"rtDepDate,attr"`
@@ -733,14 +937,14 @@ type Stop struct {
 
 func (s Stop) ParseArrival() (st time.Time, rt time.Time, err error) {
 	if s.ArrivalDate != "" && s.ArrivalTime != "" {
-		st, err = time.ParseInLocation("2006-01-02 15:04:05", s.ArrivalDate+" "+s.ArrivalTime, timeutils.EuropeStockholm())
+		st, err = timeutils.Parse(s.ArrivalDate + " " + s.ArrivalTime)
 		if err != nil {
 			return
 		}
 	}
 
 	if s.RtArrivalDate != "" && s.RtArrivalTime != "" {
-		rt, err = time.ParseInLocation("2006-01-02 15:04:05", s.RtArrivalDate+" "+s.RtArrivalTime, timeutils.EuropeStockholm())
+		rt, err = timeutils.Parse(s.RtArrivalDate + " " + s.RtArrivalTime)
 		if err != nil {
 			return
 		}
@@ -755,14 +959,14 @@ func (s Stop) ParseArrival() (st time.Time, rt time.Time, err error) {
 
 func (s Stop) ParseDeparture() (st time.Time, rt time.Time, err error) {
 	if s.DepartureDate != "" && s.DepartureTime != "" {
-		st, err = time.ParseInLocation("2006-01-02 15:04:05", s.DepartureDate+" "+s.DepartureTime, timeutils.EuropeStockholm())
+		st, err = timeutils.Parse(s.DepartureDate + " " + s.DepartureTime)
 		if err != nil {
 			return
 		}
 	}
 
 	if s.RtDepartureDate != "" && s.RtDepartureTime != "" {
-		rt, err = time.ParseInLocation("2006-01-02 15:04:05", s.RtDepartureDate+" "+s.RtDepartureTime, timeutils.EuropeStockholm())
+		rt, err = timeutils.Parse(s.RtDepartureDate + " " + s.RtDepartureTime)
 		if err != nil {
 			return
 		}
@@ -0,0 +1,55 @@
+package travelplanner_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nobina/go-trafiklab/sl/travelplanner"
+	"github.com/nobina/go-trafiklab/timeutils"
+	"github.com/nobina/go-trafiklab/vcr"
+)
+
+func TestTripsReplay(t *testing.T) {
+	client := travelplanner.NewTravelplannerClient(
+		&travelplanner.TravelPlannerConfig{APIKey: "key", BaseURL: "https://journeyplanner.integration.sl.se"},
+		vcr.Golden(t, "testdata/trips.json", http.DefaultClient))
+
+	res, err := client.Trips(context.Background(), &travelplanner.TripsRequest{OriginID: "9001", DestID: "9192"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Trips) != 2 || res.ScrF == "" {
+		t.Fatalf("got %d trips and scrF %q, want 2 trips and a scrF", len(res.Trips), res.ScrF)
+	}
+	trip := res.Trips[0]
+	if !trip.Valid || trip.Checksum != "a7c3c9c0_3" || len(trip.Legs) != 1 {
+		t.Fatalf("unexpected first trip: %+v", trip)
+	}
+	leg := trip.Legs[0]
+	if leg.Product == nil || leg.Product.Num != 17 || leg.JourneyDetail.Ref == "" {
+		t.Fatalf("unexpected leg: %+v", leg)
+	}
+	if leg.Origin.MainMastExtID != "300109001" || leg.Destination.MainMastExtID != "300109192" {
+		t.Fatalf("unexpected leg stops: %s to %s", leg.Origin.MainMastExtID, leg.Destination.MainMastExtID)
+	}
+	planned, realtime, err := leg.Origin.ParseTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 5, 6, 8, 2, 0, 0, timeutils.EuropeStockholm()); !planned.Equal(want) || realtime.Sub(planned) != time.Minute {
+		t.Fatalf("origin times = %s, %s", planned, realtime)
+	}
+	if len(trip.ServiceDays) != 1 || trip.ServiceDays[0].PlanningPeriodBegin != "2023-12-10" {
+		t.Fatalf("unexpected service days: %+v", trip.ServiceDays)
+	}
+
+	if res.Trips[1].Valid || !res.Trips[1].Legs[0].Cancelled {
+		t.Fatalf("second trip should be invalid and cancelled: %+v", res.Trips[1])
+	}
+	res.DropInvalid()
+	if len(res.Trips) != 1 {
+		t.Fatalf("DropInvalid kept %d trips, want 1", len(res.Trips))
+	}
+}
@@ -0,0 +1,78 @@
+package slidentifiers
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// GID is a validated EFA GID. The zero value is not a valid GID.
+type GID struct {
+	raw       string
+	authority string
+	entity    EntityType
+	number    int
+}
+
+// Parse validates s as an EFA GID with a prefix known to DefaultRegistry.
+func Parse(s string) (GID, error) {
+	return DefaultRegistry.Parse(s)
+}
+
+// HasGIDFormat reports whether s has the length and digits of an EFA GID,
+// whether or not its prefix is known. Used to reject malformed GIDs that
+// DetectKind reports as unknown.
+func HasGIDFormat(s string) bool {
+	return len(s) == efaLength && isDigits(s)
+}
+
+func (r *Registry) Parse(s string) (GID, error) {
+	authority, entity, id, err := r.FromEFA(s)
+	if err != nil {
+		return GID{}, err
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return GID{}, fmt.Errorf("invalid efa gid: %q: %w", s, err)
+	}
+	return GID{
+		raw:       s,
+		authority: authority,
+		entity:    entity,
+		number:    n,
+	}, nil
+}
+
+func (g GID) String() string {
+	return g.raw
+}
+
+func (g GID) IsZero() bool {
+	return g.raw == ""
+}
+
+func (g GID) Authority() string {
+	return g.authority
+}
+
+func (g GID) EntityType() EntityType {
+	return g.entity
+}
+
+// SiteNumber returns the number of the entity, e.g. 9192 for the site GID
+// 9091001000009192.
+func (g GID) SiteNumber() int {
+	return g.number
+}
+
+func (g GID) MarshalText() ([]byte, error) {
+	return []byte(g.raw), nil
+}
+
+func (g *GID) UnmarshalText(b []byte) error {
+	parsed, err := Parse(string(b))
+	if err != nil {
+		return err
+	}
+	*g = parsed
+	return nil
+}
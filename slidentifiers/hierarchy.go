@@ -0,0 +1,131 @@
+package slidentifiers
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// Hierarchy resolves the parents and children of stop points, stop areas
+// and sites from a mapping source, since the levels can't be derived from
+// the GIDs alone.
+type Hierarchy struct {
+	mu       sync.RWMutex
+	parent   map[string]string
+	children map[string][]string
+}
+
+func NewHierarchy() *Hierarchy {
+	return &Hierarchy{
+		parent:   map[string]string{},
+		children: map[string][]string{},
+	}
+}
+
+// Add registers parent as the parent of child. Both are GIDs.
+func (h *Hierarchy) Add(child, parent string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if old, ok := h.parent[child]; ok {
+		if old == parent {
+			return
+		}
+		h.children[old] = remove(h.children[old], child)
+	}
+	h.parent[child] = parent
+	h.children[parent] = append(h.children[parent], child)
+}
+
+func remove(ids []string, id string) []string {
+	for i, v := range ids {
+		if v == id {
+			return append(ids[:i], ids[i+1:]...)
+		}
+	}
+	return ids
+}
+
+// AddSiteStopAreas registers the stop areas of an SL site, as listed by the
+// SL Transport sites endpoint.
+func (h *Hierarchy) AddSiteStopAreas(siteID string, stopAreas []int) error {
+	siteGID, err := ConvertSiteIDToEFA(siteID, EntitySite)
+	if err != nil {
+		return err
+	}
+	for _, sa := range stopAreas {
+		areaGID, err := ConvertSiteIDToEFA(strconv.Itoa(sa), EntityStopArea)
+		if err != nil {
+			return err
+		}
+		h.Add(areaGID, siteGID)
+	}
+	return nil
+}
+
+// LoadGTFSStops registers the parent_station of every stop in a GTFS
+// stops.txt.
+func (h *Hierarchy) LoadGTFSStops(r io.Reader) error {
+	return readCSV(r, func(row map[string]string) error {
+		if row["stop_id"] != "" && row["parent_station"] != "" {
+			h.Add(row["stop_id"], row["parent_station"])
+		}
+		return nil
+	}, "stop_id", "parent_station")
+}
+
+// Parent returns the registered parent of gid.
+func (h *Hierarchy) Parent(gid string) (string, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	p, ok := h.parent[gid]
+	return p, ok
+}
+
+// Site walks up from gid until it reaches a site.
+func (h *Hierarchy) Site(gid string) (string, error) {
+	current := gid
+	// guard against cycles in the mapping source
+	for i := 0; i < 4; i++ {
+		_, entity, _, err := DefaultRegistry.FromEFA(current)
+		if err == nil && entity == EntitySite {
+			return current, nil
+		}
+		parent, ok := h.Parent(current)
+		if !ok {
+			break
+		}
+		current = parent
+	}
+	return "", fmt.Errorf("no site found for %q", gid)
+}
+
+// Children returns the registered children of gid.
+func (h *Hierarchy) Children(gid string) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]string(nil), h.children[gid]...)
+}
+
+// StopPoints returns all stop points below gid, e.g. every stop point of a
+// site.
+func (h *Hierarchy) StopPoints(gid string) []string {
+	stopPoints := []string{}
+	seen := map[string]bool{gid: true}
+	queue := []string{gid}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range h.Children(current) {
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			if _, entity, _, err := DefaultRegistry.FromEFA(child); err == nil && entity == EntityStopPoint {
+				stopPoints = append(stopPoints, child)
+			}
+			queue = append(queue, child)
+		}
+	}
+	return stopPoints
+}
@@ -0,0 +1,76 @@
+package slidentifiers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+// JourneyID is a HAFAS journey detail id, e.g. 1|33724|0|74|6022020, where
+// the last field is the service day as DMMYYYY without zero padding of the
+// day. The other fields are kept as is.
+type JourneyID struct {
+	Kind     string
+	Number   string
+	Variant  string
+	Operator string
+	Date     time.Time
+}
+
+func ParseJourneyID(s string) (JourneyID, error) {
+	parts := strings.Split(s, "|")
+	if len(parts) != 5 {
+		return JourneyID{}, fmt.Errorf("invalid journey id: %q", s)
+	}
+	for _, p := range parts {
+		if !isDigits(p) {
+			return JourneyID{}, fmt.Errorf("invalid journey id: %q", s)
+		}
+	}
+
+	date, err := parseJourneyDate(parts[4])
+	if err != nil {
+		return JourneyID{}, fmt.Errorf("invalid journey id: %q: %w", s, err)
+	}
+
+	return JourneyID{
+		Kind:     parts[0],
+		Number:   parts[1],
+		Variant:  parts[2],
+		Operator: parts[3],
+		Date:     date,
+	}, nil
+}
+
+func parseJourneyDate(s string) (time.Time, error) {
+	if len(s) != 7 && len(s) != 8 {
+		return time.Time{}, fmt.Errorf("invalid date: %q", s)
+	}
+	day, _ := strconv.Atoi(s[:len(s)-6])
+	month, _ := strconv.Atoi(s[len(s)-6 : len(s)-4])
+	year, _ := strconv.Atoi(s[len(s)-4:])
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, fmt.Errorf("invalid date: %q", s)
+	}
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, timeutils.EuropeStockholm())
+	if t.Day() != day {
+		return time.Time{}, fmt.Errorf("invalid date: %q", s)
+	}
+	return t, nil
+}
+
+func (j JourneyID) String() string {
+	d := j.Date.In(timeutils.EuropeStockholm())
+	return fmt.Sprintf("%s|%s|%s|%s|%d%02d%04d", j.Kind, j.Number, j.Variant, j.Operator, d.Day(), int(d.Month()), d.Year())
+}
+
+// OnDate returns the same journey on the service day of t, for rebuilding
+// stored references for another day.
+func (j JourneyID) OnDate(t time.Time) JourneyID {
+	t = t.In(timeutils.EuropeStockholm())
+	j.Date = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, timeutils.EuropeStockholm())
+	return j
+}
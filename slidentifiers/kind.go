@@ -0,0 +1,101 @@
+package slidentifiers
+
+import (
+	"fmt"
+	"strings"
+)
+
+type IDKind int
+
+const (
+	KindUnknown IDKind = iota
+	KindSiteID
+	KindHAFAS
+	KindEFA
+	KindJourney
+	KindResRobot
+)
+
+func (k IDKind) String() string {
+	switch k {
+	case KindSiteID:
+		return "site id"
+	case KindHAFAS:
+		return "hafas id"
+	case KindEFA:
+		return "efa gid"
+	case KindJourney:
+		return "journey id"
+	case KindResRobot:
+		return "resrobot id"
+	}
+	return "unknown"
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// DetectKind guesses the kind of id from its format. EFA GIDs are only
+// detected if their prefix is registered in DefaultRegistry.
+func DetectKind(id string) IDKind {
+	if _, err := ParseJourneyID(id); err == nil {
+		return KindJourney
+	}
+	if !isDigits(id) {
+		return KindUnknown
+	}
+	switch {
+	case len(id) <= 7:
+		return KindSiteID
+	case len(id) == hafasLength && id[0] == '3' && id[3] == '1':
+		return KindHAFAS
+	case len(id) == hafasLength && strings.HasPrefix(id, resRobotPrefix):
+		return KindResRobot
+	case len(id) == efaLength:
+		if _, err := Parse(id); err == nil {
+			return KindEFA
+		}
+	}
+	return KindUnknown
+}
+
+// Normalize converts id to target, detecting the kind of id first. Site
+// ids are converted to and from site EFA GIDs.
+func Normalize(id string, target IDKind) (string, error) {
+	kind := DetectKind(id)
+	if kind == target {
+		return id, nil
+	}
+
+	switch {
+	case kind == KindSiteID && target == KindHAFAS:
+		return ConvertSiteIDToHAFAS(id)
+	case kind == KindSiteID && target == KindEFA:
+		return ConvertSiteIDToEFA(id, EntitySite)
+	case kind == KindHAFAS && target == KindSiteID:
+		return ConvertHAFASToSiteID(id)
+	case kind == KindHAFAS && target == KindEFA:
+		return ConvertHAFASToEFA(id)
+	case kind == KindEFA && target == KindHAFAS:
+		return ConvertEFAToHAFAS(id)
+	case kind == KindEFA && target == KindSiteID:
+		siteID, entity, err := ConvertEFAToSiteID(id)
+		if err != nil {
+			return "", err
+		}
+		if entity != EntitySite {
+			return "", fmt.Errorf("efa gid %q is a %s, not a site", id, entity)
+		}
+		return siteID, nil
+	}
+	return "", fmt.Errorf("can't convert %s %q to %s", kind, id, target)
+}
@@ -0,0 +1,162 @@
+package slidentifiers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// MappingTable verifies algorithmic conversions against published ids,
+// e.g. the stop ids in a GTFS stops.txt, and corrects known mismatches
+// through explicit overrides.
+type MappingTable struct {
+	mu        sync.RWMutex
+	published map[string]bool
+	overrides map[string]string
+}
+
+func NewMappingTable() *MappingTable {
+	return &MappingTable{
+		published: map[string]bool{},
+		overrides: map[string]string{},
+	}
+}
+
+// AddPublished marks gid as a published id.
+func (m *MappingTable) AddPublished(gid string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.published[gid] = true
+}
+
+// AddOverride maps hafasID to gid regardless of the algorithmic conversion.
+func (m *MappingTable) AddOverride(hafasID, gid string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.overrides[hafasID] = gid
+	m.published[gid] = true
+}
+
+func (m *MappingTable) Published(gid string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.published[gid]
+}
+
+func (m *MappingTable) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.published)
+}
+
+// LoadGTFSStops adds the stop_id and parent_station columns of a GTFS
+// stops.txt as published ids.
+func (m *MappingTable) LoadGTFSStops(r io.Reader) error {
+	return readCSV(r, func(row map[string]string) error {
+		if id := row["stop_id"]; id != "" {
+			m.AddPublished(id)
+		}
+		if id := row["parent_station"]; id != "" {
+			m.AddPublished(id)
+		}
+		return nil
+	}, "stop_id")
+}
+
+// LoadOverrides reads a CSV with hafas_id and gid columns.
+func (m *MappingTable) LoadOverrides(r io.Reader) error {
+	return readCSV(r, func(row map[string]string) error {
+		if row["hafas_id"] == "" || row["gid"] == "" {
+			return errors.New("missing hafas_id or gid")
+		}
+		m.AddOverride(row["hafas_id"], row["gid"])
+		return nil
+	}, "hafas_id", "gid")
+}
+
+func readCSV(r io.Reader, fn func(row map[string]string) error, required ...string) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read header: %w", err)
+	}
+	if len(header) > 0 {
+		// GTFS files are often saved with a byte order mark
+		header[0] = trimBOM(header[0])
+	}
+	columns := map[string]int{}
+	for i, h := range header {
+		columns[h] = i
+	}
+	for _, r := range required {
+		if _, ok := columns[r]; !ok {
+			return fmt.Errorf("missing column: %s", r)
+		}
+	}
+
+	line := 1
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		line++
+		if err != nil {
+			return fmt.Errorf("failed to read line %d: %w", line, err)
+		}
+		row := map[string]string{}
+		for name, i := range columns {
+			if i < len(record) {
+				row[name] = record[i]
+			}
+		}
+		if err := fn(row); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+func trimBOM(s string) string {
+	return strings.TrimPrefix(s, "\ufeff")
+}
+
+type Verification struct {
+	HAFAS string
+	GID   string
+	// Verified is set if GID is a published id.
+	Verified bool
+	// Corrected is set if GID comes from an override rather than the
+	// algorithmic conversion.
+	Corrected bool
+	// Mismatch is set if an override disagrees with the algorithmic
+	// conversion.
+	Mismatch bool
+}
+
+// HAFASToEFA converts hafasID to a site GID and verifies the result.
+func (m *MappingTable) HAFASToEFA(hafasID string) (Verification, error) {
+	v := Verification{HAFAS: hafasID}
+	gid, convErr := ConvertHAFASToEFA(hafasID)
+
+	m.mu.RLock()
+	override, ok := m.overrides[hafasID]
+	m.mu.RUnlock()
+	if ok {
+		v.GID = override
+		v.Verified = true
+		v.Corrected = true
+		v.Mismatch = convErr != nil || gid != override
+		return v, nil
+	}
+
+	if convErr != nil {
+		return v, convErr
+	}
+	v.GID = gid
+	v.Verified = m.Published(gid)
+	return v, nil
+}
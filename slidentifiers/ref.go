@@ -0,0 +1,76 @@
+package slidentifiers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resRobotPrefix starts the 9 digit national stop ids used by ResRobot,
+// e.g. 740000001 for Stockholm Centralstation.
+const resRobotPrefix = "740"
+
+// StopRef identifies a stop across APIs, so stops from SL and ResRobot
+// can be stored side by side. Its text form is kind:id, e.g.
+// efa:9091001000009192 or resrobot:740000001.
+type StopRef struct {
+	Kind IDKind
+	ID   string
+}
+
+var refPrefixes = map[IDKind]string{
+	KindSiteID:   "site",
+	KindHAFAS:    "hafas",
+	KindEFA:      "efa",
+	KindResRobot: "resrobot",
+}
+
+// NewStopRef detects the kind of id.
+func NewStopRef(id string) (StopRef, error) {
+	kind := DetectKind(id)
+	if _, ok := refPrefixes[kind]; !ok {
+		return StopRef{}, fmt.Errorf("unknown stop id %q", id)
+	}
+	return StopRef{Kind: kind, ID: id}, nil
+}
+
+func ParseStopRef(s string) (StopRef, error) {
+	prefix, id, ok := strings.Cut(s, ":")
+	if !ok {
+		return NewStopRef(s)
+	}
+	for kind, p := range refPrefixes {
+		if p == prefix {
+			return StopRef{Kind: kind, ID: id}, nil
+		}
+	}
+	return StopRef{}, fmt.Errorf("unknown stop ref kind %q", prefix)
+}
+
+func (r StopRef) String() string {
+	return refPrefixes[r.Kind] + ":" + r.ID
+}
+
+// SL returns the ref as an SL EFA GID. ResRobot refs can't be converted.
+func (r StopRef) SL() (StopRef, error) {
+	if r.Kind == KindResRobot {
+		return StopRef{}, fmt.Errorf("can't convert %s to an SL id", r)
+	}
+	gid, err := Normalize(r.ID, KindEFA)
+	if err != nil {
+		return StopRef{}, err
+	}
+	return StopRef{Kind: KindEFA, ID: gid}, nil
+}
+
+func (r StopRef) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *StopRef) UnmarshalText(b []byte) error {
+	ref, err := ParseStopRef(string(b))
+	if err != nil {
+		return err
+	}
+	*r = ref
+	return nil
+}
@@ -0,0 +1,74 @@
+package slidentifiers
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Region describes an EFA journey planner deployment by the GID prefixes
+// of its transport authority.
+type Region struct {
+	Name      string
+	Authority string
+	Prefixes  map[EntityType]string
+}
+
+// RegionSL is the SL deployment, used by default.
+var RegionSL = Region{
+	Name:      "sl",
+	Authority: AuthoritySL,
+	Prefixes: map[EntityType]string{
+		EntitySite:      PrefixSLSite,
+		EntityStopArea:  PrefixSLStopArea,
+		EntityStopPoint: PrefixSLStopPoint,
+	},
+}
+
+var (
+	regionsMu sync.RWMutex
+	regions   = map[string]Region{RegionSL.Name: RegionSL}
+)
+
+// RegisterRegion adds or replaces a region by name and registers its
+// prefixes in DefaultRegistry, so GIDs of the region can be parsed.
+func RegisterRegion(region Region) error {
+	if region.Name == "" || region.Authority == "" {
+		return fmt.Errorf("region needs a name and an authority")
+	}
+	if err := DefaultRegistry.RegisterRegion(region); err != nil {
+		return err
+	}
+	regionsMu.Lock()
+	defer regionsMu.Unlock()
+	regions[region.Name] = region
+	return nil
+}
+
+// LookupRegion returns a registered region by name.
+func LookupRegion(name string) (Region, bool) {
+	regionsMu.RLock()
+	defer regionsMu.RUnlock()
+	r, ok := regions[name]
+	return r, ok
+}
+
+// RegisterRegion registers the prefixes of region under its authority.
+// Prefixes must be digits only and leave room for at least one digit of
+// the entity number.
+func (r *Registry) RegisterRegion(region Region) error {
+	for entity, prefix := range region.Prefixes {
+		if !isDigits(prefix) || len(prefix) >= efaLength || entity == EntityUnknown {
+			return fmt.Errorf("region %s: invalid %s prefix: %q", region.Name, entity, prefix)
+		}
+	}
+	for entity, prefix := range region.Prefixes {
+		r.Register(region.Authority, entity, prefix)
+	}
+	return nil
+}
+
+// ToEFA converts a stop id of the region to a GID with DefaultRegistry,
+// where RegisterRegion registers the prefixes of the region.
+func (region Region) ToEFA(entity EntityType, id string) (string, error) {
+	return DefaultRegistry.ToEFA(region.Authority, entity, id)
+}
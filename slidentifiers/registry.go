@@ -0,0 +1,112 @@
+package slidentifiers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Stop areas and stop points are numbered per county in the national stop
+// register: 9021 or 9022 followed by the authority code, which is the
+// county code, e.g. 001 for Stockholm and 003 for Uppsala. Sites only
+// exist in the Stockholm range.
+const (
+	PrefixSLSite      = "909100100"
+	PrefixSLStopArea  = "9021001"
+	PrefixSLStopPoint = "9022001"
+
+	PrefixULStopArea  = "9021003"
+	PrefixULStopPoint = "9022003"
+)
+
+type registryKey struct {
+	authority string
+	entity    EntityType
+}
+
+// Registry maps transport authorities and entity types to EFA GID
+// prefixes. The number of the entity fills the remaining digits of the
+// 16 digit GID.
+type Registry struct {
+	mu       sync.RWMutex
+	prefixes map[registryKey]string
+}
+
+// DefaultRegistry is used by the package level conversion functions.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry returns a registry with the prefixes of SL, which
+// Waxholmsbolaget shares, and UL registered.
+func NewRegistry() *Registry {
+	r := &Registry{
+		prefixes: map[registryKey]string{},
+	}
+	r.Register(AuthoritySL, EntitySite, PrefixSLSite)
+	r.Register(AuthoritySL, EntityStopArea, PrefixSLStopArea)
+	r.Register(AuthoritySL, EntityStopPoint, PrefixSLStopPoint)
+	r.Register(AuthorityUL, EntityStopArea, PrefixULStopArea)
+	r.Register(AuthorityUL, EntityStopPoint, PrefixULStopPoint)
+	return r
+}
+
+// Register adds or overrides the prefix for authority and entity.
+func (r *Registry) Register(authority string, entity EntityType, prefix string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prefixes[registryKey{authority: authority, entity: entity}] = prefix
+}
+
+func (r *Registry) Prefix(authority string, entity EntityType) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.prefixes[registryKey{authority: authority, entity: entity}]
+	return p, ok
+}
+
+// ToEFA converts the id of an entity belonging to authority to an EFA GID.
+func (r *Registry) ToEFA(authority string, entity EntityType, id string) (string, error) {
+	prefix, ok := r.Prefix(authority, entity)
+	if !ok {
+		return "", fmt.Errorf("no prefix registered for authority %s and entity %s", authority, entity)
+	}
+	width := efaLength - len(prefix)
+	if id == "" || len(id) > width {
+		return "", fmt.Errorf("invalid %s id: %q", entity, id)
+	}
+	n, err := parseDigits(id, len(id))
+	if err != nil {
+		return "", fmt.Errorf("invalid %s id: %w", entity, err)
+	}
+	return prefix + fmt.Sprintf("%0*d", width, n), nil
+}
+
+// FromEFA resolves the authority, entity type and id of an EFA GID using
+// the longest matching registered prefix. Authorities sharing a prefix
+// resolve to the lowest authority code.
+func (r *Registry) FromEFA(gid string) (authority string, entity EntityType, id string, err error) {
+	if _, err := parseDigits(gid, efaLength); err != nil {
+		return "", EntityUnknown, "", fmt.Errorf("invalid efa gid: %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var match registryKey
+	matchPrefix := ""
+	for k, p := range r.prefixes {
+		if !strings.HasPrefix(gid, p) {
+			continue
+		}
+		if len(p) > len(matchPrefix) || (len(p) == len(matchPrefix) && k.authority < match.authority) {
+			match = k
+			matchPrefix = p
+		}
+	}
+	if matchPrefix == "" {
+		return "", EntityUnknown, "", fmt.Errorf("no prefix registered for efa gid: %q", gid)
+	}
+
+	n, _ := strconv.Atoi(gid[len(matchPrefix):])
+	return match.authority, match.entity, strconv.Itoa(n), nil
+}
@@ -0,0 +1,140 @@
+// Package slidentifiers converts between the id formats used by the SL
+// APIs.
+//
+// Site ids are the up to 7 digit ids used by SL Transport, e.g. 9192.
+//
+// HAFAS ids are the 9 digit ids used by the legacy travel planner, of the
+// form 3AA1BBBBB where AABBBBB is the zero padded site id, e.g. 300109192.
+// HAFAS ids only exist for sites.
+//
+// EFA GIDs are 16 digit ids made of a prefix, which depends on the
+// transport authority and entity type, followed by the zero padded entity
+// number, e.g. 9091001000009192 for site 9192. See Registry.
+package slidentifiers
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type EntityType int
+
+const (
+	EntityUnknown EntityType = iota
+	EntitySite
+	EntityStopArea
+	EntityStopPoint
+)
+
+func (e EntityType) String() string {
+	switch e {
+	case EntitySite:
+		return "site"
+	case EntityStopArea:
+		return "stop area"
+	case EntityStopPoint:
+		return "stop point"
+	}
+	return "unknown"
+}
+
+// Transport authority codes. SL and UL use their county codes.
+const (
+	AuthoritySL = "001"
+	AuthorityUL = "003"
+	// AuthorityWaxholmsbolaget is an alias of AuthoritySL. Waxholmsbolaget
+	// has no range of its own, its stops are numbered among SL's, so its
+	// GIDs are SL GIDs.
+	AuthorityWaxholmsbolaget = AuthoritySL
+)
+
+const (
+	efaLength   = 16
+	hafasLength = 9
+)
+
+func parseDigits(id string, length int) (int, error) {
+	if len(id) != length {
+		return 0, fmt.Errorf("expected %d digits, got %q", length, id)
+	}
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("expected digits only, got %q", id)
+		}
+	}
+	return strconv.Atoi(id)
+}
+
+func parseSiteID(siteID string) (int, error) {
+	if siteID == "" || len(siteID) > 7 {
+		return 0, fmt.Errorf("invalid site id: %q", siteID)
+	}
+	id, err := parseDigits(siteID, len(siteID))
+	if err != nil {
+		return 0, fmt.Errorf("invalid site id: %w", err)
+	}
+	return id, nil
+}
+
+// ConvertSiteIDToHAFAS converts a site id to a HAFAS id.
+func ConvertSiteIDToHAFAS(siteID string) (string, error) {
+	id, err := parseSiteID(siteID)
+	if err != nil {
+		return "", err
+	}
+
+	// Extract the first two digits and the last five digits of the ID
+	firstTwoDigits := id / 100000
+	lastFiveDigits := id % 100000
+	return fmt.Sprintf("3%02d1%05d", firstTwoDigits, lastFiveDigits), nil
+}
+
+// ConvertHAFASToSiteID converts a HAFAS id to a site id.
+func ConvertHAFASToSiteID(hafasID string) (string, error) {
+	if _, err := parseDigits(hafasID, hafasLength); err != nil {
+		return "", fmt.Errorf("invalid hafas id: %w", err)
+	}
+	if hafasID[0] != '3' || hafasID[3] != '1' {
+		return "", fmt.Errorf("invalid hafas id: %q", hafasID)
+	}
+	firstTwoDigits, _ := strconv.Atoi(hafasID[1:3])
+	lastFiveDigits, _ := strconv.Atoi(hafasID[4:])
+	return strconv.Itoa(firstTwoDigits*100000 + lastFiveDigits), nil
+}
+
+// ConvertSiteIDToEFA converts an SL site, stop area or stop point id to an
+// EFA GID.
+func ConvertSiteIDToEFA(siteID string, entity EntityType) (string, error) {
+	return DefaultRegistry.ToEFA(AuthoritySL, entity, siteID)
+}
+
+// ConvertEFAToSiteID converts an EFA GID to the id of the entity it refers
+// to, returning the entity type.
+func ConvertEFAToSiteID(gid string) (string, EntityType, error) {
+	_, entity, id, err := DefaultRegistry.FromEFA(gid)
+	if err != nil {
+		return "", EntityUnknown, err
+	}
+	return id, entity, nil
+}
+
+// ConvertHAFASToEFA converts a HAFAS id to a site EFA GID.
+func ConvertHAFASToEFA(hafasID string) (string, error) {
+	siteID, err := ConvertHAFASToSiteID(hafasID)
+	if err != nil {
+		return "", err
+	}
+	return ConvertSiteIDToEFA(siteID, EntitySite)
+}
+
+// ConvertEFAToHAFAS converts a site EFA GID to a HAFAS id.
+func ConvertEFAToHAFAS(gid string) (string, error) {
+	siteID, entity, err := ConvertEFAToSiteID(gid)
+	if err != nil {
+		return "", err
+	}
+	if entity != EntitySite {
+		return "", fmt.Errorf("only sites have hafas ids, got %s", entity)
+	}
+	return ConvertSiteIDToHAFAS(siteID)
+}
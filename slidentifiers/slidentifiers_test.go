@@ -0,0 +1,197 @@
+package slidentifiers_test
+
+import (
+	"strconv"
+	"testing"
+	"testing/quick"
+
+	"github.com/nobina/go-trafiklab/slidentifiers"
+)
+
+// maxSiteID is the largest site id that fits a HAFAS id.
+const maxSiteID = 9999999
+
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{
+		"9091001000009192",
+		"9021001000012345",
+		"9022001000012345",
+		"9091001",
+		"909100100000919x",
+		"0000000000000000",
+		"",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		g, err := slidentifiers.Parse(s)
+		if err != nil {
+			return
+		}
+		if g.String() != s {
+			t.Fatalf("Parse(%q).String() = %q", s, g.String())
+		}
+		gid, err := slidentifiers.DefaultRegistry.ToEFA(g.Authority(), g.EntityType(), strconv.Itoa(g.SiteNumber()))
+		if err != nil {
+			t.Fatalf("ToEFA of parsed %q: %v", s, err)
+		}
+		if gid != s {
+			t.Fatalf("ToEFA of parsed %q = %q", s, gid)
+		}
+		if kind := slidentifiers.DetectKind(s); kind != slidentifiers.KindEFA {
+			t.Fatalf("DetectKind(%q) = %s, want %s", s, kind, slidentifiers.KindEFA)
+		}
+	})
+}
+
+func FuzzConvertHAFASToEFA(f *testing.F) {
+	for _, s := range []string{
+		"300109192",
+		"399199999",
+		"300100000",
+		"300209192",
+		"30010919",
+		"30010919x",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, hafasID string) {
+		gid, err := slidentifiers.ConvertHAFASToEFA(hafasID)
+		if err != nil {
+			return
+		}
+		back, err := slidentifiers.ConvertEFAToHAFAS(gid)
+		if err != nil {
+			t.Fatalf("ConvertEFAToHAFAS(%q) of %q: %v", gid, hafasID, err)
+		}
+		if back != hafasID {
+			t.Fatalf("round trip of %q via %q = %q", hafasID, gid, back)
+		}
+	})
+}
+
+func FuzzParseJourneyID(f *testing.F) {
+	for _, s := range []string{
+		"1|33724|0|74|6022020",
+		"1|33724|0|74|16022020",
+		"1|33724|0|74|06022020",
+		"1|33724|0|74|31022020",
+		"1|33724|0|74",
+		"a|b|c|d|e",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		j, err := slidentifiers.ParseJourneyID(s)
+		if err != nil {
+			return
+		}
+		again, err := slidentifiers.ParseJourneyID(j.String())
+		if err != nil {
+			t.Fatalf("ParseJourneyID(%q) of %q: %v", j.String(), s, err)
+		}
+		if again.Kind != j.Kind || again.Number != j.Number || again.Variant != j.Variant ||
+			again.Operator != j.Operator || !again.Date.Equal(j.Date) {
+			t.Fatalf("round trip of %q = %+v, want %+v", s, again, j)
+		}
+	})
+}
+
+func TestHAFASToEFARoundTrip(t *testing.T) {
+	roundTrip := func(n uint32) bool {
+		siteID := strconv.Itoa(int(n % (maxSiteID + 1)))
+		hafasID, err := slidentifiers.ConvertSiteIDToHAFAS(siteID)
+		if err != nil {
+			t.Logf("ConvertSiteIDToHAFAS(%q): %v", siteID, err)
+			return false
+		}
+		gid, err := slidentifiers.ConvertHAFASToEFA(hafasID)
+		if err != nil {
+			t.Logf("ConvertHAFASToEFA(%q): %v", hafasID, err)
+			return false
+		}
+		back, err := slidentifiers.ConvertEFAToHAFAS(gid)
+		if err != nil {
+			t.Logf("ConvertEFAToHAFAS(%q): %v", gid, err)
+			return false
+		}
+		return back == hafasID
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 10000}); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []uint32{0, 1, 99999, 100000, 9192, maxSiteID} {
+		if !roundTrip(n) {
+			t.Fatalf("round trip of site %d failed", n)
+		}
+	}
+}
+
+func TestEFAToHAFASRoundTrip(t *testing.T) {
+	roundTrip := func(n uint32) bool {
+		siteID := strconv.Itoa(int(n % (maxSiteID + 1)))
+		gid, err := slidentifiers.ConvertSiteIDToEFA(siteID, slidentifiers.EntitySite)
+		if err != nil {
+			t.Logf("ConvertSiteIDToEFA(%q): %v", siteID, err)
+			return false
+		}
+		hafasID, err := slidentifiers.ConvertEFAToHAFAS(gid)
+		if err != nil {
+			t.Logf("ConvertEFAToHAFAS(%q): %v", gid, err)
+			return false
+		}
+		back, err := slidentifiers.ConvertHAFASToEFA(hafasID)
+		if err != nil {
+			t.Logf("ConvertHAFASToEFA(%q): %v", hafasID, err)
+			return false
+		}
+		return back == gid
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 10000}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStopPointsHaveNoHAFASID(t *testing.T) {
+	gid, err := slidentifiers.ConvertSiteIDToEFA("12345", slidentifiers.EntityStopPoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := slidentifiers.ConvertEFAToHAFAS(gid); err == nil {
+		t.Fatalf("ConvertEFAToHAFAS(%q) of a stop point succeeded", gid)
+	}
+}
+
+func TestRegistryAuthorities(t *testing.T) {
+	for _, tc := range []struct {
+		authority string
+		entity    slidentifiers.EntityType
+		wantGID   string
+		// wantAuthority is the authority FromEFA resolves the GID to.
+		wantAuthority string
+	}{
+		{slidentifiers.AuthoritySL, slidentifiers.EntityStopArea, "9021001000012345", slidentifiers.AuthoritySL},
+		{slidentifiers.AuthorityWaxholmsbolaget, slidentifiers.EntityStopArea, "9021001000012345", slidentifiers.AuthoritySL},
+		{slidentifiers.AuthorityWaxholmsbolaget, slidentifiers.EntitySite, "9091001000012345", slidentifiers.AuthoritySL},
+		{slidentifiers.AuthorityUL, slidentifiers.EntityStopArea, "9021003000012345", slidentifiers.AuthorityUL},
+		{slidentifiers.AuthorityUL, slidentifiers.EntityStopPoint, "9022003000012345", slidentifiers.AuthorityUL},
+	} {
+		gid, err := slidentifiers.DefaultRegistry.ToEFA(tc.authority, tc.entity, "12345")
+		if err != nil {
+			t.Fatalf("ToEFA(%s, %s): %v", tc.authority, tc.entity, err)
+		}
+		if gid != tc.wantGID {
+			t.Fatalf("ToEFA(%s, %s) = %q, want %q", tc.authority, tc.entity, gid, tc.wantGID)
+		}
+		authority, entity, _, err := slidentifiers.DefaultRegistry.FromEFA(gid)
+		if err != nil {
+			t.Fatalf("FromEFA(%q): %v", gid, err)
+		}
+		if authority != tc.wantAuthority || entity != tc.entity {
+			t.Fatalf("FromEFA(%q) = %s, %s, want %s, %s", gid, authority, entity, tc.wantAuthority, tc.entity)
+		}
+		if kind := slidentifiers.DetectKind(gid); kind != slidentifiers.KindEFA {
+			t.Fatalf("DetectKind(%q) = %s, want %s", gid, kind, slidentifiers.KindEFA)
+		}
+	}
+}
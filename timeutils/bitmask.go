@@ -0,0 +1,73 @@
+package timeutils
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// DayBitmask is a set of operating days over a timetable period, decoded
+// from the hex bitmasks used by the journey planners (sDaysB, RVB). The
+// most significant bit of the first byte is the first day of the period.
+type DayBitmask struct {
+	bits  []byte
+	begin time.Time
+	end   time.Time
+}
+
+// ParseDayBitmask decodes s for the period from begin to end, both
+// inclusive. Bits past end are ignored; a zero end accepts all bits.
+func ParseDayBitmask(s string, begin, end time.Time) (DayBitmask, error) {
+	if len(s)%2 == 1 {
+		s += "0"
+	}
+	bits, err := hex.DecodeString(s)
+	if err != nil {
+		return DayBitmask{}, fmt.Errorf("invalid day bitmask %q: %w", s, err)
+	}
+	m := DayBitmask{
+		bits:  bits,
+		begin: Date(begin.In(EuropeStockholm()).Date()),
+	}
+	if !end.IsZero() {
+		m.end = Date(end.In(EuropeStockholm()).Date())
+	}
+	return m, nil
+}
+
+// RunsOn reports whether the day of date is set.
+func (m DayBitmask) RunsOn(date time.Time) bool {
+	day := m.dayIndex(date.In(EuropeStockholm()))
+	if day < 0 || day >= len(m.bits)*8 {
+		return false
+	}
+	if !m.end.IsZero() && day > m.dayIndex(m.end) {
+		return false
+	}
+	return m.bits[day/8]&(0x80>>(day%8)) != 0
+}
+
+// Dates returns the set days in order, as midnight in Stockholm.
+func (m DayBitmask) Dates() []time.Time {
+	var dates []time.Time
+	for i := 0; i < len(m.bits)*8; i++ {
+		date := m.begin.AddDate(0, 0, i)
+		if !m.end.IsZero() && date.After(m.end) {
+			break
+		}
+		if m.bits[i/8]&(0x80>>(i%8)) != 0 {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+// dayIndex counts calendar days rather than 24 hour periods, which differ
+// around daylight saving switches.
+func (m DayBitmask) dayIndex(day time.Time) int {
+	y1, m1, d1 := m.begin.Date()
+	y2, m2, d2 := day.Date()
+	a := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+	b := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
@@ -0,0 +1,113 @@
+package timeutils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+func dates(ds ...time.Time) []time.Time { return ds }
+
+func TestParseDayBitmask(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		mask       string
+		begin, end time.Time
+		want       []time.Time
+	}{
+		{
+			name:  "first bit is first day",
+			mask:  "80",
+			begin: timeutils.Date(2024, time.January, 1),
+			want:  dates(timeutils.Date(2024, time.January, 1)),
+		},
+		{
+			name:  "second byte",
+			mask:  "0001",
+			begin: timeutils.Date(2024, time.January, 1),
+			want:  dates(timeutils.Date(2024, time.January, 16)),
+		},
+		{
+			name:  "odd length is padded",
+			mask:  "A",
+			begin: timeutils.Date(2024, time.January, 1),
+			want:  dates(timeutils.Date(2024, time.January, 1), timeutils.Date(2024, time.January, 3)),
+		},
+		{
+			name:  "bits past end are ignored",
+			mask:  "FF",
+			begin: timeutils.Date(2024, time.January, 1),
+			end:   timeutils.Date(2024, time.January, 2),
+			want:  dates(timeutils.Date(2024, time.January, 1), timeutils.Date(2024, time.January, 2)),
+		},
+		{
+			name:  "spring switch",
+			mask:  "E0",
+			begin: timeutils.Date(2024, time.March, 30),
+			want:  dates(timeutils.Date(2024, time.March, 30), timeutils.Date(2024, time.March, 31), timeutils.Date(2024, time.April, 1)),
+		},
+		{
+			name:  "autumn switch",
+			mask:  "50",
+			begin: timeutils.Date(2024, time.October, 26),
+			want:  dates(timeutils.Date(2024, time.October, 27), timeutils.Date(2024, time.October, 29)),
+		},
+		{
+			name:  "empty",
+			mask:  "00",
+			begin: timeutils.Date(2024, time.January, 1),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := timeutils.ParseDayBitmask(tc.mask, tc.begin, tc.end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := m.Dates()
+			if len(got) != len(tc.want) {
+				t.Fatalf("Dates() = %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tc.want[i]) {
+					t.Fatalf("Dates() = %v, want %v", got, tc.want)
+				}
+				if !m.RunsOn(got[i]) {
+					t.Errorf("RunsOn(%s) = false", got[i].Format("2006-01-02"))
+				}
+			}
+		})
+	}
+}
+
+func TestDayBitmaskRunsOn(t *testing.T) {
+	stockholm := timeutils.EuropeStockholm()
+	m, err := timeutils.ParseDayBitmask("A0", timeutils.Date(2024, time.October, 26), timeutils.Date(2024, time.October, 28))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		t    time.Time
+		want bool
+	}{
+		{time.Date(2024, time.October, 25, 12, 0, 0, 0, stockholm), false},
+		{time.Date(2024, time.October, 26, 0, 0, 0, 0, stockholm), true},
+		{time.Date(2024, time.October, 26, 23, 59, 0, 0, stockholm), true},
+		// The 25 hour day must not shift later days.
+		{time.Date(2024, time.October, 27, 23, 30, 0, 0, stockholm), false},
+		{time.Date(2024, time.October, 28, 0, 30, 0, 0, stockholm), true},
+		// Midnight in Stockholm is the previous day in UTC.
+		{time.Date(2024, time.October, 27, 23, 30, 0, 0, time.UTC), true},
+		{time.Date(2024, time.October, 29, 12, 0, 0, 0, stockholm), false},
+	} {
+		if got := m.RunsOn(tc.t); got != tc.want {
+			t.Errorf("RunsOn(%s) = %t, want %t", tc.t, got, tc.want)
+		}
+	}
+}
+
+func TestParseDayBitmaskInvalid(t *testing.T) {
+	if _, err := timeutils.ParseDayBitmask("zz", timeutils.Date(2024, time.January, 1), time.Time{}); err == nil {
+		t.Fatal("ParseDayBitmask succeeded")
+	}
+}
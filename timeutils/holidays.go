@@ -0,0 +1,130 @@
+package timeutils
+
+import (
+	"sort"
+	"time"
+)
+
+// Holiday is a Swedish public holiday (röd dag) or holiday eve.
+type Holiday struct {
+	Date time.Time
+	Name string
+	// Eve is set for days like midsommarafton that aren't public holidays
+	// but where traffic is usually reduced.
+	Eve bool
+}
+
+// Holidays returns the Swedish public holidays and holiday eves of year in
+// date order. They are computed, so any year works.
+func Holidays(year int) []Holiday {
+	easter := easterSunday(year)
+	midsummer := firstWeekday(year, time.June, 20, time.Saturday)
+	allSaints := firstWeekday(year, time.October, 31, time.Saturday)
+
+	holidays := []Holiday{
+		{Date: Date(year, time.January, 1), Name: "Nyårsdagen"},
+		{Date: Date(year, time.January, 6), Name: "Trettondedag jul"},
+		{Date: easter.AddDate(0, 0, -2), Name: "Långfredagen"},
+		{Date: easter, Name: "Påskdagen"},
+		{Date: easter.AddDate(0, 0, 1), Name: "Annandag påsk"},
+		{Date: Date(year, time.May, 1), Name: "Första maj"},
+		{Date: easter.AddDate(0, 0, 39), Name: "Kristi himmelsfärdsdag"},
+		{Date: easter.AddDate(0, 0, 49), Name: "Pingstdagen"},
+		{Date: Date(year, time.June, 6), Name: "Sveriges nationaldag"},
+		{Date: midsummer.AddDate(0, 0, -1), Name: "Midsommarafton", Eve: true},
+		{Date: midsummer, Name: "Midsommardagen"},
+		{Date: allSaints, Name: "Alla helgons dag"},
+		{Date: Date(year, time.December, 24), Name: "Julafton", Eve: true},
+		{Date: Date(year, time.December, 25), Name: "Juldagen"},
+		{Date: Date(year, time.December, 26), Name: "Annandag jul"},
+		{Date: Date(year, time.December, 31), Name: "Nyårsafton", Eve: true},
+	}
+	sort.Slice(holidays, func(i, j int) bool {
+		return holidays[i].Date.Before(holidays[j].Date)
+	})
+	return holidays
+}
+
+// HolidayOn returns the holiday or holiday eve on the day of date, if any.
+func HolidayOn(date time.Time) (Holiday, bool) {
+	y, m, d := date.In(EuropeStockholm()).Date()
+	for _, h := range Holidays(y) {
+		if h.Date.Month() == m && h.Date.Day() == d {
+			return h, true
+		}
+	}
+	return Holiday{}, false
+}
+
+// IsHoliday reports whether date is a Swedish public holiday. Holiday eves
+// are not public holidays.
+func IsHoliday(date time.Time) bool {
+	h, ok := HolidayOn(date)
+	return ok && !h.Eve
+}
+
+// CalendarKind is the kind of timetable that runs on a day.
+type CalendarKind int
+
+const (
+	CalendarWeekday CalendarKind = iota
+	CalendarSaturday
+	CalendarSundayHoliday
+)
+
+func (k CalendarKind) String() string {
+	switch k {
+	case CalendarSaturday:
+		return "saturday"
+	case CalendarSundayHoliday:
+		return "sunday/holiday"
+	}
+	return "weekday"
+}
+
+// ServiceCalendarKind returns the timetable kind for the day of date:
+// Sunday timetables on Sundays and public holidays, Saturday timetables on
+// Saturdays and holiday eves.
+func ServiceCalendarKind(date time.Time) CalendarKind {
+	local := date.In(EuropeStockholm())
+	if h, ok := HolidayOn(local); ok {
+		if !h.Eve {
+			return CalendarSundayHoliday
+		}
+		if local.Weekday() != time.Sunday {
+			return CalendarSaturday
+		}
+	}
+	switch local.Weekday() {
+	case time.Sunday:
+		return CalendarSundayHoliday
+	case time.Saturday:
+		return CalendarSaturday
+	}
+	return CalendarWeekday
+}
+
+// easterSunday uses the anonymous Gregorian algorithm.
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return Date(year, time.Month(month), day)
+}
+
+// firstWeekday returns the first weekday on or after the given date.
+func firstWeekday(year int, month time.Month, day int, weekday time.Weekday) time.Time {
+	t := Date(year, month, day)
+	return t.AddDate(0, 0, (int(weekday)-int(t.Weekday())+7)%7)
+}
@@ -0,0 +1,97 @@
+package timeutils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+func TestHolidays(t *testing.T) {
+	for _, tc := range []struct {
+		year int
+		want map[string]time.Time
+	}{
+		{2024, map[string]time.Time{
+			"Långfredagen":           timeutils.Date(2024, time.March, 29),
+			"Påskdagen":              timeutils.Date(2024, time.March, 31),
+			"Annandag påsk":          timeutils.Date(2024, time.April, 1),
+			"Kristi himmelsfärdsdag": timeutils.Date(2024, time.May, 9),
+			"Pingstdagen":            timeutils.Date(2024, time.May, 19),
+			"Midsommarafton":         timeutils.Date(2024, time.June, 21),
+			"Midsommardagen":         timeutils.Date(2024, time.June, 22),
+			"Alla helgons dag":       timeutils.Date(2024, time.November, 2),
+		}},
+		{2025, map[string]time.Time{
+			"Långfredagen":           timeutils.Date(2025, time.April, 18),
+			"Påskdagen":              timeutils.Date(2025, time.April, 20),
+			"Kristi himmelsfärdsdag": timeutils.Date(2025, time.May, 29),
+			"Pingstdagen":            timeutils.Date(2025, time.June, 8),
+			"Midsommarafton":         timeutils.Date(2025, time.June, 20),
+			"Alla helgons dag":       timeutils.Date(2025, time.November, 1),
+		}},
+		{2038, map[string]time.Time{
+			"Påskdagen": timeutils.Date(2038, time.April, 25),
+		}},
+	} {
+		holidays := timeutils.Holidays(tc.year)
+		if len(holidays) != 16 {
+			t.Errorf("%d: got %d holidays, want 16", tc.year, len(holidays))
+		}
+		got := make(map[string]time.Time)
+		for i, h := range holidays {
+			got[h.Name] = h.Date
+			if i > 0 && !holidays[i-1].Date.Before(h.Date) {
+				t.Errorf("%d: %s is not after %s", tc.year, h.Name, holidays[i-1].Name)
+			}
+		}
+		for name, want := range tc.want {
+			if !got[name].Equal(want) {
+				t.Errorf("%d: %s = %s, want %s", tc.year, name, got[name].Format("2006-01-02"), want.Format("2006-01-02"))
+			}
+		}
+	}
+}
+
+func TestIsHoliday(t *testing.T) {
+	stockholm := timeutils.EuropeStockholm()
+	for _, tc := range []struct {
+		t    time.Time
+		want bool
+	}{
+		{timeutils.Date(2024, time.December, 25), true},
+		{timeutils.Date(2024, time.December, 24), false},
+		{timeutils.Date(2024, time.June, 21), false},
+		{timeutils.Date(2024, time.June, 22), true},
+		{timeutils.Date(2024, time.June, 23), false},
+		// 2024-01-01 00:30 in Stockholm is still 2023 in UTC.
+		{time.Date(2023, time.December, 31, 23, 30, 0, 0, time.UTC), true},
+		{time.Date(2024, time.January, 1, 23, 30, 0, 0, stockholm), true},
+	} {
+		if got := timeutils.IsHoliday(tc.t); got != tc.want {
+			t.Errorf("IsHoliday(%s) = %t, want %t", tc.t, got, tc.want)
+		}
+	}
+}
+
+func TestServiceCalendarKind(t *testing.T) {
+	for _, tc := range []struct {
+		day  time.Time
+		want timeutils.CalendarKind
+	}{
+		{timeutils.Date(2024, time.January, 2), timeutils.CalendarWeekday},
+		{timeutils.Date(2024, time.January, 13), timeutils.CalendarSaturday},
+		{timeutils.Date(2024, time.January, 14), timeutils.CalendarSundayHoliday},
+		// Trettondedag jul on a Saturday.
+		{timeutils.Date(2024, time.January, 6), timeutils.CalendarSundayHoliday},
+		{timeutils.Date(2024, time.May, 9), timeutils.CalendarSundayHoliday},
+		{timeutils.Date(2024, time.June, 21), timeutils.CalendarSaturday},
+		{timeutils.Date(2024, time.December, 24), timeutils.CalendarSaturday},
+		// Julafton on a Sunday keeps the Sunday timetable.
+		{timeutils.Date(2023, time.December, 24), timeutils.CalendarSundayHoliday},
+	} {
+		if got := timeutils.ServiceCalendarKind(tc.day); got != tc.want {
+			t.Errorf("ServiceCalendarKind(%s) = %s, want %s", tc.day.Format("2006-01-02"), got, tc.want)
+		}
+	}
+}
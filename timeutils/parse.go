@@ -0,0 +1,40 @@
+package timeutils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// localLayouts are the formats without offset seen across the APIs. They
+// are all interpreted as Stockholm time.
+var localLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"20060102 1504",
+	"200601021504",
+	"2006-01-02",
+	"20060102",
+}
+
+// Parse parses a timestamp in any of the formats used by the APIs: RFC3339,
+// "2006-01-02 15:04:05", EFA's date and time concatenated (200601021504),
+// and SL Transport's local ISO time without offset. The result is always in
+// Europe/Stockholm.
+func Parse(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.In(EuropeStockholm()), nil
+	}
+	for _, layout := range localLayouts {
+		if len(layout) != len(s) {
+			continue
+		}
+		if t, err := time.ParseInLocation(layout, s, EuropeStockholm()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown time format: %q", s)
+}
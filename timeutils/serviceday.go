@@ -0,0 +1,82 @@
+package timeutils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServiceDayCutoff is how long after midnight the previous service day
+// continues. Trips departing before it belong to the previous day's
+// timetable.
+const ServiceDayCutoff = 4 * time.Hour
+
+// ServiceDay returns the service day t belongs to, as midnight in
+// Stockholm.
+func ServiceDay(t time.Time) time.Time {
+	local := t.In(EuropeStockholm())
+	day := Date(local.Date())
+	wall := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute + time.Duration(local.Second())*time.Second
+	if wall < ServiceDayCutoff {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// ServiceDayBounds returns the first instant of the service day and the
+// first instant of the next one, both at ServiceDayCutoff wall clock time.
+func ServiceDayBounds(day time.Time) (start, end time.Time) {
+	y, m, d := day.In(EuropeStockholm()).Date()
+	hour, minute := int(ServiceDayCutoff/time.Hour), int(ServiceDayCutoff%time.Hour/time.Minute)
+	start = time.Date(y, m, d, hour, minute, 0, 0, EuropeStockholm())
+	end = time.Date(y, m, d+1, hour, minute, 0, 0, EuropeStockholm())
+	return start, end
+}
+
+// Date returns midnight of the given date in Stockholm.
+func Date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, EuropeStockholm())
+}
+
+// ParseServiceDate parses a service date as sent by the journey planners,
+// either 2006-01-02 or 20060102 (itd_date).
+func ParseServiceDate(s string) (time.Time, error) {
+	layout := "2006-01-02"
+	if len(s) == 8 && !strings.Contains(s, "-") {
+		layout = "20060102"
+	}
+	t, err := time.ParseInLocation(layout, s, EuropeStockholm())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid service date %q: %w", s, err)
+	}
+	return t, nil
+}
+
+// FormatServiceDate formats the service day of day as 2006-01-02.
+func FormatServiceDate(day time.Time) string {
+	return day.In(EuropeStockholm()).Format("2006-01-02")
+}
+
+// ServiceTime returns the instant of a timetable time on a service day.
+// Timetable times may exceed 24:00 for trips after midnight, e.g. 25:10 or
+// 25:10:00, and are counted from noon minus 12 hours so that they stay
+// correct on days with a daylight saving switch.
+func ServiceTime(day time.Time, clock string) (time.Time, error) {
+	parts := strings.Split(clock, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return time.Time{}, fmt.Errorf("invalid service time %q", clock)
+	}
+	var hms [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || (i > 0 && n > 59) {
+			return time.Time{}, fmt.Errorf("invalid service time %q", clock)
+		}
+		hms[i] = n
+	}
+	y, m, d := day.In(EuropeStockholm()).Date()
+	noon := time.Date(y, m, d, 12, 0, 0, 0, EuropeStockholm())
+	offset := time.Duration(hms[0])*time.Hour + time.Duration(hms[1])*time.Minute + time.Duration(hms[2])*time.Second
+	return noon.Add(-12 * time.Hour).Add(offset), nil
+}
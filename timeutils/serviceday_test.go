@@ -0,0 +1,104 @@
+package timeutils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+func TestServiceTimeDST(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		day   time.Time
+		clock string
+		want  time.Time
+	}{
+		{"spring morning", timeutils.Date(2024, time.March, 31), "08:00:00", time.Date(2024, time.March, 31, 6, 0, 0, 0, time.UTC)},
+		{"spring after midnight", timeutils.Date(2024, time.March, 31), "25:10:00", time.Date(2024, time.March, 31, 23, 10, 0, 0, time.UTC)},
+		// Noon minus 12 hours is 23:00 the day before on the spring switch.
+		{"spring start", timeutils.Date(2024, time.March, 31), "00:00", time.Date(2024, time.March, 30, 22, 0, 0, 0, time.UTC)},
+		{"autumn morning", timeutils.Date(2024, time.October, 27), "08:00:00", time.Date(2024, time.October, 27, 7, 0, 0, 0, time.UTC)},
+		{"autumn after midnight", timeutils.Date(2024, time.October, 27), "25:10:00", time.Date(2024, time.October, 28, 0, 10, 0, 0, time.UTC)},
+		// and 01:00 summer time on the autumn switch.
+		{"autumn start", timeutils.Date(2024, time.October, 27), "00:00", time.Date(2024, time.October, 26, 23, 0, 0, 0, time.UTC)},
+		{"ordinary day", timeutils.Date(2024, time.January, 15), "23:59:59", time.Date(2024, time.January, 15, 22, 59, 59, 0, time.UTC)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := timeutils.ServiceTime(tc.day, tc.clock)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tc.want) {
+				t.Fatalf("ServiceTime(%s, %s) = %s, want %s", tc.day.Format("2006-01-02"), tc.clock, got.UTC(), tc.want)
+			}
+		})
+	}
+}
+
+// Timetable times are elapsed time from noon minus 12 hours, so the time
+// between two stops is their difference even across a switch.
+func TestServiceTimeElapsedAcrossDST(t *testing.T) {
+	for _, day := range []time.Time{timeutils.Date(2024, time.March, 31), timeutils.Date(2024, time.October, 27)} {
+		dep, err := timeutils.ServiceTime(day, "01:30:00")
+		if err != nil {
+			t.Fatal(err)
+		}
+		arr, err := timeutils.ServiceTime(day, "03:30:00")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if arr.Sub(dep) != 2*time.Hour {
+			t.Fatalf("01:30 to 03:30 on %s = %s, want 2h", day.Format("2006-01-02"), arr.Sub(dep))
+		}
+	}
+}
+
+func TestServiceTimeInvalid(t *testing.T) {
+	for _, clock := range []string{"", "8", "08:60", "08:00:60", "-1:00", "08:00:00:00", "ab:cd"} {
+		if _, err := timeutils.ServiceTime(timeutils.Date(2024, time.January, 15), clock); err == nil {
+			t.Errorf("ServiceTime(%q) succeeded", clock)
+		}
+	}
+}
+
+func TestServiceDayDST(t *testing.T) {
+	stockholm := timeutils.EuropeStockholm()
+	for _, tc := range []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{"spring before cutoff", time.Date(2024, time.March, 31, 3, 30, 0, 0, stockholm), timeutils.Date(2024, time.March, 30)},
+		{"spring at cutoff", time.Date(2024, time.March, 31, 4, 0, 0, 0, stockholm), timeutils.Date(2024, time.March, 31)},
+		// 02:30 standard time, the second time the clock shows 02:30.
+		{"autumn repeated hour", time.Date(2024, time.October, 27, 1, 30, 0, 0, time.UTC), timeutils.Date(2024, time.October, 26)},
+		{"autumn at cutoff", time.Date(2024, time.October, 27, 4, 0, 0, 0, stockholm), timeutils.Date(2024, time.October, 27)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := timeutils.ServiceDay(tc.t); !got.Equal(tc.want) {
+				t.Fatalf("ServiceDay(%s) = %s, want %s", tc.t, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestServiceDayBoundsDST(t *testing.T) {
+	for _, tc := range []struct {
+		day  time.Time
+		want time.Duration
+	}{
+		{timeutils.Date(2024, time.March, 30), 23 * time.Hour},
+		{timeutils.Date(2024, time.March, 31), 24 * time.Hour},
+		{timeutils.Date(2024, time.October, 26), 25 * time.Hour},
+		{timeutils.Date(2024, time.October, 27), 24 * time.Hour},
+	} {
+		start, end := timeutils.ServiceDayBounds(tc.day)
+		if end.Sub(start) != tc.want {
+			t.Errorf("service day %s lasts %s, want %s", tc.day.Format("2006-01-02"), end.Sub(start), tc.want)
+		}
+		if !timeutils.ServiceDay(start).Equal(tc.day) || !timeutils.ServiceDay(end.Add(-time.Second)).Equal(tc.day) {
+			t.Errorf("bounds of %s are outside the service day", tc.day.Format("2006-01-02"))
+		}
+	}
+}
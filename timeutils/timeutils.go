@@ -1,21 +1,33 @@
 package timeutils
 
 import (
-	"log"
+	"sync"
 	"time"
+
+	// Embeds the zoneinfo database, used by time.LoadLocation when the
+	// system has none, e.g. on scratch or alpine images.
+	_ "time/tzdata"
 )
 
 const sweden = "Europe/Stockholm"
 
-var _ = EuropeStockholm() // crash on init if location not available
+var (
+	stockholmOnce sync.Once
+	stockholm     *time.Location
+)
 
-// GetDefaultLocation gets the location with the correct timezone that we should use
-// Panics if locale is not found, the only reason this should happen is if we're
-// on an alpine docker image and the timezone data is not installed
+// EuropeStockholm returns the Europe/Stockholm location used for all SL
+// times. It is loaded once, from the system zoneinfo if available and
+// otherwise from the copy embedded in the binary.
 func EuropeStockholm() *time.Location {
-	loc, err := time.LoadLocation(sweden)
-	if err != nil {
-		log.Fatalf("Could not load location, something is very broken: %s", err.Error())
-	}
-	return loc
+	stockholmOnce.Do(func() {
+		loc, err := time.LoadLocation(sweden)
+		if err != nil {
+			// Can't happen with the embedded database, but a fixed CET
+			// zone beats killing the process.
+			loc = time.FixedZone("CET", 60*60)
+		}
+		stockholm = loc
+	})
+	return stockholm
 }
@@ -0,0 +1,376 @@
+package trafiklab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nobina/go-trafiklab/gtfs"
+	"github.com/nobina/go-trafiklab/metrics"
+	"github.com/nobina/go-trafiklab/requests"
+	"github.com/nobina/go-trafiklab/sl/deviations"
+	"github.com/nobina/go-trafiklab/sl/networkstatus"
+	"github.com/nobina/go-trafiklab/sl/stops"
+	"github.com/nobina/go-trafiklab/sl/stopsnearby"
+	"github.com/nobina/go-trafiklab/sl/trafficstatus"
+	"github.com/nobina/go-trafiklab/sl/transport"
+	"github.com/nobina/go-trafiklab/sl/travelplanner"
+	"github.com/nobina/go-trafiklab/timeutils"
+)
+
+// The travel planner and stops clients call the HAFAS based Reseplanerare
+// 3.1 and typeahead APIs, which SL serves under /v1 of the journey planner
+// host; the EFA based journey planner is under /v2 of the same host.
+const (
+	DefaultTravelPlannerURL = "https://journeyplanner.integration.sl.se"
+	DefaultTransportURL     = "https://transport.integration.sl.se"
+	DefaultDeviationsURL    = "https://deviations.integration.sl.se"
+	DefaultStopsURL         = "https://journeyplanner.integration.sl.se"
+	DefaultStopsNearbyURL   = "https://api.sl.se/api2"
+	DefaultTrafficStatusURL = "https://api.sl.se"
+	DefaultGTFSURL          = gtfs.DefaultBaseURL
+)
+
+// BaseURLs overrides the base url of each API. Empty fields use the url
+// of the configured profile.
+type BaseURLs struct {
+	TravelPlanner string
+	Transport     string
+	Deviations    string
+	Stops         string
+	StopsNearby   string
+	TrafficStatus string
+	GTFS          string
+}
+
+// merge fills the empty fields of b from fallback.
+func (b BaseURLs) merge(fallback BaseURLs) BaseURLs {
+	if b.TravelPlanner == "" {
+		b.TravelPlanner = fallback.TravelPlanner
+	}
+	if b.Transport == "" {
+		b.Transport = fallback.Transport
+	}
+	if b.Deviations == "" {
+		b.Deviations = fallback.Deviations
+	}
+	if b.Stops == "" {
+		b.Stops = fallback.Stops
+	}
+	if b.StopsNearby == "" {
+		b.StopsNearby = fallback.StopsNearby
+	}
+	if b.TrafficStatus == "" {
+		b.TrafficStatus = fallback.TrafficStatus
+	}
+	if b.GTFS == "" {
+		b.GTFS = fallback.GTFS
+	}
+	return b
+}
+
+// Config configures all clients at once. Clients for APIs that need a key
+// are only created when their key or key pool is set.
+type Config struct {
+	TravelPlannerAPIKey string
+	StopsAPIKey         string
+	StopsNearbyAPIKey   string
+	TrafficStatusAPIKey string
+	GTFSRegionalAPIKey  string
+	GTFSSwedenAPIKey    string
+
+	// Profile selects the base urls, production if empty. BaseURLs
+	// overrides individual APIs of the profile.
+	Profile       Profile
+	BaseURLs      BaseURLs
+	AllowInsecure requests.AllowInsecure
+	// Debug enables debug output of the clients. With Logger set, all
+	// requests and responses are instead dumped to it with keys redacted.
+	Debug  bool
+	Logger requests.Logger
+	// UserAgent is sent by all clients, go-trafiklab/<version> if empty.
+	UserAgent string
+	// Clock replaces the current time in trip searches and network status,
+	// e.g. for deterministic tests.
+	Clock timeutils.Clock
+
+	// Middlewares wrap the http.Client shared by all clients.
+	Middlewares []requests.Middleware
+
+	// KeyPools rotate between several keys per API. A pool replaces the
+	// single key of its API.
+	KeyPools KeyPools
+
+	// OnQuotaThreshold is called when the rate limit utilization of an API
+	// host reaches QuotaThreshold, e.g. 0.8.
+	QuotaThreshold   float64
+	OnQuotaThreshold func(host string, q requests.Quota)
+
+	// Metrics receives an observation for every request made, e.g. a
+	// metrics.LatencyTracker.
+	Metrics metrics.Recorder
+
+	// OnSlowRequest is called for every request taking longer than
+	// SlowRequestThreshold.
+	SlowRequestThreshold time.Duration
+	OnSlowRequest        func(metrics.SlowRequest)
+
+	// RetryPolicy retries failed requests of all clients. Requests are
+	// not retried if nil.
+	RetryPolicy *requests.RetryPolicy
+	// RetryPolicies replace RetryPolicy for single APIs, e.g. to also
+	// retry 404 from an API that returns it spuriously.
+	RetryPolicies RetryPolicies
+}
+
+type RetryPolicies struct {
+	Transport     *requests.RetryPolicy
+	Deviations    *requests.RetryPolicy
+	TravelPlanner *requests.RetryPolicy
+	Stops         *requests.RetryPolicy
+	StopsNearby   *requests.RetryPolicy
+	TrafficStatus *requests.RetryPolicy
+	GTFS          *requests.RetryPolicy
+}
+
+type KeyPools struct {
+	TravelPlanner *requests.KeyPool
+	Stops         *requests.KeyPool
+	StopsNearby   *requests.KeyPool
+	TrafficStatus *requests.KeyPool
+}
+
+func (cfg *Config) baseURLs() (BaseURLs, error) {
+	profile := cfg.Profile
+	if profile == "" {
+		profile = ProfileProduction
+	}
+	urls, err := ProfileBaseURLs(profile)
+	if err != nil {
+		return BaseURLs{}, err
+	}
+	urls = cfg.BaseURLs.merge(urls)
+	if missing := urls.missingEnv(); profile == ProfileIntegration && len(missing) > 0 {
+		return BaseURLs{}, fmt.Errorf("integration profile is missing base urls, set %s or Config.BaseURLs", strings.Join(missing, ", "))
+	}
+	return urls, nil
+}
+
+func (cfg *Config) Valid() error {
+	urls, err := cfg.baseURLs()
+	if err != nil {
+		return err
+	}
+	for _, u := range []string{
+		urls.TravelPlanner,
+		urls.Transport,
+		urls.Deviations,
+		urls.Stops,
+		urls.StopsNearby,
+		urls.TrafficStatus,
+		urls.GTFS,
+	} {
+		if err := requests.ValidateBaseURL(u, cfg.AllowInsecure); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Client struct {
+	TravelPlanner *travelplanner.TravelPlannerClient
+	Transport     *transport.Client
+	Deviations    *deviations.Client
+	Stops         *stops.Client
+	StopsNearby   *stopsnearby.Client
+	TrafficStatus *trafficstatus.Client
+	GTFS          *gtfs.Client
+	NetworkStatus *networkstatus.Client
+
+	quota *requests.QuotaTracker
+}
+
+// Quota returns the request counts and rate limit state per API host.
+func (c *Client) Quota() map[string]requests.Quota {
+	return c.quota.Quota()
+}
+
+// Health pings every configured client concurrently and returns the
+// result per API, nil for healthy ones.
+func (c *Client) Health(ctx context.Context) map[string]error {
+	type pinger interface {
+		Ping(context.Context) error
+	}
+	pingers := map[string]pinger{
+		"transport":  c.Transport,
+		"deviations": c.Deviations,
+	}
+	if c.TravelPlanner != nil {
+		pingers["travelplanner"] = c.TravelPlanner
+	}
+	if c.Stops != nil {
+		pingers["stops"] = c.Stops
+	}
+	if c.StopsNearby != nil {
+		pingers["stopsnearby"] = c.StopsNearby
+	}
+	if c.TrafficStatus != nil {
+		pingers["trafficstatus"] = c.TrafficStatus
+	}
+	if c.GTFS != nil {
+		pingers["gtfs"] = c.GTFS
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]error, len(pingers))
+	for name, p := range pingers {
+		wg.Add(1)
+		go func(name string, p pinger) {
+			defer wg.Done()
+			err := p.Ping(ctx)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}(name, p)
+	}
+	wg.Wait()
+	return results
+}
+
+// Healthy reports whether all configured clients are healthy.
+func (c *Client) Healthy(ctx context.Context) bool {
+	for _, err := range c.Health(ctx) {
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// NewClient creates the clients. It fails if cfg is not Valid, e.g. for
+// an unknown profile, rather than falling back to production.
+func NewClient(cfg *Config, client *http.Client) (*Client, error) {
+	if err := cfg.Valid(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	urls, err := cfg.baseURLs()
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		quota: requests.NewQuotaTracker(cfg.QuotaThreshold, cfg.OnQuotaThreshold),
+	}
+
+	middlewares := []requests.Middleware{c.quota.Middleware()}
+	if cfg.UserAgent != "" {
+		middlewares = append(middlewares, requests.UserAgent(cfg.UserAgent))
+	}
+	if cfg.Metrics != nil {
+		middlewares = append(middlewares, metrics.Middleware(cfg.Metrics))
+	}
+	if cfg.OnSlowRequest != nil {
+		middlewares = append(middlewares, metrics.SlowRequests(cfg.SlowRequestThreshold, cfg.OnSlowRequest))
+	}
+	if cfg.Debug && cfg.Logger != nil {
+		middlewares = append(middlewares, requests.Dump(cfg.Logger, 0))
+	}
+	middlewares = append(middlewares, cfg.Middlewares...)
+	// The retries wrap the other middlewares so that every attempt is
+	// counted and logged.
+	clientFor := func(policy *requests.RetryPolicy) *http.Client {
+		if policy == nil {
+			policy = cfg.RetryPolicy
+		}
+		if policy == nil {
+			return requests.WrapClient(client, middlewares...)
+		}
+		return requests.WrapClient(client, append([]requests.Middleware{policy.Middleware()}, middlewares...)...)
+	}
+
+	var transportOpts []transport.Option
+	var deviationsOpts []deviations.Option
+	var travelPlannerOpts []travelplanner.Option
+	var stopsOpts []stops.Option
+	var stopsNearbyOpts []stopsnearby.Option
+	var trafficStatusOpts []trafficstatus.Option
+	if cfg.Debug && cfg.Logger == nil {
+		transportOpts = append(transportOpts, transport.WithDebug())
+		deviationsOpts = append(deviationsOpts, deviations.WithDebug())
+		travelPlannerOpts = append(travelPlannerOpts, travelplanner.WithDebug())
+		stopsNearbyOpts = append(stopsNearbyOpts, stopsnearby.WithDebug())
+		trafficStatusOpts = append(trafficStatusOpts, trafficstatus.WithDebug())
+	}
+	if cfg.Clock != nil {
+		travelPlannerOpts = append(travelPlannerOpts, travelplanner.WithClock(cfg.Clock))
+	}
+	if cfg.Logger != nil {
+		stopsOpts = append(stopsOpts, stops.WithLogger(cfg.Logger))
+		trafficStatusOpts = append(trafficStatusOpts, trafficstatus.WithLogger(cfg.Logger))
+	}
+	if cfg.KeyPools.TravelPlanner != nil {
+		travelPlannerOpts = append(travelPlannerOpts, travelplanner.WithKeyPool(cfg.KeyPools.TravelPlanner))
+	}
+	if cfg.KeyPools.Stops != nil {
+		stopsOpts = append(stopsOpts, stops.WithKeyPool(cfg.KeyPools.Stops))
+	}
+	if cfg.KeyPools.StopsNearby != nil {
+		stopsNearbyOpts = append(stopsNearbyOpts, stopsnearby.WithKeyPool(cfg.KeyPools.StopsNearby))
+	}
+	if cfg.KeyPools.TrafficStatus != nil {
+		trafficStatusOpts = append(trafficStatusOpts, trafficstatus.WithKeyPool(cfg.KeyPools.TrafficStatus))
+	}
+
+	c.Transport = transport.NewClient(&transport.Config{
+		BaseURL: urls.Transport,
+	}, clientFor(cfg.RetryPolicies.Transport), transportOpts...)
+	c.Deviations = deviations.NewClient(&deviations.Config{
+		BaseURL: urls.Deviations,
+	}, clientFor(cfg.RetryPolicies.Deviations), deviationsOpts...)
+
+	if cfg.TravelPlannerAPIKey != "" || cfg.KeyPools.TravelPlanner != nil {
+		c.TravelPlanner = travelplanner.NewTravelplannerClient(&travelplanner.TravelPlannerConfig{
+			APIKey:  cfg.TravelPlannerAPIKey,
+			BaseURL: urls.TravelPlanner,
+		}, clientFor(cfg.RetryPolicies.TravelPlanner), travelPlannerOpts...)
+	}
+	if cfg.StopsAPIKey != "" || cfg.KeyPools.Stops != nil {
+		c.Stops = stops.NewClient(&stops.Config{
+			APIKey:  cfg.StopsAPIKey,
+			BaseURL: urls.Stops,
+		}, clientFor(cfg.RetryPolicies.Stops), stopsOpts...)
+	}
+	if cfg.StopsNearbyAPIKey != "" || cfg.KeyPools.StopsNearby != nil {
+		c.StopsNearby = stopsnearby.NewClient(&stopsnearby.Config{
+			APIKey:  cfg.StopsNearbyAPIKey,
+			BaseURL: urls.StopsNearby,
+		}, clientFor(cfg.RetryPolicies.StopsNearby), stopsNearbyOpts...)
+	}
+	if cfg.TrafficStatusAPIKey != "" || cfg.KeyPools.TrafficStatus != nil {
+		c.TrafficStatus = trafficstatus.NewClient(&trafficstatus.Config{
+			APIKey:  cfg.TrafficStatusAPIKey,
+			BaseURL: urls.TrafficStatus,
+		}, clientFor(cfg.RetryPolicies.TrafficStatus), trafficStatusOpts...)
+		var networkStatusOpts []networkstatus.Option
+		if cfg.Clock != nil {
+			networkStatusOpts = append(networkStatusOpts, networkstatus.WithClock(cfg.Clock))
+		}
+		c.NetworkStatus = networkstatus.NewClient(c.TrafficStatus, c.Deviations, networkStatusOpts...)
+	}
+	if cfg.GTFSRegionalAPIKey != "" || cfg.GTFSSwedenAPIKey != "" {
+		var gtfsOpts []gtfs.Option
+		if cfg.Debug && cfg.Logger == nil {
+			gtfsOpts = append(gtfsOpts, gtfs.WithDebug())
+		}
+		c.GTFS = gtfs.NewClient(&gtfs.Config{
+			BaseURL:        urls.GTFS,
+			RegionalAPIKey: cfg.GTFSRegionalAPIKey,
+			SwedenAPIKey:   cfg.GTFSSwedenAPIKey,
+		}, clientFor(cfg.RetryPolicies.GTFS), gtfsOpts...)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,211 @@
+// Package vcr records HTTP interactions of the clients into golden files
+// and replays them, so decoding can be regression tested without api keys.
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/nobina/go-trafiklab/requests"
+)
+
+type Mode int
+
+const (
+	// ModeReplay serves responses from the golden file and fails requests
+	// that weren't recorded.
+	ModeReplay Mode = iota
+	// ModeRecord makes real requests and records them.
+	ModeRecord
+)
+
+const redacted = "REDACTED"
+
+// ModeFromEnv returns ModeRecord if the environment variable name is set
+// to "record", otherwise ModeReplay.
+func ModeFromEnv(name string) Mode {
+	if os.Getenv(name) == "record" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+type Interaction struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// Recorder records or replays the interactions in one golden file.
+type Recorder struct {
+	mu           sync.Mutex
+	path         string
+	mode         Mode
+	redactParams []string
+	interactions []Interaction
+	replayed     map[int]bool
+}
+
+type Option func(*Recorder)
+
+// WithRedactedParams redacts additional query parameters. The "key"
+// parameter is always redacted.
+func WithRedactedParams(params ...string) Option {
+	return func(r *Recorder) {
+		r.redactParams = append(r.redactParams, params...)
+	}
+}
+
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:         path,
+		mode:         mode,
+		redactParams: []string{"key"},
+		replayed:     map[int]bool{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == ModeReplay {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read golden file: %w", err)
+		}
+		if err := json.Unmarshal(b, &r.interactions); err != nil {
+			return nil, fmt.Errorf("failed to decode golden file: %w", err)
+		}
+	}
+
+	return r, nil
+}
+
+func (r *Recorder) redact(u *url.URL) string {
+	q := u.Query()
+	for _, p := range r.redactParams {
+		if q.Has(p) {
+			q.Set(p, redacted)
+		}
+	}
+	c := *u
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// Middleware records or replays every request depending on the mode.
+func (r *Recorder) Middleware() requests.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return requests.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if r.mode == ModeReplay {
+				return r.replay(req)
+			}
+			return r.record(next, req)
+		})
+	}
+}
+
+// Client returns a copy of client using the recorder.
+func (r *Recorder) Client(client *http.Client) *http.Client {
+	return requests.WrapClient(client, r.Middleware())
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	u := r.redact(req.URL)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.interactions {
+		if r.replayed[i] || in.Method != req.Method || in.URL != u {
+			continue
+		}
+		r.replayed[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.StatusCode, http.StatusText(in.StatusCode)),
+			StatusCode:    in.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewBufferString(in.Body)),
+			ContentLength: int64(len(in.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded interaction for %s %s", req.Method, u)
+}
+
+func (r *Recorder) record(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	res, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(b))
+
+	header := res.Header.Clone()
+	header.Del("Set-Cookie")
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Method:     req.Method,
+		URL:        r.redact(req.URL),
+		StatusCode: res.StatusCode,
+		Header:     header,
+		Body:       string(b),
+	})
+	r.mu.Unlock()
+
+	return res, nil
+}
+
+// Save writes the recorded interactions to the golden file. It does
+// nothing in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode == ModeReplay {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode interactions: %w", err)
+	}
+	if err := os.WriteFile(r.path, b, 0o644); err != nil {
+		return fmt.Errorf("failed to write golden file: %w", err)
+	}
+	return nil
+}
+
+// RecordEnv is the environment variable that makes Golden record instead
+// of replay when set to "record".
+const RecordEnv = "TRAFIKLAB_RECORD"
+
+// Golden returns client replaying the golden file at path, or recording
+// it when RecordEnv is set. Recordings are saved when the test ends.
+func Golden(t testing.TB, path string, client *http.Client, opts ...Option) *http.Client {
+	t.Helper()
+	r, err := New(path, ModeFromEnv(RecordEnv), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := r.Save(); err != nil {
+			t.Error(err)
+		}
+	})
+	return r.Client(client)
+}
@@ -0,0 +1,61 @@
+package vcr_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nobina/go-trafiklab/vcr"
+)
+
+func TestRecordReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"site":"` + r.URL.Query().Get("site") + `"}`))
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "golden.json")
+
+	rec, err := vcr.New(path, vcr.ModeRecord, vcr.WithRedactedParams("accessId"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := rec.Client(srv.Client())
+	for _, q := range []string{"site=1&key=secret", "site=2&accessId=secret"} {
+		res, err := client.Get(srv.URL + "/v1/messages?" + q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	rep, err := vcr.New(path, vcr.ModeReplay, vcr.WithRedactedParams("accessId"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = rep.Client(http.DefaultClient)
+	for _, tc := range []struct{ query, want string }{
+		{"site=2&accessId=other", `{"site":"2"}`},
+		{"site=1&key=other", `{"site":"1"}`},
+	} {
+		res, err := client.Get(srv.URL + "/v1/messages?" + tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if string(b) != tc.want {
+			t.Fatalf("replay of %s = %s, want %s", tc.query, b, tc.want)
+		}
+	}
+	if _, err := client.Get(srv.URL + "/v1/messages?site=1&key=other"); err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Fatalf("second replay of a single recording = %v, want an error", err)
+	}
+}